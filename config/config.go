@@ -1,8 +1,15 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -10,9 +17,14 @@ import (
 
 type Config struct {
 	// Server
-	ServerPort string
+	ServerPort      string
+	Environment     string        // APP_ENV, "production" refuses to start with an unsafe encryption key
+	ShutdownTimeout time.Duration // Time allowed to drain requests on SIGTERM
+	AllowedOrigins  []string      // CORS and WebSocket origins, "*" allows any, empty allows all with a warning
 
 	// Database
+	DBDriver   string
+	DBPath     string
 	DBHost     string
 	DBPort     string
 	DBUser     string
@@ -20,49 +32,317 @@ type Config struct {
 	DBName     string
 
 	// SSH
-	SSHTimeout   time.Duration
-	SSHKeepAlive time.Duration
+	SSHTimeout        time.Duration
+	SSHKeepAlive      time.Duration
+	SSHCommandTimeout time.Duration // Default limit for interactive commands
+	MaxCommandOutput  int64         // Bytes of stdout plus stderr kept per command, 0 is unlimited
+	MaxSSHConnections int           // Pooled connections before idle ones are evicted, 0 is unlimited
+	FanOutConcurrency int           // Servers a multi-server command runs on at once
+	SSHPreset         string        // Algorithm preset for servers without their own: default or legacy
+	SSHCiphers        []string      // Override the preset's lists when set
+	SSHKeyExchanges   []string
+	SSHMACs           []string
+	SSHKeyDir         string // Directory holding the key and ssh_config files servers may name, empty allows none
 
 	// Monitoring
-	MetricsInterval time.Duration
+	MetricsInterval     time.Duration
+	CustomMetricTimeout time.Duration     // Upper bound for each custom metric command
+	MetricCommands      map[string]string // Collector command overrides by name, servers can override
+
+	// Command policy (regular expressions)
+	CommandAllowPatterns []string
+	CommandDenyPatterns  []string
+	ServerRateLimit      float64 // Command/SFTP requests per second per server, 0 disables
+	ServerRateBurst      int
+	LogTailDirs          []string // Directories whose files may be tailed
+
+	// SFTP
+	UploadConcurrency  int
+	MaxEditFileSize    int64 // Bytes, limit for the text editor read/write endpoints
+	UploadQuota        int64 // Bytes per upload request, servers can override, 0 is unlimited
+	UploadMaxFileSize  int64 // Bytes per uploaded file, 0 is unlimited
+	MaxUploadSize      int64 // Bytes per upload request body, 0 is unlimited
+	MaxMultipartMemory int64 // Bytes of an upload kept in memory, the rest is spooled to temp files
 
 	// Security
-	EncryptionKey string
+	EncryptionKey     string
+	EncryptionOldKeys []string // Previous keys, still accepted for decryption
 
 	// WebSocket
-	WSPingInterval time.Duration
-	WSPongWait     time.Duration
+	WSPingInterval       time.Duration
+	WSPongWait           time.Duration
+	WSSendBuffer         int           // Messages queued per client before drops count
+	WSWriteTimeout       time.Duration // Deadline for each message write
+	WSReadBuffer         int           // Upgrader I/O buffer sizes in bytes
+	WSWriteBuffer        int
+	WSMaxDroppedMessages int
+	WSMaxPerIP           int // Open WebSocket connections per client IP, 0 is unlimited
+	WSDeltaMode          bool
+
+	// Logging
+	LogLevel      string // debug, info, warning or error
+	LogFormat     string
+	LogFile       string
+	LogMaxSizeMB  int
+	LogMaxBackups int
+	LogStdout     bool
+	// Regular expressions whose last group, or whole match without groups,
+	// is masked in log messages, added to the built-in patterns
+	LogRedactPatterns []string
+}
+
+// EncryptionKeySize is the length in bytes of an AES-256 key
+const EncryptionKeySize = 32
+
+// defaultEncryptionKey is published with the source code. It keeps a fresh
+// checkout usable but must never protect real credentials.
+const defaultEncryptionKey = "3nC_rYpT!8t2vKp#6Lq1zWm9x4Dg7HsQ"
+
+// current is read from many goroutines, a new snapshot replaces it whole
+var current atomic.Pointer[Config]
+
+// writeMu serializes Reload and Update so neither loses the other's change
+var writeMu sync.Mutex
+
+// restartOnly lists settings that are consumed once at startup. A reload
+// keeps their running value and reports them as ignored.
+var restartOnly = []string{
+	"ServerPort", "Environment",
+	"DBDriver", "DBPath", "DBHost", "DBPort", "DBUser", "DBPassword", "DBName",
+	"CommandAllowPatterns", "CommandDenyPatterns",
+	"EncryptionKey", "EncryptionOldKeys",
+	"LogFormat", "LogFile", "LogMaxSizeMB", "LogMaxBackups", "LogStdout", "LogRedactPatterns",
+}
+
+// Change describes a setting whose value differs after a reload
+type Change struct {
+	Field string
+	Old   interface{}
+	New   interface{}
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Field, c.Old, c.New)
 }
 
-var AppConfig *Config
+// dotenvKeys are the variables that came from .env rather than the process
+// environment, so a reload may overwrite or remove them
+var dotenvKeys = make(map[string]bool)
 
 func Load() error {
-	if err := godotenv.Load(); err != nil {
+	loadDotenv()
+
+	cfg := read()
+	if err := validateEncryptionKeys(cfg); err != nil {
+		return err
+	}
+
+	current.Store(cfg)
+	return nil
+}
+
+// IsProduction reports whether APP_ENV is production
+func (c *Config) IsProduction() bool {
+	return strings.EqualFold(c.Environment, "production")
+}
+
+// validateEncryptionKeys rejects keys AES-256 cannot use, so credentials are
+// never written with a key that fails later. The built-in default is only
+// tolerated outside production.
+func validateEncryptionKeys(cfg *Config) error {
+	const hint = "generate one with `openssl rand -base64 24`"
+
+	if cfg.EncryptionKey == "" {
+		return fmt.Errorf("ENCRYPTION_KEY is empty, %s", hint)
+	}
+	if len(cfg.EncryptionKey) != EncryptionKeySize {
+		return fmt.Errorf("ENCRYPTION_KEY must be %d bytes, got %d; %s", EncryptionKeySize, len(cfg.EncryptionKey), hint)
+	}
+	for i, key := range cfg.EncryptionOldKeys {
+		if len(key) != EncryptionKeySize {
+			return fmt.Errorf("ENCRYPTION_OLD_KEYS entry %d must be %d bytes, got %d", i+1, EncryptionKeySize, len(key))
+		}
+	}
+
+	if cfg.EncryptionKey == defaultEncryptionKey {
+		if cfg.IsProduction() {
+			return errors.New("ENCRYPTION_KEY is the built-in default, refusing to start with APP_ENV=production; " + hint)
+		}
+		log.Printf("WARNING: ENCRYPTION_KEY is not set, server passwords are encrypted with the publicly known default key; %s", hint)
+	}
+	return nil
+}
+
+// Get returns the current configuration. The returned value must not be
+// modified, a reload replaces it as a whole.
+func Get() *Config {
+	return current.Load()
+}
+
+// Reload re-reads .env and the environment and swaps in the new
+// configuration. It returns the settings that changed and the restart-only
+// settings whose new value was ignored.
+func Reload() ([]Change, []string) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	loadDotenv()
+
+	previous := Get()
+	next := read()
+
+	oldValue := reflect.ValueOf(previous).Elem()
+	newValue := reflect.ValueOf(next).Elem()
+
+	var ignored []string
+	for _, field := range restartOnly {
+		if !reflect.DeepEqual(oldValue.FieldByName(field).Interface(), newValue.FieldByName(field).Interface()) {
+			ignored = append(ignored, field)
+			newValue.FieldByName(field).Set(oldValue.FieldByName(field))
+		}
+	}
+
+	var changes []Change
+	for i := 0; i < newValue.NumField(); i++ {
+		before, after := oldValue.Field(i).Interface(), newValue.Field(i).Interface()
+		if !reflect.DeepEqual(before, after) {
+			changes = append(changes, Change{Field: newValue.Type().Field(i).Name, Old: before, New: after})
+		}
+	}
+
+	current.Store(next)
+	return changes, ignored
+}
+
+// Update applies fn to a copy of the current configuration and swaps the
+// copy in, for settings changed by the application itself
+func Update(fn func(*Config)) {
+	writeMu.Lock()
+	defer writeMu.Unlock()
+
+	next := *Get()
+	fn(&next)
+	current.Store(&next)
+}
+
+// loadDotenv applies .env without overriding variables set in the process
+// environment. Values that came from .env are refreshed on every call.
+func loadDotenv() {
+	values, err := godotenv.Read()
+	if err != nil {
 		// No .env file, use defaults or env vars
+		values = nil
+	}
+
+	for key := range dotenvKeys {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+			delete(dotenvKeys, key)
+		}
+	}
+
+	for key, value := range values {
+		if _, exists := os.LookupEnv(key); exists && !dotenvKeys[key] {
+			continue
+		}
+		os.Setenv(key, value)
+		dotenvKeys[key] = true
 	}
+}
 
+// read builds a configuration from the environment
+func read() *Config {
+	shutdownTimeout, _ := strconv.Atoi(getEnv("SHUTDOWN_TIMEOUT", "30"))
 	sshTimeout, _ := strconv.Atoi(getEnv("SSH_TIMEOUT", "30"))
 	sshKeepAlive, _ := strconv.Atoi(getEnv("SSH_KEEPALIVE", "60"))
+	sshCommandTimeout, _ := strconv.Atoi(getEnv("SSH_COMMAND_TIMEOUT", "60"))
+	maxCommandOutput, _ := strconv.ParseInt(getEnv("MAX_COMMAND_OUTPUT", "10485760"), 10, 64)
+	maxSSHConnections, _ := strconv.Atoi(getEnv("MAX_SSH_CONNECTIONS", "1000"))
+	fanOutConcurrency, _ := strconv.Atoi(getEnv("FAN_OUT_CONCURRENCY", "10"))
 	metricsInterval, _ := strconv.Atoi(getEnv("METRICS_INTERVAL", "10"))
+	customMetricTimeout, _ := strconv.Atoi(getEnv("CUSTOM_METRIC_TIMEOUT", "5"))
 	wsPingInterval, _ := strconv.Atoi(getEnv("WS_PING_INTERVAL", "30"))
 	wsPongWait, _ := strconv.Atoi(getEnv("WS_PONG_WAIT", "60"))
+	wsSendBuffer, _ := strconv.Atoi(getEnv("WS_SEND_BUFFER", "256"))
+	wsWriteTimeout, _ := strconv.Atoi(getEnv("WS_WRITE_TIMEOUT", "10"))
+	wsReadBuffer, _ := strconv.Atoi(getEnv("WS_READ_BUFFER", "1024"))
+	wsWriteBuffer, _ := strconv.Atoi(getEnv("WS_WRITE_BUFFER", "1024"))
+	wsMaxDropped, _ := strconv.Atoi(getEnv("WS_MAX_DROPPED", "10"))
+	wsMaxPerIP, _ := strconv.Atoi(getEnv("WS_MAX_PER_IP", "20"))
+	wsDeltaMode, _ := strconv.ParseBool(getEnv("WS_DELTA_MODE", "false"))
+	serverRateLimit, _ := strconv.ParseFloat(getEnv("SERVER_RATE_LIMIT", "0"), 64)
+	serverRateBurst, _ := strconv.Atoi(getEnv("SERVER_RATE_BURST", "10"))
+	uploadConcurrency, _ := strconv.Atoi(getEnv("UPLOAD_CONCURRENCY", "4"))
+	maxEditFileSize, _ := strconv.ParseInt(getEnv("MAX_EDIT_FILE_SIZE", "20971520"), 10, 64)
+	uploadQuota, _ := strconv.ParseInt(getEnv("UPLOAD_QUOTA", "0"), 10, 64)
+	uploadMaxFileSize, _ := strconv.ParseInt(getEnv("UPLOAD_MAX_FILE_SIZE", "0"), 10, 64)
+	maxUploadSize, _ := strconv.ParseInt(getEnv("MAX_UPLOAD_SIZE", "10737418240"), 10, 64)
+	maxMultipartMemory, _ := strconv.ParseInt(getEnv("MAX_MULTIPART_MEMORY", "33554432"), 10, 64)
+	logMaxSize, _ := strconv.Atoi(getEnv("LOG_MAX_SIZE_MB", "100"))
+	logMaxBackups, _ := strconv.Atoi(getEnv("LOG_MAX_BACKUPS", "5"))
+	logStdout, _ := strconv.ParseBool(getEnv("LOG_STDOUT", "true"))
 
-	AppConfig = &Config{
-		ServerPort:      getEnv("SERVER_PORT", "8080"),
-		DBHost:          getEnv("DB_HOST", "localhost"),
-		DBPort:          getEnv("DB_PORT", "3306"),
-		DBUser:          getEnv("DB_USER", "root"),
-		DBPassword:      getEnv("DB_PASSWORD", ""),
-		DBName:          getEnv("DB_NAME", "Suap"),
-		SSHTimeout:      time.Duration(sshTimeout) * time.Second,
-		SSHKeepAlive:    time.Duration(sshKeepAlive) * time.Second,
-		MetricsInterval: time.Duration(metricsInterval) * time.Second,
-		EncryptionKey:   getEnv("ENCRYPTION_KEY", "3nC_rYpT!8t2vKp#6Lq1zWm9x4Dg7HsQ"),
-		WSPingInterval:  time.Duration(wsPingInterval) * time.Second,
-		WSPongWait:      time.Duration(wsPongWait) * time.Second,
+	logTailDirs := getEnvList("LOG_TAIL_DIRS")
+	if len(logTailDirs) == 0 {
+		logTailDirs = []string{"/var/log"}
 	}
 
-	return nil
+	return &Config{
+		ServerPort:           getEnv("SERVER_PORT", "8080"),
+		Environment:          getEnv("APP_ENV", "development"),
+		ShutdownTimeout:      time.Duration(shutdownTimeout) * time.Second,
+		AllowedOrigins:       getEnvList("ALLOWED_ORIGINS"),
+		DBDriver:             getEnv("DB_DRIVER", "mysql"),
+		DBPath:               getEnv("DB_PATH", "monitoring.db"),
+		DBHost:               getEnv("DB_HOST", "localhost"),
+		DBPort:               getEnv("DB_PORT", "3306"),
+		DBUser:               getEnv("DB_USER", "root"),
+		DBPassword:           getEnv("DB_PASSWORD", ""),
+		DBName:               getEnv("DB_NAME", "Suap"),
+		SSHTimeout:           time.Duration(sshTimeout) * time.Second,
+		SSHKeepAlive:         time.Duration(sshKeepAlive) * time.Second,
+		SSHCommandTimeout:    time.Duration(sshCommandTimeout) * time.Second,
+		MaxCommandOutput:     maxCommandOutput,
+		MaxSSHConnections:    maxSSHConnections,
+		FanOutConcurrency:    atLeast("FAN_OUT_CONCURRENCY", fanOutConcurrency, 1),
+		SSHPreset:            getEnv("SSH_PRESET", "default"),
+		SSHCiphers:           getEnvList("SSH_CIPHERS"),
+		SSHKeyExchanges:      getEnvList("SSH_KEX"),
+		SSHMACs:              getEnvList("SSH_MACS"),
+		SSHKeyDir:            getEnv("SSH_KEY_DIR", ""),
+		MetricsInterval:      time.Duration(metricsInterval) * time.Second,
+		CustomMetricTimeout:  time.Duration(customMetricTimeout) * time.Second,
+		MetricCommands:       metricCommands(),
+		CommandAllowPatterns: getEnvList("COMMAND_ALLOW_PATTERNS"),
+		CommandDenyPatterns:  getEnvList("COMMAND_DENY_PATTERNS"),
+		ServerRateLimit:      serverRateLimit,
+		ServerRateBurst:      serverRateBurst,
+		LogTailDirs:          logTailDirs,
+		UploadConcurrency:    uploadConcurrency,
+		MaxEditFileSize:      maxEditFileSize,
+		UploadQuota:          uploadQuota,
+		UploadMaxFileSize:    uploadMaxFileSize,
+		MaxUploadSize:        maxUploadSize,
+		MaxMultipartMemory:   int64(atLeast("MAX_MULTIPART_MEMORY", int(maxMultipartMemory), 1<<20)),
+		EncryptionOldKeys:    getEnvList("ENCRYPTION_OLD_KEYS"),
+		EncryptionKey:        getEnv("ENCRYPTION_KEY", defaultEncryptionKey),
+		WSPingInterval:       time.Duration(wsPingInterval) * time.Second,
+		WSPongWait:           time.Duration(wsPongWait) * time.Second,
+		WSSendBuffer:         atLeast("WS_SEND_BUFFER", wsSendBuffer, 16),
+		WSWriteTimeout:       time.Duration(atLeast("WS_WRITE_TIMEOUT", wsWriteTimeout, 1)) * time.Second,
+		WSReadBuffer:         atLeast("WS_READ_BUFFER", wsReadBuffer, 512),
+		WSWriteBuffer:        atLeast("WS_WRITE_BUFFER", wsWriteBuffer, 512),
+		WSMaxDroppedMessages: wsMaxDropped,
+		WSMaxPerIP:           wsMaxPerIP,
+		WSDeltaMode:          wsDeltaMode,
+		LogLevel:             getEnv("LOG_LEVEL", "info"),
+		LogFormat:            getEnv("LOG_FORMAT", "text"),
+		LogFile:              getEnv("LOG_FILE", ""),
+		LogMaxSizeMB:         logMaxSize,
+		LogMaxBackups:        logMaxBackups,
+		LogStdout:            logStdout,
+		LogRedactPatterns:    getEnvList("LOG_REDACT_PATTERNS"),
+	}
 }
 
 func getEnv(key, defaultValue string) string {
@@ -71,3 +351,34 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// atLeast raises value to min, warning that the configured value was too low
+func atLeast(key string, value, min int) int {
+	if value < min {
+		log.Printf("WARNING: %s=%d is below the minimum, using %d", key, value, min)
+		return min
+	}
+	return value
+}
+
+// metricCommands reads the METRIC_CMD_* collector overrides that are set
+func metricCommands() map[string]string {
+	commands := make(map[string]string)
+	for _, name := range []string{"cpu", "memory", "disk", "uptime"} {
+		if command := strings.TrimSpace(getEnv("METRIC_CMD_"+strings.ToUpper(name), "")); command != "" {
+			commands[name] = command
+		}
+	}
+	return commands
+}
+
+// getEnvList splits a comma-separated variable, ignoring empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
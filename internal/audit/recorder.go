@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"sync"
+	"time"
+
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+	"monitoring/internal/utils"
+)
+
+const (
+	bufferSize    = 1024
+	batchSize     = 100
+	flushInterval = 2 * time.Second
+)
+
+// Recorder persists audit entries in the background so request handling
+// never waits on the database
+type Recorder struct {
+	entries chan *models.AuditEntry
+	done    chan struct{}
+	closed  bool         // Set by Close, later entries are dropped
+	mu      sync.RWMutex // Guards closed against sends on the closed channel
+}
+
+var Log *Recorder
+
+// InitRecorder starts the background flusher
+func InitRecorder() {
+	Log = &Recorder{
+		entries: make(chan *models.AuditEntry, bufferSize),
+		done:    make(chan struct{}),
+	}
+	go Log.run()
+}
+
+// Record queues an entry, dropping it if the buffer is full or the
+// recorder has been closed
+func (r *Recorder) Record(entry *models.AuditEntry) {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		utils.AppLogger.Warning("Audit log closed, dropping %s entry for server %d", entry.Action, entry.ServerID)
+		return
+	}
+
+	select {
+	case r.entries <- entry:
+	default:
+		utils.AppLogger.Warning("Audit buffer full, dropping %s entry for server %d", entry.Action, entry.ServerID)
+	}
+}
+
+// Close flushes pending entries and stops the flusher. It is safe to call
+// more than once.
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.entries)
+	}
+	r.mu.Unlock()
+	<-r.done
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*models.AuditEntry, 0, batchSize)
+
+	for {
+		select {
+		case entry, ok := <-r.entries:
+			if !ok {
+				r.flush(batch)
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= batchSize {
+				r.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			r.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+func (r *Recorder) flush(batch []*models.AuditEntry) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := database.DB.Create(&batch).Error; err != nil {
+		utils.AppLogger.Error("Failed to write %d audit entries: %v", len(batch), err)
+	}
+}
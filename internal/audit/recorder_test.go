@@ -0,0 +1,19 @@
+package audit
+
+import (
+	"testing"
+
+	"monitoring/internal/models"
+	"monitoring/internal/utils"
+)
+
+func TestRecordAfterCloseIsDropped(t *testing.T) {
+	utils.InitLogger(utils.LogError + 1)
+	InitRecorder()
+
+	Log.Close()
+	Log.Close()
+
+	// Used to panic with a send on a closed channel
+	Log.Record(&models.AuditEntry{Action: models.AuditSSHCommand, ServerID: 1})
+}
@@ -15,13 +15,26 @@ import (
 
 var DB *gorm.DB
 
+// Init connects to the database selected by DB_DRIVER
+func Init() error {
+	switch config.Get().DBDriver {
+	case "sqlite":
+		return InitSQLite()
+	case "mysql", "":
+		return InitMySQL()
+	default:
+		return fmt.Errorf("unsupported database driver: %s", config.Get().DBDriver)
+	}
+}
+
 func InitMySQL() error {
+	cfg := config.Get()
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		config.AppConfig.DBUser,
-		config.AppConfig.DBPassword,
-		config.AppConfig.DBHost,
-		config.AppConfig.DBPort,
-		config.AppConfig.DBName,
+		cfg.DBUser,
+		cfg.DBPassword,
+		cfg.DBHost,
+		cfg.DBPort,
+		cfg.DBName,
 	)
 
 	var err error
@@ -46,7 +59,8 @@ func InitMySQL() error {
 }
 
 func AutoMigrate() error {
-	err := DB.AutoMigrate(&models.Server{})
+	err := DB.AutoMigrate(&models.Server{}, &models.ServerGroup{}, &models.AuditEntry{}, &models.CustomMetric{},
+		&models.ScheduledJob{}, &models.JobRun{}, &models.StatusEvent{})
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
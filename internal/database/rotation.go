@@ -0,0 +1,73 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+	"monitoring/internal/utils"
+)
+
+// RotateEncryptionKey re-encrypts every stored server password, including
+// jump host and sudo passwords, from oldKey to newKey in a single transaction
+// and returns the number of servers updated.
+// Values already encrypted with newKey are left untouched.
+func RotateEncryptionKey(oldKey, newKey string) (int, error) {
+	if len(newKey) != config.EncryptionKeySize {
+		return 0, fmt.Errorf("new encryption key must be %d bytes", config.EncryptionKeySize)
+	}
+
+	keys := append([]string{oldKey}, config.Get().EncryptionOldKeys...)
+	count := 0
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var servers []models.Server
+		if err := tx.Unscoped().Find(&servers).Error; err != nil {
+			return err
+		}
+
+		for _, server := range servers {
+			updates := map[string]interface{}{}
+			for column, value := range map[string]string{
+				"password":      server.Password,
+				"jump_password": server.JumpPassword,
+				"sudo_password": server.SudoPassword,
+			} {
+				if value == "" {
+					continue
+				}
+
+				plaintext, err := utils.DecryptWithKeys(value, keys)
+				if err != nil {
+					if _, rotated := utils.DecryptWithKeys(value, []string{newKey}); rotated == nil {
+						continue
+					}
+					return fmt.Errorf("failed to decrypt %s of server %d: %w", column, server.ID, err)
+				}
+
+				encrypted, err := utils.EncryptWithKey(plaintext, newKey)
+				if err != nil {
+					return err
+				}
+				updates[column] = encrypted
+			}
+
+			if len(updates) == 0 {
+				continue
+			}
+			if err := tx.Unscoped().Model(&models.Server{}).Where("id = ?", server.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	utils.AppLogger.Info("Re-encrypted credentials of %d servers with key %s", count, utils.KeyID(newKey))
+	return count, nil
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+	"monitoring/internal/utils"
+)
+
+const (
+	oldTestKey = "0123456789abcdef0123456789abcdef"
+	newTestKey = "fedcba9876543210fedcba9876543210"
+)
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	utils.InitLogger(utils.LogError + 1)
+
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/test.db"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	DB = db
+	if err := AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func encryptForTest(t *testing.T, plaintext, key string, legacy bool) string {
+	t.Helper()
+	ciphertext, err := utils.EncryptWithKey(plaintext, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy {
+		// Values stored before key IDs existed have no "<id>$" prefix
+		_, ciphertext, _ = strings.Cut(ciphertext, "$")
+	}
+	return ciphertext
+}
+
+func TestRotateEncryptionKey(t *testing.T) {
+	openTestDB(t)
+
+	servers := []models.Server{
+		{Name: "prefixed", IPAddress: "10.0.0.1", Password: encryptForTest(t, "one", oldTestKey, false),
+			SudoPassword: encryptForTest(t, "sudo-one", oldTestKey, false)},
+		{Name: "legacy", IPAddress: "10.0.0.2", Password: encryptForTest(t, "two", oldTestKey, true),
+			JumpPassword: encryptForTest(t, "jump-two", oldTestKey, true)},
+		{Name: "rotated", IPAddress: "10.0.0.3", Password: encryptForTest(t, "three", newTestKey, false)},
+	}
+	if err := DB.Create(&servers).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := RotateEncryptionKey(oldTestKey, newTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("rotated %d servers, want 2", count)
+	}
+
+	want := map[string]map[string]string{
+		"prefixed": {"password": "one", "sudo_password": "sudo-one"},
+		"legacy":   {"password": "two", "jump_password": "jump-two"},
+		"rotated":  {"password": "three"},
+	}
+	var stored []models.Server
+	if err := DB.Find(&stored).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, server := range stored {
+		values := map[string]string{
+			"password":      server.Password,
+			"jump_password": server.JumpPassword,
+			"sudo_password": server.SudoPassword,
+		}
+		for column, plaintext := range want[server.Name] {
+			if !strings.HasPrefix(values[column], utils.KeyID(newTestKey)+"$") {
+				t.Errorf("%s %s = %q, not encrypted with the new key", server.Name, column, values[column])
+			}
+			got, err := utils.DecryptWithKeys(values[column], []string{newTestKey})
+			if err != nil || got != plaintext {
+				t.Errorf("%s %s decrypts to %q, %v; want %q", server.Name, column, got, err, plaintext)
+			}
+		}
+	}
+}
+
+func TestRotateEncryptionKeyWrongOldKey(t *testing.T) {
+	openTestDB(t)
+
+	server := models.Server{Name: "legacy", IPAddress: "10.0.0.2", Password: encryptForTest(t, "two", oldTestKey, true)}
+	if err := DB.Create(&server).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RotateEncryptionKey("ffffffffffffffffffffffffffffffff", newTestKey); err == nil {
+		t.Fatal("rotation with the wrong old key succeeded")
+	}
+
+	var stored models.Server
+	if err := DB.First(&stored, server.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Password != server.Password {
+		t.Error("password changed although the rotation failed")
+	}
+}
+
+func TestRotateEncryptionKeySize(t *testing.T) {
+	openTestDB(t)
+
+	for _, key := range []string{newTestKey[:config.EncryptionKeySize-1], newTestKey + "0"} {
+		_, err := RotateEncryptionKey(oldTestKey, key)
+		if err == nil || !strings.Contains(err.Error(), "32 bytes") {
+			t.Errorf("%d byte key: error = %v, want a 32 byte requirement", len(key), err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"fmt"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"monitoring/config"
+	"monitoring/internal/utils"
+)
+
+// InitSQLite opens the SQLite database file configured by DB_PATH
+func InitSQLite() error {
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on", config.Get().DBPath)
+
+	var err error
+	DB, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Info),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	// SQLite allows a single writer; one connection avoids "database is locked" errors
+	sqlDB.SetMaxOpenConns(1)
+	sqlDB.SetMaxIdleConns(1)
+
+	utils.AppLogger.Info("Opened SQLite database at %s", config.Get().DBPath)
+	return nil
+}
@@ -0,0 +1,135 @@
+package exporter
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+
+	"monitoring/internal/models"
+	"monitoring/internal/monitor"
+	"monitoring/internal/ssh"
+	"monitoring/internal/websocket"
+)
+
+const namespace = "servmon"
+
+// serverLabels keeps cardinality bounded to one series per server
+var serverLabels = []string{"server_id", "server"}
+
+// serverGauge reads a single value from the last collected snapshot
+type serverGauge struct {
+	desc  *prometheus.Desc
+	value func(m *models.MetricSnapshot) float64
+}
+
+func newServerGauge(name, help string, value func(m *models.MetricSnapshot) float64) serverGauge {
+	return serverGauge{
+		desc:  prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, serverLabels, nil),
+		value: value,
+	}
+}
+
+// Collector exposes the worker pool's last known snapshots. Scrapes never
+// trigger SSH calls.
+type Collector struct {
+	gauges        []serverGauge
+	up            *prometheus.Desc
+	lastCollected *prometheus.Desc
+	reconnects    *prometheus.Desc
+	failures      *prometheus.Desc
+	sshClients    *prometheus.Desc
+	sshEvictions  *prometheus.Desc
+	wsClients     *prometheus.Desc
+	workers       *prometheus.Desc
+}
+
+var Registry *prometheus.Registry
+
+// InitRegistry creates the registry served on /metrics
+func InitRegistry() {
+	Registry = prometheus.NewRegistry()
+	Registry.MustRegister(
+		NewCollector(),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+func NewCollector() *Collector {
+	desc := func(name, help string, labels []string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), help, labels, nil)
+	}
+
+	return &Collector{
+		gauges: []serverGauge{
+			newServerGauge("cpu_usage", "CPU usage percentage", func(m *models.MetricSnapshot) float64 { return m.CPUUsage }),
+			newServerGauge("mem_total_megabytes", "Total memory in MB", func(m *models.MetricSnapshot) float64 { return float64(m.MemTotal) }),
+			newServerGauge("mem_used_megabytes", "Used memory in MB", func(m *models.MetricSnapshot) float64 { return float64(m.MemUsed) }),
+			newServerGauge("mem_usage", "Memory usage percentage", func(m *models.MetricSnapshot) float64 { return m.MemPercent }),
+			newServerGauge("disk_total_gigabytes", "Total disk space in GB", func(m *models.MetricSnapshot) float64 { return float64(m.DiskTotal) }),
+			newServerGauge("disk_used_gigabytes", "Used disk space in GB", func(m *models.MetricSnapshot) float64 { return float64(m.DiskUsed) }),
+			newServerGauge("disk_usage", "Disk usage percentage", func(m *models.MetricSnapshot) float64 { return m.DiskPercent }),
+			newServerGauge("net_rx_bytes_per_second", "Network receive throughput", func(m *models.MetricSnapshot) float64 { return m.NetRXRate }),
+			newServerGauge("net_tx_bytes_per_second", "Network transmit throughput", func(m *models.MetricSnapshot) float64 { return m.NetTXRate }),
+			newServerGauge("uptime_seconds", "Server uptime in seconds", func(m *models.MetricSnapshot) float64 { return float64(m.Uptime) }),
+		},
+		up:            desc("worker_up", "Whether the monitoring worker is running", serverLabels),
+		lastCollected: desc("last_collected_timestamp_seconds", "Time of the last successful collection", serverLabels),
+		reconnects:    desc("worker_reconnects_total", "SSH reconnects performed by the worker", serverLabels),
+		failures:      desc("worker_failures_total", "Failed connections and collections of the worker", serverLabels),
+		sshClients:    desc("ssh_clients", "Pooled SSH connections", nil),
+		sshEvictions:  desc("ssh_evictions_total", "Idle SSH connections closed to stay within MAX_SSH_CONNECTIONS", nil),
+		wsClients:     desc("websocket_clients", "Connected WebSocket clients", nil),
+		workers:       desc("workers", "Monitoring workers", nil),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, g := range c.gauges {
+		ch <- g.desc
+	}
+	ch <- c.up
+	ch <- c.lastCollected
+	ch <- c.reconnects
+	ch <- c.failures
+	ch <- c.sshClients
+	ch <- c.sshEvictions
+	ch <- c.wsClients
+	ch <- c.workers
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	if monitor.Pool != nil {
+		states := monitor.Pool.States()
+		ch <- prometheus.MustNewConstMetric(c.workers, prometheus.GaugeValue, float64(len(states)))
+
+		for _, state := range states {
+			labels := []string{strconv.FormatUint(uint64(state.ServerID), 10), state.ServerName}
+
+			up := 0.0
+			if state.Running {
+				up = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up, labels...)
+			ch <- prometheus.MustNewConstMetric(c.reconnects, prometheus.CounterValue, float64(state.Reconnects), labels...)
+			ch <- prometheus.MustNewConstMetric(c.failures, prometheus.CounterValue, float64(state.Failures), labels...)
+
+			if state.LastMetrics == nil {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.lastCollected, prometheus.GaugeValue, float64(state.LastMetrics.Timestamp), labels...)
+			for _, g := range c.gauges {
+				ch <- prometheus.MustNewConstMetric(g.desc, prometheus.GaugeValue, g.value(state.LastMetrics), labels...)
+			}
+		}
+	}
+
+	if ssh.Pool != nil {
+		ch <- prometheus.MustNewConstMetric(c.sshClients, prometheus.GaugeValue, float64(ssh.Pool.Count()))
+		ch <- prometheus.MustNewConstMetric(c.sshEvictions, prometheus.CounterValue, float64(ssh.Pool.Evictions()))
+	}
+	if websocket.Hub != nil {
+		ch <- prometheus.MustNewConstMetric(c.wsClients, prometheus.GaugeValue, float64(websocket.Hub.GetClientCount()))
+	}
+}
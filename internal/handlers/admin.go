@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/config"
+	"monitoring/internal/database"
+	"monitoring/internal/utils"
+)
+
+type RotateKeyRequest struct {
+	OldKey string `json:"old_key" binding:"required"`
+	NewKey string `json:"new_key" binding:"required"`
+}
+
+// RotateEncryptionKey re-encrypts stored credentials with a new key. The old
+// key must match the running configuration.
+func RotateEncryptionKey(c *gin.Context) {
+	var req RotateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(req.OldKey), []byte(config.Get().EncryptionKey)) != 1 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Old key does not match the current encryption key"})
+		return
+	}
+
+	count, err := database.RotateEncryptionKey(req.OldKey, req.NewKey)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Switch the running process over; the old key stays readable until restart
+	config.Update(func(cfg *config.Config) {
+		cfg.EncryptionOldKeys = append(append([]string(nil), cfg.EncryptionOldKeys...), req.OldKey)
+		cfg.EncryptionKey = req.NewKey
+	})
+	utils.AppLogger.Warning("Encryption key rotated, update ENCRYPTION_KEY and ENCRYPTION_OLD_KEYS before restarting")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Encryption key rotated",
+		"rotated": count,
+		"key_id":  utils.KeyID(req.NewKey),
+	})
+}
+
+// GenerateEncryptionKey returns a new random key for ENCRYPTION_KEY or for
+// RotateEncryptionKey. It is not stored or applied.
+func GenerateEncryptionKey(c *gin.Context) {
+	key, err := utils.GenerateEncryptionKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"key":    key,
+		"key_id": utils.KeyID(key),
+	})
+}
+
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required"` // debug, info, warning or error
+}
+
+// GetLogLevel returns the level the logger currently writes from
+func GetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"level": utils.AppLogger.Level().String()})
+}
+
+// SetLogLevel changes the log level of the running process. It lasts until
+// a restart, or a configuration reload that changes LOG_LEVEL.
+func SetLogLevel(c *gin.Context) {
+	var req LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	level, err := utils.ParseLogLevel(req.Level)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "level"})
+		return
+	}
+
+	previous := utils.AppLogger.Level()
+	utils.AppLogger.SetLevel(level)
+	// Logged as a warning so the change shows at any level
+	utils.AppLogger.Warning("Log level changed from %s to %s by %s", previous, level, c.ClientIP())
+
+	c.JSON(http.StatusOK, gin.H{
+		"level":    level.String(),
+		"previous": previous.String(),
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/internal/audit"
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+)
+
+// recordAudit queues an audit entry for the server in the request path
+func recordAudit(c *gin.Context, action models.AuditAction, target string, success bool) {
+	serverID, _ := strconv.ParseUint(c.Param("serverId"), 10, 32)
+	recordServerAudit(c, uint(serverID), action, target, success)
+}
+
+// recordServerAudit queues an audit entry for an explicit server
+func recordServerAudit(c *gin.Context, serverID uint, action models.AuditAction, target string, success bool) {
+	audit.Log.Record(&models.AuditEntry{
+		Actor:    c.ClientIP(),
+		ServerID: serverID,
+		Action:   action,
+		Target:   target,
+		Success:  success,
+	})
+}
+
+// GetAuditLog returns audit entries filtered by server, action and time range
+func GetAuditLog(c *gin.Context) {
+	query := database.DB.Model(&models.AuditEntry{})
+
+	if serverID := c.Query("server_id"); serverID != "" {
+		id, err := strconv.ParseUint(serverID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+			return
+		}
+		query = query.Where("server_id = ?", id)
+	}
+
+	if action := c.Query("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+
+	if from := c.Query("from"); from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' time, expected RFC3339"})
+			return
+		}
+		query = query.Where("timestamp >= ?", t)
+	}
+
+	if to := c.Query("to"); to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' time, expected RFC3339"})
+			return
+		}
+		query = query.Where("timestamp <= ?", t)
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	var entries []models.AuditEntry
+	if err := query.Order("timestamp DESC").Limit(limit).Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"total":   len(entries),
+	})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+	"monitoring/internal/ssh"
+)
+
+// GetCustomMetrics returns the custom metrics defined for a server
+func GetCustomMetrics(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return
+	}
+
+	var metrics []models.CustomMetric
+	if err := database.DB.Where("server_id = ?", id).Order("name").Find(&metrics).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch custom metrics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"metrics": metrics,
+		"total":   len(metrics),
+	})
+}
+
+// CreateCustomMetric adds a custom metric command to a server
+func CreateCustomMetric(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return
+	}
+
+	if err := database.DB.First(&models.Server{}, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
+		return
+	}
+
+	var req models.CustomMetricRequest
+	if !bindCustomMetric(c, &req) {
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.CustomMetric{}).Where("server_id = ? AND name = ?", id, req.Name).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Custom metric already exists"})
+		return
+	}
+
+	metric := &models.CustomMetric{
+		ServerID:  uint(id),
+		Name:      req.Name,
+		Command:   req.Command,
+		ParseType: req.ParseType,
+		Timeout:   req.Timeout,
+	}
+
+	if err := database.DB.Create(metric).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create custom metric"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, metric)
+}
+
+// UpdateCustomMetric replaces the definition of a custom metric
+func UpdateCustomMetric(c *gin.Context) {
+	metric, ok := findCustomMetric(c)
+	if !ok {
+		return
+	}
+
+	var req models.CustomMetricRequest
+	if !bindCustomMetric(c, &req) {
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.CustomMetric{}).
+		Where("server_id = ? AND name = ? AND id <> ?", metric.ServerID, req.Name, metric.ID).
+		Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Custom metric already exists"})
+		return
+	}
+
+	metric.Name = req.Name
+	metric.Command = req.Command
+	metric.ParseType = req.ParseType
+	metric.Timeout = req.Timeout
+
+	if err := database.DB.Save(metric).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update custom metric"})
+		return
+	}
+
+	c.JSON(http.StatusOK, metric)
+}
+
+// DeleteCustomMetric removes a custom metric
+func DeleteCustomMetric(c *gin.Context) {
+	metric, ok := findCustomMetric(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DB.Delete(metric).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete custom metric"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Custom metric deleted"})
+}
+
+// findCustomMetric loads the :metricId metric of the :id server
+func findCustomMetric(c *gin.Context) (*models.CustomMetric, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return nil, false
+	}
+	metricID, err := strconv.ParseUint(c.Param("metricId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid metric ID"})
+		return nil, false
+	}
+
+	var metric models.CustomMetric
+	if err := database.DB.Where("server_id = ?", id).First(&metric, metricID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Custom metric not found"})
+		return nil, false
+	}
+	return &metric, true
+}
+
+// bindCustomMetric validates a custom metric definition. Commands go through
+// the same policy as interactive ones since they run on every tick.
+func bindCustomMetric(c *gin.Context, req *models.CustomMetricRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if req.ParseType == "" {
+		req.ParseType = models.CustomParseNumber
+	}
+	if req.ParseType != models.CustomParseNumber && req.ParseType != models.CustomParseString {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "parse_type must be number or string"})
+		return false
+	}
+	if req.Timeout < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "timeout must not be negative"})
+		return false
+	}
+
+	if allowed, rule := ssh.Policy.Check(req.Command); !allowed {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Command not allowed",
+			"rule":  rule,
+		})
+		return false
+	}
+	return true
+}
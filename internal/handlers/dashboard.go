@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/config"
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+	"monitoring/internal/monitor"
+	ws "monitoring/internal/websocket"
+)
+
+// GetDashboardSummary returns server counts by status and fleet-wide
+// averages. Averages come from the workers' last snapshots so the endpoint
+// never contacts servers and is cheap to poll.
+func GetDashboardSummary(c *gin.Context) {
+	var rows []struct {
+		Status models.ServerStatus
+		Count  int64
+	}
+	if err := database.DB.Model(&models.Server{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count servers"})
+		return
+	}
+
+	byStatus := map[models.ServerStatus]int64{
+		models.StatusOnline:      0,
+		models.StatusOffline:     0,
+		models.StatusError:       0,
+		models.StatusMaintenance: 0,
+		models.StatusDisabled:    0,
+	}
+	var total int64
+	for _, row := range rows {
+		byStatus[row.Status] += row.Count
+		total += row.Count
+	}
+
+	// Snapshots older than a few intervals belong to servers that stopped reporting
+	staleBefore := time.Now().Add(-3 * config.Get().MetricsInterval).Unix()
+
+	var activeWorkers, reporting int
+	var cpuSum, memSum float64
+	for _, state := range monitor.Pool.States() {
+		if state.Running {
+			activeWorkers++
+		}
+		if state.Paused || state.LastMetrics == nil || state.LastMetrics.Timestamp < staleBefore {
+			continue
+		}
+		reporting++
+		cpuSum += state.LastMetrics.CPUUsage
+		memSum += state.LastMetrics.MemPercent
+	}
+
+	var avgCPU, avgMem float64
+	if reporting > 0 {
+		avgCPU = cpuSum / float64(reporting)
+		avgMem = memSum / float64(reporting)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_servers":     total,
+		"by_status":         byStatus,
+		"active_workers":    activeWorkers,
+		"websocket_clients": ws.Hub.GetClientCount(),
+		"avg_cpu_usage":     avgCPU,
+		"avg_mem_percent":   avgMem,
+		"reporting_servers": reporting,
+		"timestamp":         time.Now().Unix(),
+	})
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/config"
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+	"monitoring/internal/ratelimit"
+	"monitoring/internal/ssh"
+	"monitoring/internal/utils"
+	"monitoring/internal/winrm"
+)
+
+// maxFanOutServers bounds the servers one request can reach
+const maxFanOutServers = 500
+
+// FanOutRequest runs one command on several servers. They are picked by
+// server_ids, or else by tag and group_id, where disabled servers are left out.
+type FanOutRequest struct {
+	ServerIDs []uint            `json:"server_ids"`
+	Tag       string            `json:"tag"`
+	GroupID   *uint             `json:"group_id"`
+	Command   string            `json:"command" binding:"required"`
+	Timeout   int               `json:"timeout"` // Seconds per server, overrides SSH_COMMAND_TIMEOUT
+	Env       map[string]string `json:"env"`
+}
+
+// FanOutResult is the outcome of the command on one server. Error is set
+// when it could not run; a command that ran and failed has a non-zero
+// ExitCode instead.
+type FanOutResult struct {
+	Name      string `json:"name,omitempty"`
+	Stdout    string `json:"stdout"`
+	Stderr    string `json:"stderr"`
+	ExitCode  int    `json:"exit_code"`
+	Truncated bool   `json:"truncated"`
+	Error     string `json:"error,omitempty"`
+}
+
+// ExecuteOnServers runs a command on several servers concurrently, at most
+// FAN_OUT_CONCURRENCY at a time, and returns the result per server ID. The
+// command policy is checked once; each server's rate limit and timeout apply
+// separately and a failing server does not stop the others.
+func ExecuteOnServers(c *gin.Context) {
+	var req FanOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := models.ValidateEnv(req.Env); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "env"})
+		return
+	}
+
+	if allowed, rule := ssh.Policy.Check(req.Command); !allowed {
+		utils.AppLogger.Warning("Command denied on multiple servers: %s (%s)", req.Command, rule)
+		recordAudit(c, models.AuditSSHCommand, req.Command, false)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Command not allowed",
+			"rule":  rule,
+		})
+		return
+	}
+
+	servers, results, ok := fanOutServers(c, &req)
+	if !ok {
+		return
+	}
+
+	timeout := config.Get().SSHCommandTimeout
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Second
+	}
+
+	outcomes := make([]FanOutResult, len(servers))
+	concurrency := config.Get().FanOutConcurrency
+	if concurrency > len(servers) {
+		concurrency = len(servers)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				outcomes[i] = runOnServer(c, &servers[i], &req, timeout)
+			}
+		}()
+	}
+	for i := range servers {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	succeeded := 0
+	for i, server := range servers {
+		results[server.ID] = outcomes[i]
+		if outcomes[i].Error == "" && outcomes[i].ExitCode == 0 {
+			succeeded++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"command":   req.Command,
+		"results":   results,
+		"total":     len(results),
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	})
+}
+
+// fanOutServers loads the servers a request targets. IDs that do not exist
+// are returned as failed results.
+func fanOutServers(c *gin.Context, req *FanOutRequest) ([]models.Server, map[uint]FanOutResult, bool) {
+	results := make(map[uint]FanOutResult)
+	var servers []models.Server
+
+	if len(req.ServerIDs) > 0 {
+		if len(req.ServerIDs) > maxFanOutServers {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d servers can be targeted at once", maxFanOutServers)})
+			return nil, nil, false
+		}
+		if err := database.DB.Where("id IN ?", req.ServerIDs).Find(&servers).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch servers"})
+			return nil, nil, false
+		}
+
+		found := make(map[uint]bool, len(servers))
+		for _, server := range servers {
+			found[server.ID] = true
+		}
+		for _, id := range req.ServerIDs {
+			if !found[id] {
+				results[id] = FanOutResult{Error: "Server not found"}
+			}
+		}
+		return servers, results, true
+	}
+
+	tag := models.NormalizeTag(req.Tag)
+	if tag == "" && req.GroupID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "server_ids, tag or group_id is required"})
+		return nil, nil, false
+	}
+
+	query := database.DB.Where("enabled = ?", true)
+	if tag != "" {
+		query = query.Where("tags LIKE ?", "%,"+tag+",%")
+	}
+	if req.GroupID != nil {
+		query = query.Where("group_id = ?", *req.GroupID)
+	}
+	if err := query.Order("id").Limit(maxFanOutServers + 1).Find(&servers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch servers"})
+		return nil, nil, false
+	}
+	if len(servers) > maxFanOutServers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("more than %d servers match, narrow the selection", maxFanOutServers)})
+		return nil, nil, false
+	}
+	if len(servers) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No servers match"})
+		return nil, nil, false
+	}
+	return servers, results, true
+}
+
+// runOnServer runs the command on one server the way ExecuteSSHCommand
+// does, without a working directory, and records it in the audit log
+func runOnServer(c *gin.Context, server *models.Server, req *FanOutRequest, timeout time.Duration) FanOutResult {
+	result := FanOutResult{Name: server.Name}
+
+	if allowed, delay := ratelimit.Servers.Allow(server); !allowed {
+		result.Error = fmt.Sprintf("Too many requests for this server, retry after %ds", ratelimit.RetryAfterSeconds(delay))
+		return result
+	}
+
+	password, err := utils.Decrypt(server.Password)
+	if err != nil {
+		result.Error = "Failed to decrypt credentials"
+		return result
+	}
+
+	utils.AppLogger.Info("Comando ejecutado en servidor %d: %s", server.ID, req.Command)
+	if server.UsesWinRM() {
+		client, err := winrm.Pool.GetClient(server, password)
+		if err != nil {
+			result.Error = "Failed to connect to server"
+			return result
+		}
+		output, err := client.ExecuteWithTimeout(req.Command, timeout)
+		recordServerAudit(c, server.ID, models.AuditSSHCommand, req.Command, err == nil)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		result.Stdout = strings.ReplaceAll(output, "\r\n", "\n")
+		return result
+	}
+
+	client, err := ssh.Pool.GetClient(server, password)
+	if err != nil {
+		result.Error = "Failed to connect to server"
+		return result
+	}
+
+	command, input, err := client.PrepareSudo(req.Command)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	output, err := client.ExecuteDetailed(command, input, req.Env, timeout)
+	if err == nil {
+		err = ssh.SudoResultError(output)
+	}
+	recordServerAudit(c, server.ID, models.AuditSSHCommand, req.Command, err == nil && output.ExitCode == 0)
+
+	if errors.Is(err, ssh.ErrCommandTimeout) {
+		result.Error = fmt.Sprintf("command timed out after %v", timeout)
+		return result
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Stdout = output.Stdout
+	result.Stderr = output.Stderr
+	result.ExitCode = output.ExitCode
+	result.Truncated = output.Truncated
+	return result
+}
@@ -6,31 +6,109 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"monitoring/config"
 	"monitoring/internal/database"
+	"monitoring/internal/monitor"
+	"monitoring/internal/ssh"
+	ws "monitoring/internal/websocket"
 )
 
 var startTime = time.Now()
 
+// Component statuses, ordered from best to worst
+const (
+	componentOK       = "ok"
+	componentDegraded = "degraded"
+	componentError    = "error"
+)
+
+var componentSeverity = map[string]int{
+	componentOK:       0,
+	componentDegraded: 1,
+	componentError:    2,
+}
+
+// HealthCheck returns the app status. The overall status is the worst of the
+// components: healthy, degraded or unhealthy.
 func HealthCheck(c *gin.Context) {
-	dbStatus := "ok"
-	sqlDB, err := database.DB.DB()
-	if err != nil || sqlDB.Ping() != nil {
-		dbStatus = "error"
+	dbStatus := componentOK
+	if database.DB == nil {
+		dbStatus = componentError
+	} else if sqlDB, err := database.DB.DB(); err != nil || sqlDB.Ping() != nil {
+		dbStatus = componentError
+	}
+
+	components := gin.H{
+		"database":    gin.H{"status": dbStatus},
+		"websocket":   websocketHealth(),
+		"worker_pool": workerPoolHealth(),
+		"ssh_pool":    sshPoolHealth(),
+	}
+
+	worst := componentOK
+	for _, component := range components {
+		if s := component.(gin.H)["status"].(string); componentSeverity[s] > componentSeverity[worst] {
+			worst = s
+		}
 	}
 
 	status := "healthy"
-	if dbStatus == "error" {
+	switch worst {
+	case componentDegraded:
+		status = "degraded"
+	case componentError:
 		status = "unhealthy"
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status":    status,
-		"uptime":    time.Since(startTime).String(),
-		"database":  dbStatus,
-		"timestamp": time.Now().Unix(),
+		"status":     status,
+		"uptime":     time.Since(startTime).String(),
+		"database":   dbStatus,
+		"components": components,
+		"timestamp":  time.Now().Unix(),
 	})
 }
 
+func websocketHealth() gin.H {
+	if ws.Hub == nil || !ws.Hub.IsRunning() {
+		return gin.H{"status": componentError, "clients": 0}
+	}
+	return gin.H{"status": componentOK, "clients": ws.Hub.GetClientCount(), "rooms": ws.Hub.GetRoomCount()}
+}
+
+// workerPoolHealth is degraded when some registered workers have stopped
+func workerPoolHealth() gin.H {
+	if monitor.Pool == nil {
+		return gin.H{"status": componentError, "active_workers": 0, "workers": 0}
+	}
+
+	states := monitor.Pool.States()
+	active := 0
+	for _, state := range states {
+		if state.Running {
+			active++
+		}
+	}
+
+	status := componentOK
+	if active < len(states) {
+		status = componentDegraded
+	}
+	return gin.H{"status": status, "active_workers": active, "workers": len(states)}
+}
+
+func sshPoolHealth() gin.H {
+	if ssh.Pool == nil {
+		return gin.H{"status": componentError, "connections": 0}
+	}
+	return gin.H{
+		"status":          componentOK,
+		"connections":     ssh.Pool.Count(),
+		"max_connections": config.Get().MaxSSHConnections,
+		"evictions":       ssh.Pool.Evictions(),
+	}
+}
+
 // ReadyCheck returns whether the app is ready
 func ReadyCheck(c *gin.Context) {
 	sqlDB, err := database.DB.DB()
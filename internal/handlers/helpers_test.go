@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"monitoring/config"
+	"monitoring/internal/audit"
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+	"monitoring/internal/ratelimit"
+	"monitoring/internal/sftp"
+	"monitoring/internal/ssh"
+	"monitoring/internal/ssh/sshtest"
+	"monitoring/internal/utils"
+)
+
+// setupTestServer initializes the database and pools the handlers use and
+// stores a server backed by an in-process SSH server. Settings read from the
+// environment must be set with t.Setenv before calling it.
+func setupTestServer(t *testing.T) models.Server {
+	t.Helper()
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	utils.InitLogger(utils.LogError + 1)
+	gin.SetMode(gin.TestMode)
+
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/test.db"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.DB = db
+	if err := database.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	audit.InitRecorder()
+	ratelimit.InitServerLimiter()
+	if err := ssh.InitPolicy(); err != nil {
+		t.Fatal(err)
+	}
+	ssh.InitPool()
+	sftp.InitPool()
+	t.Cleanup(func() {
+		sftp.Pool.CloseAll()
+		ssh.Pool.CloseAll()
+		audit.Log.Close()
+	})
+
+	sshServer := sshtest.NewServer(t, "127.0.0.1:0")
+	password, err := utils.Encrypt(sshtest.Password)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := models.Server{
+		Name:      "test",
+		IPAddress: sshServer.Host,
+		Port:      sshServer.Port,
+		Username:  sshtest.User,
+		Password:  password,
+		Enabled:   true,
+	}
+	if err := database.DB.Create(&server).Error; err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+// serveTest runs handler for req with the serverId path parameter set
+func serveTest(handler gin.HandlerFunc, serverID uint, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+	c.Params = gin.Params{{Key: "serverId", Value: strconv.FormatUint(uint64(serverID), 10)}}
+	handler(c)
+	return w
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+	"monitoring/internal/scheduler"
+	"monitoring/internal/ssh"
+)
+
+// GetScheduledJobs returns the scheduled jobs of a server
+func GetScheduledJobs(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return
+	}
+
+	var jobs []models.ScheduledJob
+	if err := database.DB.Where("server_id = ?", id).Order("name").Find(&jobs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch scheduled jobs"})
+		return
+	}
+	for i := range jobs {
+		jobs[i].NextRunAt = scheduler.Jobs.NextRun(jobs[i].ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"total": len(jobs),
+	})
+}
+
+// CreateScheduledJob adds a scheduled command to a server
+func CreateScheduledJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return
+	}
+
+	var server models.Server
+	if err := database.DB.First(&server, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
+		return
+	}
+	if server.UsesWinRM() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Scheduled jobs are only supported on SSH servers"})
+		return
+	}
+
+	var req models.ScheduledJobRequest
+	if !bindScheduledJob(c, &req) {
+		return
+	}
+
+	job := &models.ScheduledJob{
+		ServerID: uint(id),
+		Name:     req.Name,
+		Schedule: req.Schedule,
+		Command:  req.Command,
+		Timeout:  req.Timeout,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+
+	if err := database.DB.Create(job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create scheduled job"})
+		return
+	}
+	if !job.Enabled {
+		// Create leaves false to the column default of true
+		database.DB.Model(job).Update("enabled", false)
+	}
+
+	scheduler.Jobs.Set(*job)
+	job.NextRunAt = scheduler.Jobs.NextRun(job.ID)
+	c.JSON(http.StatusCreated, job)
+}
+
+// UpdateScheduledJob replaces the definition of a scheduled job
+func UpdateScheduledJob(c *gin.Context) {
+	job, ok := findScheduledJob(c)
+	if !ok {
+		return
+	}
+
+	var req models.ScheduledJobRequest
+	if !bindScheduledJob(c, &req) {
+		return
+	}
+
+	job.Name = req.Name
+	job.Schedule = req.Schedule
+	job.Command = req.Command
+	job.Timeout = req.Timeout
+	if req.Enabled != nil {
+		job.Enabled = *req.Enabled
+	}
+
+	if err := database.DB.Save(job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update scheduled job"})
+		return
+	}
+
+	scheduler.Jobs.Set(*job)
+	job.NextRunAt = scheduler.Jobs.NextRun(job.ID)
+	c.JSON(http.StatusOK, job)
+}
+
+// DeleteScheduledJob removes a scheduled job and its run history
+func DeleteScheduledJob(c *gin.Context) {
+	job, ok := findScheduledJob(c)
+	if !ok {
+		return
+	}
+
+	scheduler.Jobs.Remove(job.ID)
+
+	if err := database.DB.Delete(job).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete scheduled job"})
+		return
+	}
+	database.DB.Where("job_id = ?", job.ID).Delete(&models.JobRun{})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Scheduled job deleted"})
+}
+
+// RunScheduledJob runs a job now, whether or not it is enabled, and returns
+// the result. A job that is already running is not started again.
+func RunScheduledJob(c *gin.Context) {
+	job, ok := findScheduledJob(c)
+	if !ok {
+		return
+	}
+
+	run, err := scheduler.Jobs.RunNow(*job, c.ClientIP())
+	if errors.Is(err, scheduler.ErrJobRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// GetJobRuns returns the most recent runs of a job, newest first
+func GetJobRuns(c *gin.Context) {
+	job, ok := findScheduledJob(c)
+	if !ok {
+		return
+	}
+
+	limit := scheduler.MaxRunsKept
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l < limit {
+		limit = l
+	}
+
+	var runs []models.JobRun
+	if err := database.DB.Where("job_id = ?", job.ID).Order("id DESC").Limit(limit).Find(&runs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch job runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"runs":  runs,
+		"total": len(runs),
+	})
+}
+
+// findScheduledJob loads the :jobId job of the :id server
+func findScheduledJob(c *gin.Context) (*models.ScheduledJob, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return nil, false
+	}
+	jobID, err := strconv.ParseUint(c.Param("jobId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return nil, false
+	}
+
+	var job models.ScheduledJob
+	if err := database.DB.Where("server_id = ?", id).First(&job, jobID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Scheduled job not found"})
+		return nil, false
+	}
+	job.NextRunAt = scheduler.Jobs.NextRun(job.ID)
+	return &job, true
+}
+
+// bindScheduledJob validates a job definition, including its schedule and
+// the command policy
+func bindScheduledJob(c *gin.Context, req *models.ScheduledJobRequest) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	if _, err := scheduler.Parse(req.Schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule: " + err.Error(), "field": "schedule"})
+		return false
+	}
+	if req.Timeout < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "timeout must not be negative"})
+		return false
+	}
+
+	if allowed, rule := ssh.Policy.Check(req.Command); !allowed {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Command not allowed",
+			"rule":  rule,
+		})
+		return false
+	}
+	return true
+}
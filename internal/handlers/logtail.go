@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"monitoring/config"
+	"monitoring/internal/sftp"
+	"monitoring/internal/ssh"
+	"monitoring/internal/utils"
+	ws "monitoring/internal/websocket"
+)
+
+const (
+	defaultTailLines = 50
+	maxTailLines     = 1000
+)
+
+// tailControl is a control message sent by the client:
+//
+//	{"type": "tail_start", "path": "/var/log/syslog", "lines": 100}
+//	{"type": "tail_stop"}
+//
+// Each followed line is sent back as
+//
+//	{"type": "log_line", "payload": {"path": "/var/log/syslog", "line": "..."}}
+type tailControl struct {
+	Type  ws.MessageType `json:"type"`
+	Path  string         `json:"path"`
+	Lines int            `json:"lines"`
+}
+
+// tailSession follows at most one file for a WebSocket connection
+type tailSession struct {
+	conn    *websocket.Conn
+	client  *ssh.SSHClient
+	writeMu sync.Mutex
+	cancel  context.CancelFunc
+}
+
+// TailLogWebSocket streams new lines of a remote log file
+func TailLogWebSocket(c *gin.Context) {
+	if !acquireWSConnection(c) {
+		return
+	}
+	defer ws.Hub.ReleaseConnection(c.RemoteIP())
+
+	client, err := getSSHClient(c)
+	if err != nil {
+		c.JSON(clientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := wsUpgrader().Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		utils.AppLogger.Error("Failed to upgrade to WebSocket: %v", err)
+		return
+	}
+
+	session := &tailSession{conn: conn, client: client}
+	defer func() {
+		session.stop()
+		conn.Close()
+	}()
+
+	for {
+		var msg tailControl
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case ws.MessageTypeTailStart:
+			session.start(msg.Path, msg.Lines)
+		case ws.MessageTypeTailStop:
+			session.stop()
+		default:
+			session.send(ws.MessageTypeError, gin.H{"error": "Unknown message type"})
+		}
+	}
+}
+
+// start validates path and replaces any running tail with a new one
+func (t *tailSession) start(requested string, lines int) {
+	path, err := resolveTailPath(t.client, requested)
+	if err != nil {
+		t.send(ws.MessageTypeError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if lines <= 0 {
+		lines = defaultTailLines
+	}
+	if lines > maxTailLines {
+		lines = maxTailLines
+	}
+
+	t.stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	go func() {
+		err := t.client.ExecuteStream(ctx, ssh.TailCommand(path, lines), func(line string) {
+			t.send(ws.MessageTypeLogLine, gin.H{"path": path, "line": line})
+		})
+		if err != nil {
+			t.send(ws.MessageTypeError, gin.H{"error": err.Error()})
+		}
+	}()
+}
+
+func (t *tailSession) stop() {
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+}
+
+// send serializes writes, gorilla connections allow a single writer
+func (t *tailSession) send(msgType ws.MessageType, payload interface{}) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	t.conn.SetWriteDeadline(time.Now().Add(config.Get().WSWriteTimeout))
+	t.conn.WriteJSON(ws.Message{Type: msgType, Payload: payload})
+}
+
+// resolveTailPath jails the path to the server root and LOG_TAIL_DIRS
+func resolveTailPath(client *ssh.SSHClient, requested string) (string, error) {
+	path, err := sftp.SanitizePath(client.Server.RootPath, requested)
+	if err != nil {
+		return "", err
+	}
+
+	for _, dir := range config.Get().LogTailDirs {
+		if sftp.IsWithin(dir, path) && path != dir {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("path is not in an allowed log directory")
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/internal/ssh"
+)
+
+// GetServerContainers returns the Docker containers running on a server
+func GetServerContainers(c *gin.Context) {
+	client, err := getSSHClient(c)
+	if err != nil {
+		c.JSON(clientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	containers, available, err := ssh.NewMetricCollector(client).CollectDockerContainers()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"docker_available": available,
+		"containers":       containers,
+		"total":            len(containers),
+	})
+}
+
+// GetServerPorts returns the listening TCP and UDP sockets of a server and
+// their owning processes. processes_hidden is set when some owners could not
+// be seen, which needs root or use_sudo.
+func GetServerPorts(c *gin.Context) {
+	client, err := getSSHClient(c)
+	if err != nil {
+		c.JSON(clientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ports, err := ssh.NewMetricCollector(client).CollectOpenPorts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	hidden := false
+	for _, port := range ports {
+		hidden = hidden || port.Process == ""
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ports":            ports,
+		"total":            len(ports),
+		"processes_hidden": hidden,
+	})
+}
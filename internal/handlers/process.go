@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/internal/ssh"
+	"monitoring/internal/utils"
+)
+
+// ProcessList returns the top CPU consuming processes of a server
+func ProcessList(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit <= 0 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Limit must be between 1 and 200"})
+		return
+	}
+
+	client, err := getSSHClient(c)
+	if err != nil {
+		c.JSON(clientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	processes, err := ssh.NewMetricCollector(client).CollectTopProcesses(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"processes": processes,
+		"total":     len(processes),
+	})
+}
+
+// KillProcess sends a signal (default SIGTERM) to a process on a server
+func KillProcess(c *gin.Context) {
+	pid, err := strconv.Atoi(c.Param("pid"))
+	if err != nil || pid <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid PID"})
+		return
+	}
+
+	signal, err := ssh.NormalizeSignal(c.Query("signal"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	command := ssh.KillCommand(pid, signal)
+	if allowed, rule := ssh.Policy.Check(command); !allowed {
+		utils.AppLogger.Warning("Kill denied on server %s: %s (%s)", c.Param("serverId"), command, rule)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Command not allowed",
+			"rule":  rule,
+		})
+		return
+	}
+
+	client, err := getSSHClient(c)
+	if err != nil {
+		c.JSON(clientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := client.KillProcess(pid, signal); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  "Failed to kill process",
+			"detail": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Signal sent",
+		"pid":     pid,
+		"signal":  "SIG" + signal,
+	})
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"monitoring/internal/exporter"
+)
+
+// PrometheusMetrics serves the exporter registry in the Prometheus text format
+func PrometheusMetrics(c *gin.Context) {
+	promhttp.HandlerFor(exporter.Registry, promhttp.HandlerOpts{}).ServeHTTP(c.Writer, c.Request)
+}
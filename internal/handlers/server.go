@@ -1,21 +1,137 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"monitoring/internal/database"
 	"monitoring/internal/models"
 	"monitoring/internal/monitor"
+	"monitoring/internal/ratelimit"
+	"monitoring/internal/scheduler"
+	"monitoring/internal/ssh"
 	"monitoring/internal/utils"
+	ws "monitoring/internal/websocket"
 )
 
-// GetServers returns all servers
+// serverSortColumns maps the sort query values of GetServers to columns
+var serverSortColumns = map[string]string{
+	"name":    "name",
+	"status":  "status",
+	"created": "created_at",
+}
+
+// serverStatuses are the values accepted by the status filter of GetServers
+var serverStatuses = map[models.ServerStatus]bool{
+	models.StatusOnline:      true,
+	models.StatusOffline:     true,
+	models.StatusError:       true,
+	models.StatusMaintenance: true,
+	models.StatusDisabled:    true,
+}
+
+// likeEscaper keeps user input from acting as LIKE wildcards
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// errJumpConflict rejects a server with both kinds of jump host settings
+const errJumpConflict = "proxy_jump and jump_host cannot both be set"
+
+// errAuthOrder rejects an unknown auth_order
+const errAuthOrder = "auth_order must be password, key or empty for key then password"
+
+const (
+	defaultServerPageSize = 50
+	maxServerPageSize     = 500
+)
+
+// GetServers returns servers, optionally filtered by tag, group, status or a
+// case-insensitive q matched against name, IP address and username. Sorting
+// uses sort (name, status, created) and order (asc, desc). Passing page or
+// page_size paginates the result; without them every server is returned.
 func GetServers(c *gin.Context) {
+	query := database.DB.Model(&models.Server{})
+
+	if tag := models.NormalizeTag(c.Query("tag")); tag != "" {
+		query = query.Where("tags LIKE ?", "%,"+tag+",%")
+	}
+
+	if groupID := c.Query("group_id"); groupID != "" {
+		id, err := strconv.ParseUint(groupID, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+			return
+		}
+		query = query.Where("group_id = ?", id)
+	}
+
+	if q := strings.ToLower(strings.TrimSpace(c.Query("q"))); q != "" {
+		pattern := "%" + likeEscaper.Replace(q) + "%"
+		query = query.Where("LOWER(name) LIKE ? ESCAPE '!' OR LOWER(ip_address) LIKE ? ESCAPE '!' OR LOWER(username) LIKE ? ESCAPE '!'",
+			pattern, pattern, pattern)
+	}
+
+	if status := c.Query("status"); status != "" {
+		if !serverStatuses[models.ServerStatus(status)] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	column := "id"
+	if sort := c.Query("sort"); sort != "" {
+		var ok bool
+		if column, ok = serverSortColumns[sort]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort, expected name, status or created"})
+			return
+		}
+	}
+	order := strings.ToLower(c.DefaultQuery("order", "asc"))
+	if order != "asc" && order != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order, expected asc or desc"})
+		return
+	}
+	query = query.Order(column + " " + order)
+	if column != "id" {
+		// Tie-break on id so pages stay stable when sort values repeat
+		query = query.Order("id")
+	}
+
+	paginated := c.Query("page") != "" || c.Query("page_size") != ""
+	page, pageSize := 1, defaultServerPageSize
+	if paginated {
+		var err error
+		if page, err = strconv.Atoi(c.DefaultQuery("page", "1")); err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+			return
+		}
+		if pageSize, err = strconv.Atoi(c.DefaultQuery("page_size", strconv.Itoa(defaultServerPageSize))); err != nil || pageSize < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page_size"})
+			return
+		}
+		if pageSize > maxServerPageSize {
+			pageSize = maxServerPageSize
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch servers"})
+		return
+	}
+
+	if paginated {
+		query = query.Offset((page - 1) * pageSize).Limit(pageSize)
+	}
+
 	var servers []models.Server
-	if err := database.DB.Find(&servers).Error; err != nil {
+	if err := query.Find(&servers).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch servers"})
 		return
 	}
@@ -25,10 +141,16 @@ func GetServers(c *gin.Context) {
 		dtos[i] = server.ToDTO()
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"servers": dtos,
-		"total":   len(dtos),
-	})
+		"total":   total,
+	}
+	if paginated {
+		response["page"] = page
+		response["page_size"] = pageSize
+		response["total_pages"] = (int(total) + pageSize - 1) / pageSize
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // GetServer returns a single server
@@ -48,6 +170,54 @@ func GetServer(c *gin.Context) {
 	c.JSON(http.StatusOK, server.ToDTO())
 }
 
+// GetServerInfo returns a server with the hostname, OS, kernel, architecture
+// and CPU model of the host. The details are cached after the first request;
+// refresh=true collects them again.
+func GetServerInfo(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return
+	}
+
+	var server models.Server
+	if err := database.DB.First(&server, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
+		return
+	}
+
+	if server.UsesWinRM() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "System info is only available for SSH servers"})
+		return
+	}
+
+	password, err := utils.Decrypt(server.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decrypt credentials"})
+		return
+	}
+
+	client, err := ssh.Pool.GetClient(&server, password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to server"})
+		return
+	}
+
+	info, err := client.SystemInfo(c.Query("refresh") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  "Failed to collect system info",
+			"detail": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server":      server.ToDTO(),
+		"system_info": info,
+	})
+}
+
 // CreateServer creates a new server
 func CreateServer(c *gin.Context) {
 	var req models.CreateServerRequest
@@ -62,8 +232,30 @@ func CreateServer(c *gin.Context) {
 		return
 	}
 
-	if req.Port == "" {
-		req.Port = "22"
+	if req.IPAddress, err = models.NormalizeHost(req.IPAddress); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "ip_address"})
+		return
+	}
+	if req.Port, err = models.NormalizePort(req.Port); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "port"})
+		return
+	}
+	if !req.AllowDuplicate {
+		// Soft-deleted servers are excluded, so their address can be reused
+		var existing models.Server
+		err := database.DB.Where("ip_address = ? AND port = ? AND username = ?", req.IPAddress, req.Port, req.Username).
+			First(&existing).Error
+		if err == nil {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":       "A server with this address, port and username already exists",
+				"existing_id": existing.ID,
+			})
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicates"})
+			return
+		}
 	}
 	if req.Sys == "" {
 		req.Sys = models.SysLinux
@@ -71,29 +263,134 @@ func CreateServer(c *gin.Context) {
 	if req.Connection == "" {
 		req.Connection = models.ConnSSH
 	}
+	var encryptedJumpPassword string
+	if req.JumpHost != "" {
+		if req.JumpHost, err = models.NormalizeHost(req.JumpHost); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "jump_host"})
+			return
+		}
+		if req.JumpPort, err = models.NormalizePort(req.JumpPort); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "jump_port"})
+			return
+		}
+		if req.JumpPassword != "" {
+			if encryptedJumpPassword, err = utils.Encrypt(req.JumpPassword); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt password"})
+				return
+			}
+		}
+	}
+	if req.ProxyJump, err = models.NormalizeProxyJump(req.ProxyJump); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "proxy_jump"})
+		return
+	}
+	if req.ProxyJump != "" && req.JumpHost != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errJumpConflict, "field": "proxy_jump"})
+		return
+	}
+	req.SSHConfigPath = strings.TrimSpace(req.SSHConfigPath)
+	if req.SSHConfigPath != "" {
+		if err := ssh.ValidateSSHConfig(req.SSHConfigPath); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "ssh_config_path"})
+			return
+		}
+	}
+	req.KeyPath = strings.TrimSpace(req.KeyPath)
+	if req.KeyPath != "" {
+		if err := ssh.ValidateKey(req.KeyPath); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "key_path"})
+			return
+		}
+	}
+	if !ssh.IsAuthOrder(req.AuthOrder) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errAuthOrder, "field": "auth_order"})
+		return
+	}
+	if !ssh.IsAlgorithmPreset(req.SSHPreset) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ssh_preset must be default or legacy", "field": "ssh_preset"})
+		return
+	}
+	if err := models.ValidateShell(req.Shell); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "shell"})
+		return
+	}
+	if err := models.ValidateEnv(req.Env); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "env"})
+		return
+	}
+	if err := models.ValidateMetricCommands(req.MetricCommands); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "metric_commands"})
+		return
+	}
+
+	var encryptedSudoPassword string
+	if req.SudoPassword != "" {
+		if encryptedSudoPassword, err = utils.Encrypt(req.SudoPassword); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt password"})
+			return
+		}
+	}
+	if req.GroupID != nil {
+		if err := database.DB.First(&models.ServerGroup{}, *req.GroupID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Group not found"})
+			return
+		}
+	}
 
 	server := &models.Server{
-		IPAddress:  req.IPAddress,
-		Password:   encryptedPassword,
-		Port:       req.Port,
-		Sys:        req.Sys,
-		Connection: req.Connection,
-		Username:   req.Username,
-		Name:       req.Name,
-		Status:     models.StatusOffline,
+		IPAddress:      req.IPAddress,
+		Password:       encryptedPassword,
+		Port:           req.Port,
+		Sys:            req.Sys,
+		Connection:     req.Connection,
+		Username:       req.Username,
+		Name:           req.Name,
+		RootPath:       req.RootPath,
+		NetInterface:   req.NetInterface,
+		GroupID:        req.GroupID,
+		JumpHost:       req.JumpHost,
+		JumpPort:       req.JumpPort,
+		JumpUser:       req.JumpUser,
+		JumpPassword:   encryptedJumpPassword,
+		ProxyJump:      req.ProxyJump,
+		SSHConfigPath:  req.SSHConfigPath,
+		KeyPath:        req.KeyPath,
+		AuthOrder:      req.AuthOrder,
+		UseSudo:        req.UseSudo,
+		SudoPassword:   encryptedSudoPassword,
+		SSHPreset:      req.SSHPreset,
+		RateLimit:      req.RateLimit,
+		RateBurst:      req.RateBurst,
+		UploadQuota:    req.UploadQuota,
+		Shell:          strings.TrimSpace(req.Shell),
+		Env:            req.Env,
+		MetricCommands: req.MetricCommands,
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		ReadOnly:       req.ReadOnly,
+		TLSSkipVerify:  req.TLSSkipVerify,
+		Status:         models.StatusOffline,
+	}
+	if !server.Enabled {
+		server.Status = models.StatusDisabled
 	}
+	server.SetTags(req.Tags)
 
 	if err := database.DB.Create(server).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create server"})
 		return
 	}
+	monitor.RecordStatusChange(server.ID, "", server.Status)
 
-	// Start monitoring worker
-	if err := monitor.Pool.AddWorker(server, req.Password); err != nil {
+	if !server.Enabled {
+		// Create leaves false to the column default of true
+		database.DB.Model(server).Update("enabled", false)
+	} else if err := monitor.Pool.AddWorker(server, req.Password); err != nil {
 		utils.AppLogger.Warning("Failed to start monitoring: %v", err)
 	}
 
-	c.JSON(http.StatusCreated, server.ToDTO())
+	dto := server.ToDTO()
+	ws.Hub.BroadcastServerEvent(ws.MessageTypeServerAdded, dto)
+	c.JSON(http.StatusCreated, dto)
 }
 
 // UpdateServer updates an existing server
@@ -117,7 +414,10 @@ func UpdateServer(c *gin.Context) {
 	}
 
 	if req.IPAddress != "" {
-		server.IPAddress = req.IPAddress
+		if server.IPAddress, err = models.NormalizeHost(req.IPAddress); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "ip_address"})
+			return
+		}
 	}
 	if req.Password != "" {
 		encryptedPassword, err := utils.Encrypt(req.Password)
@@ -128,7 +428,10 @@ func UpdateServer(c *gin.Context) {
 		server.Password = encryptedPassword
 	}
 	if req.Port != "" {
-		server.Port = req.Port
+		if server.Port, err = models.NormalizePort(req.Port); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "port"})
+			return
+		}
 	}
 	if req.Sys != "" {
 		server.Sys = req.Sys
@@ -142,23 +445,188 @@ func UpdateServer(c *gin.Context) {
 	if req.Name != "" {
 		server.Name = req.Name
 	}
+	if req.RootPath != nil {
+		server.RootPath = *req.RootPath
+	}
+	if req.NetInterface != nil {
+		server.NetInterface = *req.NetInterface
+	}
+	if req.Tags != nil {
+		server.SetTags(*req.Tags)
+	}
+	if req.JumpHost != nil {
+		server.JumpHost = ""
+		if *req.JumpHost != "" {
+			if server.JumpHost, err = models.NormalizeHost(*req.JumpHost); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "jump_host"})
+				return
+			}
+		}
+	}
+	if req.JumpPort != nil {
+		if server.JumpPort, err = models.NormalizePort(*req.JumpPort); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "jump_port"})
+			return
+		}
+	}
+	if req.JumpUser != nil {
+		server.JumpUser = *req.JumpUser
+	}
+	if req.JumpPassword != nil {
+		server.JumpPassword = ""
+		if *req.JumpPassword != "" {
+			if server.JumpPassword, err = utils.Encrypt(*req.JumpPassword); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt password"})
+				return
+			}
+		}
+	}
+	if req.ProxyJump != nil {
+		if server.ProxyJump, err = models.NormalizeProxyJump(*req.ProxyJump); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "proxy_jump"})
+			return
+		}
+	}
+	if server.ProxyJump != "" && server.JumpHost != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errJumpConflict, "field": "proxy_jump"})
+		return
+	}
+	if req.SSHConfigPath != nil {
+		server.SSHConfigPath = strings.TrimSpace(*req.SSHConfigPath)
+		if server.SSHConfigPath != "" {
+			if err := ssh.ValidateSSHConfig(server.SSHConfigPath); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "ssh_config_path"})
+				return
+			}
+		}
+	}
+	if req.KeyPath != nil {
+		server.KeyPath = strings.TrimSpace(*req.KeyPath)
+		if server.KeyPath != "" {
+			if err := ssh.ValidateKey(server.KeyPath); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "key_path"})
+				return
+			}
+		}
+	}
+	if req.AuthOrder != nil {
+		if !ssh.IsAuthOrder(*req.AuthOrder) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errAuthOrder, "field": "auth_order"})
+			return
+		}
+		server.AuthOrder = *req.AuthOrder
+	}
+	if req.ReadOnly != nil {
+		server.ReadOnly = *req.ReadOnly
+	}
+	if req.TLSSkipVerify != nil {
+		server.TLSSkipVerify = *req.TLSSkipVerify
+	}
+	if req.UseSudo != nil {
+		server.UseSudo = *req.UseSudo
+	}
+	if req.SudoPassword != nil {
+		server.SudoPassword = ""
+		if *req.SudoPassword != "" {
+			if server.SudoPassword, err = utils.Encrypt(*req.SudoPassword); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encrypt password"})
+				return
+			}
+		}
+	}
+	if req.SSHPreset != nil {
+		if !ssh.IsAlgorithmPreset(*req.SSHPreset) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ssh_preset must be default or legacy", "field": "ssh_preset"})
+			return
+		}
+		server.SSHPreset = *req.SSHPreset
+	}
+	if req.RateLimit != nil {
+		server.RateLimit = req.RateLimit
+		if *req.RateLimit < 0 {
+			server.RateLimit = nil
+		}
+	}
+	if req.RateBurst != nil {
+		server.RateBurst = req.RateBurst
+		if *req.RateBurst < 0 {
+			server.RateBurst = nil
+		}
+	}
+	if req.UploadQuota != nil {
+		server.UploadQuota = req.UploadQuota
+		if *req.UploadQuota < 0 {
+			server.UploadQuota = nil
+		}
+	}
+	if req.Shell != nil {
+		if err := models.ValidateShell(*req.Shell); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "shell"})
+			return
+		}
+		server.Shell = strings.TrimSpace(*req.Shell)
+	}
+	if req.Env != nil {
+		if err := models.ValidateEnv(req.Env); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "env"})
+			return
+		}
+		server.Env = req.Env
+	}
+	if req.MetricCommands != nil {
+		if err := models.ValidateMetricCommands(req.MetricCommands); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "field": "metric_commands"})
+			return
+		}
+		server.MetricCommands = req.MetricCommands
+	}
+
+	previousStatus := server.Status
+	enabledChanged := req.Enabled != nil && *req.Enabled != server.Enabled
+	if enabledChanged {
+		server.Enabled = *req.Enabled
+		server.Status = models.StatusOffline
+		if !server.Enabled {
+			server.Status = models.StatusDisabled
+		}
+	}
 
 	if err := database.DB.Save(&server).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update server"})
 		return
 	}
+	monitor.RecordStatusChange(server.ID, previousStatus, server.Status)
 
-	// Restart worker if credentials changed
-	if req.Password != "" || req.IPAddress != "" || req.Port != "" || req.Username != "" {
+	jumpChanged := req.JumpHost != nil || req.JumpPort != nil || req.JumpUser != nil || req.JumpPassword != nil ||
+		req.ProxyJump != nil || req.SSHConfigPath != nil
+	sudoChanged := req.UseSudo != nil || req.SudoPassword != nil
+	authChanged := req.KeyPath != nil || req.AuthOrder != nil
+	presetChanged := req.SSHPreset != nil
+	tlsChanged := req.TLSSkipVerify != nil
+	shellChanged := req.Shell != nil || req.Env != nil
+	metricCommandsChanged := req.MetricCommands != nil
+
+	// Disabled servers have no worker. Start one when the server is enabled
+	// and restart it if credentials or collection settings changed.
+	if !server.Enabled {
+		monitor.Pool.RemoveWorker(uint(id))
+	} else if enabledChanged || req.Password != "" || req.IPAddress != "" || req.Port != "" || req.Username != "" || req.NetInterface != nil ||
+		jumpChanged || authChanged || sudoChanged || presetChanged || tlsChanged || shellChanged || metricCommandsChanged {
+		paused := monitor.Pool.IsPaused(uint(id))
 		monitor.Pool.RemoveWorker(uint(id))
 		password := req.Password
 		if password == "" {
 			password, _ = utils.Decrypt(server.Password)
 		}
 		monitor.Pool.AddWorker(&server, password)
+		if paused {
+			monitor.Pool.SetPaused(uint(id), true)
+		}
 	}
 
-	c.JSON(http.StatusOK, server.ToDTO())
+	dto := server.ToDTO()
+	ws.Hub.BroadcastServerEvent(ws.MessageTypeServerUpdated, dto)
+	c.JSON(http.StatusOK, dto)
 }
 
 // DeleteServer deletes a server
@@ -169,13 +637,22 @@ func DeleteServer(c *gin.Context) {
 		return
 	}
 
+	var server models.Server
+	found := database.DB.First(&server, id).Error == nil
+
 	monitor.Pool.RemoveWorker(uint(id))
+	ratelimit.Servers.Remove(uint(id))
+	ws.Hub.ForgetServer(uint(id))
+	scheduler.Jobs.RemoveServer(uint(id))
 
 	if err := database.DB.Delete(&models.Server{}, id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete server"})
 		return
 	}
 
+	if found {
+		ws.Hub.BroadcastServerEvent(ws.MessageTypeServerRemoved, server.ToDTO())
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Server deleted"})
 }
 
@@ -193,9 +670,188 @@ func GetServerStatus(c *gin.Context) {
 		return
 	}
 
+	status := server.Status
+	paused := monitor.Pool.IsPaused(uint(id))
+	if !server.Enabled {
+		status = models.StatusDisabled
+	} else if paused {
+		status = models.StatusMaintenance
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"server_id":     id,
-		"status":        server.Status,
+		"status":        status,
 		"is_monitoring": monitor.Pool.GetWorkerStatus(uint(id)),
+		"paused":        paused,
+		"enabled":       server.Enabled,
+	})
+}
+
+// GetServerStatusHistory returns the status transitions of a server between
+// from and to (RFC3339, by default the last 24 hours), newest first, with the
+// uptime percentage over that window
+func GetServerStatusHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return
+	}
+
+	var server models.Server
+	if err := database.DB.First(&server, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
+		return
+	}
+
+	from, to, ok := statusWindow(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	initial, events, err := statusHistory(&server, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status history"})
+		return
+	}
+	uptime := models.ComputeUptime(initial, events, from, to)
+
+	newest := make([]models.StatusEvent, 0, limit)
+	for i := len(events) - 1; i >= 0 && len(newest) < limit; i-- {
+		newest = append(newest, events[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server_id":      id,
+		"from":           from,
+		"to":             to,
+		"events":         newest,
+		"total":          len(events),
+		"uptime_percent": uptime.Percent,
+	})
+}
+
+// statusWindow reads the from and to query times (RFC3339), by default the
+// 24 hours up to now. A to in the future is moved back to now.
+func statusWindow(c *gin.Context) (from, to time.Time, ok bool) {
+	var err error
+	now := time.Now()
+	to = now
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' time, expected RFC3339"})
+			return from, to, false
+		}
+		if to.After(now) {
+			to = now
+		}
+	}
+	from = to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' time, expected RFC3339"})
+			return from, to, false
+		}
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "'from' must be before 'to'"})
+		return from, to, false
+	}
+	return from, to, true
+}
+
+// statusHistory loads the transitions of [from, to) in time order and the
+// status the server had at from: the last transition before it, else the
+// earliest known state, else the current status when nothing was recorded.
+// Transitions are rare, so the whole window is loaded.
+func statusHistory(server *models.Server, from, to time.Time) (models.ServerStatus, []models.StatusEvent, error) {
+	var events []models.StatusEvent
+	if err := database.DB.Where("server_id = ? AND timestamp >= ? AND timestamp < ?", server.ID, from, to).
+		Order("timestamp, id").Find(&events).Error; err != nil {
+		return "", nil, err
+	}
+
+	// Find rather than First, a server with no older events is not an error
+	var last []models.StatusEvent
+	if err := database.DB.Where("server_id = ? AND timestamp < ?", server.ID, from).
+		Order("timestamp DESC, id DESC").Limit(1).Find(&last).Error; err != nil {
+		return "", nil, err
+	}
+
+	switch {
+	case len(last) > 0:
+		return last[0].To, events, nil
+	case len(events) > 0:
+		return events[0].From, events, nil
+	default:
+		return server.Status, events, nil
+	}
+}
+
+// GetWorkerStats returns the reliability counters of a server's monitoring
+// worker. Counters start over when the worker is restarted.
+func GetWorkerStats(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return
+	}
+
+	state, ok := monitor.Pool.State(uint(id))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server is not being monitored"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server_id":            id,
+		"running":              state.Running,
+		"paused":               state.Paused,
+		"reconnects":           state.Reconnects,
+		"failures":             state.Failures,
+		"consecutive_failures": state.ConsecutiveFailures,
+		"last_error":           state.LastError,
+		"last_error_at":        optionalTime(state.LastErrorAt),
+		"last_success":         optionalTime(state.LastSuccess),
+	})
+}
+
+// optionalTime renders a zero time as null
+func optionalTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// PauseMonitoring stops collecting metrics for a server during maintenance
+func PauseMonitoring(c *gin.Context) {
+	setMonitoringPaused(c, true)
+}
+
+// ResumeMonitoring restarts collection for a paused server
+func ResumeMonitoring(c *gin.Context) {
+	setMonitoringPaused(c, false)
+}
+
+func setMonitoringPaused(c *gin.Context, paused bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return
+	}
+
+	if !monitor.Pool.SetPaused(uint(id), paused) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server is not being monitored"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"server_id": id,
+		"paused":    paused,
 	})
 }
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/internal/ssh"
+	"monitoring/internal/utils"
+)
+
+// ControlService starts, stops or restarts a systemd unit on a server
+func ControlService(c *gin.Context) {
+	unit := c.Param("unit")
+	action := c.Param("action")
+	sudo := c.Query("sudo") == "true"
+
+	if !ssh.ServiceActions[action] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid action, expected start, stop or restart"})
+		return
+	}
+	if err := ssh.ValidateServiceUnit(unit); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	command := ssh.ServiceCommand(unit, action, sudo)
+	if allowed, rule := ssh.Policy.Check(command); !allowed {
+		utils.AppLogger.Warning("Service action denied on server %s: %s (%s)", c.Param("serverId"), command, rule)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Command not allowed",
+			"rule":  rule,
+		})
+		return
+	}
+
+	client, err := getSSHClient(c)
+	if err != nil {
+		c.JSON(clientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	state, err := client.ControlService(unit, action, sudo)
+	if errors.Is(err, ssh.ErrSudoPasswordRequired) || errors.Is(err, ssh.ErrSudoPasswordRejected) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  "Service action failed",
+			"detail": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"unit":   unit,
+		"action": action,
+		"state":  state,
+	})
+}
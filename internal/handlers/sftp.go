@@ -1,14 +1,22 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
-	"monitoring/internal/database"
+	"monitoring/config"
 	"monitoring/internal/models"
 	"monitoring/internal/sftp"
 	"monitoring/internal/utils"
@@ -18,12 +26,35 @@ import (
 func getSFTPClient(c *gin.Context) (*sftp.SFTPClient, error) {
 	serverID, err := strconv.ParseUint(c.Param("serverId"), 10, 32)
 	if err != nil {
-		return nil, fmt.Errorf("invalid server ID")
+		return nil, errInvalidServerID
 	}
 
-	var server models.Server
-	if err := database.DB.First(&server, serverID).Error; err != nil {
-		return nil, fmt.Errorf("server not found")
+	return getSFTPClientByID(uint(serverID), false)
+}
+
+// getWritableSFTPClient is getSFTPClient for handlers that modify files. It
+// fails with errReadOnly for read-only servers.
+func getWritableSFTPClient(c *gin.Context) (*sftp.SFTPClient, error) {
+	serverID, err := strconv.ParseUint(c.Param("serverId"), 10, 32)
+	if err != nil {
+		return nil, errInvalidServerID
+	}
+
+	return getSFTPClientByID(uint(serverID), true)
+}
+
+// errReadOnly refuses changes to a server marked read-only
+var errReadOnly = errors.New("server is read-only")
+
+// getSFTPClientByID returns the pooled SFTP client of a server. write is set
+// when the caller will modify files, which read-only servers refuse.
+func getSFTPClientByID(serverID uint, write bool) (*sftp.SFTPClient, error) {
+	server, err := findServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+	if write && server.ReadOnly {
+		return nil, errReadOnly
 	}
 
 	password, err := utils.Decrypt(server.Password)
@@ -31,7 +62,7 @@ func getSFTPClient(c *gin.Context) (*sftp.SFTPClient, error) {
 		return nil, fmt.Errorf("failed to decrypt credentials")
 	}
 
-	client, err := sftp.Pool.GetClient(&server, password)
+	client, err := sftp.Pool.GetClient(server, password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to server: %w", err)
 	}
@@ -39,21 +70,70 @@ func getSFTPClient(c *gin.Context) (*sftp.SFTPClient, error) {
 	return client, nil
 }
 
+// sftpErrorStatus maps the kind of a failed SFTP operation to an HTTP status
+func sftpErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidServerID), errors.Is(err, sftp.ErrSourceIsDir):
+		return http.StatusBadRequest
+	case errors.Is(err, sftp.ErrNotFound), errors.Is(err, errServerNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, sftp.ErrPermission), errors.Is(err, errReadOnly):
+		return http.StatusForbidden
+	case errors.Is(err, sftp.ErrExists):
+		return http.StatusConflict
+	case errors.Is(err, sftp.ErrNoSpace):
+		return http.StatusInsufficientStorage
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// sftpError replies with the status matching err and its message
+func sftpError(c *gin.Context, err error) {
+	c.JSON(sftpErrorStatus(err), gin.H{"error": err.Error()})
+}
+
+// uploadJob wraps a multipart file as an SFTP upload job
+func uploadJob(remotePath string, fileHeader *multipart.FileHeader) sftp.UploadJob {
+	return sftp.UploadJob{
+		RemotePath: remotePath,
+		Open: func() (io.ReadCloser, error) {
+			return fileHeader.Open()
+		},
+	}
+}
+
 func ListFiles(c *gin.Context) {
 	client, err := getSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
-	path := c.DefaultQuery("path", "/")
+	path, err := client.ResolvePath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	files, err := client.ListDirectory(path)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
+	if c.Query("detect_mime") == "true" {
+		for i := range files {
+			if files[i].IsDir {
+				continue
+			}
+			if preview, err := client.DetectMime(files[i].Path); err == nil {
+				files[i].MimeType = preview.MimeType
+				files[i].Previewable = preview.Previewable
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"path":  path,
 		"files": files,
@@ -62,9 +142,9 @@ func ListFiles(c *gin.Context) {
 }
 
 func CreateDirectory(c *gin.Context) {
-	client, err := getSFTPClient(c)
+	client, err := getWritableSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
@@ -74,8 +154,14 @@ func CreateDirectory(c *gin.Context) {
 		return
 	}
 
+	req.Path, err = client.ResolvePath(req.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	if err := client.CreateDirectory(req.Path); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
@@ -86,13 +172,23 @@ func CreateDirectory(c *gin.Context) {
 }
 
 func UploadFile(c *gin.Context) {
-	client, err := getSFTPClient(c)
+	client, err := getWritableSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
+		return
+	}
+
+	form, ok := parseUploadForm(c)
+	if !ok {
 		return
 	}
+	if len(form.File["file"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
+		return
+	}
+	header := form.File["file"][0]
 
-	file, header, err := c.Request.FormFile("file")
+	file, err := header.Open()
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No file provided"})
 		return
@@ -101,18 +197,30 @@ func UploadFile(c *gin.Context) {
 
 	remotePath := c.PostForm("path")
 	if remotePath == "" {
-		remotePath = "/" + header.Filename
+		remotePath = header.Filename
 	} else {
 
 		if filepath.Ext(remotePath) == "" {
-			remotePath = filepath.Join(remotePath, header.Filename)
+			remotePath = filepath.Join(remotePath, filepath.Base(header.Filename))
 		}
 	}
 
+	remotePath, err = client.ResolvePath(remotePath)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !checkUploadSpace(c, client, filepath.Dir(remotePath), []*multipart.FileHeader{header}) {
+		return
+	}
+
 	if err := client.UploadFile(remotePath, file, header.Size); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		recordAudit(c, models.AuditUpload, remotePath, false)
+		sftpError(c, err)
 		return
 	}
+	recordAudit(c, models.AuditUpload, remotePath, true)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":  "File uploaded",
@@ -122,46 +230,192 @@ func UploadFile(c *gin.Context) {
 	})
 }
 
+// DownloadFile streams a file, honouring a single Range. With compress=true
+// the server gzips it and the body is sent with Content-Encoding: gzip, so
+// browsers still save the original file.
 func DownloadFile(c *gin.Context) {
-	client, err := getSFTPClient(c)
+	client, path, info, ok := downloadTarget(c)
+	if !ok {
+		return
+	}
+
+	filename := filepath.Base(path)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "application/octet-stream")
+
+	if c.Query("compress") == "true" && client.CanCompress() {
+		c.Header("Content-Encoding", "gzip")
+		streamCompressed(c, client, path, info.Size())
+		return
+	}
+
+	c.Header("Accept-Ranges", "bytes")
+
+	size := info.Size()
+	start, length, partial, err := parseByteRange(c.GetHeader("Range"), size)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", size))
+		c.JSON(http.StatusRequestedRangeNotSatisfiable, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !partial {
+		c.Header("Content-Length", strconv.FormatInt(size, 10))
+
+		if err := client.DownloadFile(path, c.Writer); err != nil {
+			sftpError(c, err)
+			return
+		}
+		return
+	}
+
+	c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, size))
+	c.Header("Content-Length", strconv.FormatInt(length, 10))
+	c.Status(http.StatusPartialContent)
+
+	if err := client.DownloadRange(path, c.Writer, start, length); err != nil {
+		utils.AppLogger.Error("Range download of %s failed: %v", path, err)
+	}
+}
+
+// DownloadCompressed downloads a file as a .gz archive compressed on the
+// server. Servers without gzip get the file uncompressed under its own name.
+func DownloadCompressed(c *gin.Context) {
+	client, path, info, ok := downloadTarget(c)
+	if !ok {
 		return
 	}
 
+	filename := filepath.Base(path)
+	if !client.CanCompress() {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		c.Header("Content-Type", "application/octet-stream")
+		c.Header("Content-Length", strconv.FormatInt(info.Size(), 10))
+		if err := client.DownloadFile(path, c.Writer); err != nil {
+			sftpError(c, err)
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.gz", filename))
+	c.Header("Content-Type", "application/gzip")
+	streamCompressed(c, client, path, info.Size())
+}
+
+// downloadTarget resolves the path query parameter to a regular file,
+// answering the request itself when it cannot
+func downloadTarget(c *gin.Context) (*sftp.SFTPClient, string, os.FileInfo, bool) {
+	client, err := getSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return nil, "", nil, false
+	}
+
 	path := c.Query("path")
 	if path == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Path is required"})
-		return
+		return nil, "", nil, false
+	}
+
+	path, err = client.ResolvePath(path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, "", nil, false
 	}
 
 	// Get file info
 	info, err := client.Stat(path)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
-		return
+		return nil, "", nil, false
 	}
 
 	if info.IsDir() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot download a directory"})
-		return
+		return nil, "", nil, false
 	}
 
-	filename := filepath.Base(path)
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Content-Length", strconv.FormatInt(info.Size(), 10))
+	return client, path, info, true
+}
 
-	if err := client.DownloadFile(path, c.Writer); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// streamCompressed sends the gzipped file. The compressed size is only known
+// at the end, so it is sent as the X-Compressed-Size trailer.
+func streamCompressed(c *gin.Context, client *sftp.SFTPClient, path string, size int64) {
+	c.Header("X-Original-Size", strconv.FormatInt(size, 10))
+	c.Header("Trailer", "X-Compressed-Size")
+	c.Status(http.StatusOK)
+
+	written, err := client.DownloadCompressed(c.Request.Context(), path, c.Writer)
+	if err != nil {
+		utils.AppLogger.Error("Compressed download of %s failed: %v", path, err)
 		return
 	}
+	c.Writer.Header().Set("X-Compressed-Size", strconv.FormatInt(written, 10))
 }
 
+// parseByteRange parses a single "bytes=start-end" Range header against a file size.
+// Open-ended ("bytes=100-") and suffix ("bytes=-100") forms are supported. partial
+// is false when the header is absent or requests several ranges, in which case the
+// whole file is served.
+func parseByteRange(header string, size int64) (start, length int64, partial bool, err error) {
+	if header == "" {
+		return 0, size, false, nil
+	}
+
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, size, false, nil
+	}
+
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, false, fmt.Errorf("invalid range")
+	}
+
+	if first == "" {
+		// Suffix range: the last N bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false, fmt.Errorf("invalid range")
+		}
+		if n > size {
+			n = size
+		}
+		if n == 0 {
+			return 0, 0, false, fmt.Errorf("range not satisfiable")
+		}
+		return size - n, n, true, nil
+	}
+
+	start, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, false, fmt.Errorf("invalid range")
+	}
+	if start >= size {
+		return 0, 0, false, fmt.Errorf("range not satisfiable")
+	}
+
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false, fmt.Errorf("invalid range")
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return start, end - start + 1, true, nil
+}
+
+// DeleteFile deletes a file or directory. With dry_run the files and
+// directories that would go are listed instead, along with the confirm token
+// a recursive delete of a non-empty directory must then send.
 func DeleteFile(c *gin.Context) {
-	client, err := getSFTPClient(c)
+	client, err := getWritableSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
@@ -171,23 +425,35 @@ func DeleteFile(c *gin.Context) {
 		return
 	}
 
-	// Check if it's a directory
-	info, err := client.Stat(req.Path)
+	req.Path, err = client.ResolvePath(req.Path)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preview, err := removePath(client, req)
+	if !req.DryRun && !errors.Is(err, errDeleteNotConfirmed) {
+		recordAudit(c, models.AuditDelete, req.Path, err == nil)
+	}
+	if errors.Is(err, os.ErrNotExist) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
 		return
 	}
+	if errors.Is(err, errDeleteNotConfirmed) {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error":   err.Error(),
+			"preview": preview,
+		})
+		return
+	}
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
 
-	if info.IsDir() {
-		if err := client.RemoveDirectory(req.Path, req.Recursive); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
-	} else {
-		if err := client.DeleteFile(req.Path); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	if req.DryRun {
+		c.JSON(http.StatusOK, preview)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -196,11 +462,95 @@ func DeleteFile(c *gin.Context) {
 	})
 }
 
+// errDeleteNotConfirmed is returned for a recursive delete of a non-empty
+// directory whose confirm token does not match its current preview
+var errDeleteNotConfirmed = errors.New("recursive delete must be confirmed with the token from a dry run")
+
+// removePath deletes a resolved path, or only previews it for a dry run.
+// Recursive deletes of non-empty directories only run when the request
+// carries the confirm token of the tree as it is now; otherwise the preview
+// is returned with errDeleteNotConfirmed.
+func removePath(client *sftp.SFTPClient, req models.DeleteRequest) (*models.DeletePreview, error) {
+	if req.DryRun {
+		return client.PreviewRemove(req.Path)
+	}
+
+	if req.Recursive {
+		info, err := client.Stat(req.Path)
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			preview, err := client.PreviewRemove(req.Path)
+			if err != nil {
+				return nil, err
+			}
+			if preview.Files+preview.Directories > 1 && req.Confirm != preview.Confirm {
+				return preview, errDeleteNotConfirmed
+			}
+		}
+	}
+
+	return nil, client.Remove(req.Path, req.Recursive)
+}
+
+// BulkDelete deletes several files or directories in one request. Each path
+// is resolved and deleted on its own; a failure is reported for that path
+// and the rest are still processed.
+func BulkDelete(c *gin.Context) {
+	client, err := getWritableSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	var req models.BulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]models.BulkDeleteResult, 0, len(req.Items))
+	deleted := 0
+	for _, item := range req.Items {
+		result := models.BulkDeleteResult{Path: item.Path}
+
+		path, err := client.ResolvePath(item.Path)
+		if err == nil {
+			result.Path = path
+			item.Path = path
+			result.Preview, err = removePath(client, item)
+			if !item.DryRun && !errors.Is(err, errDeleteNotConfirmed) {
+				recordAudit(c, models.AuditDelete, path, err == nil)
+			}
+		}
+
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			result.Error = "File not found"
+		case err != nil:
+			result.Error = err.Error()
+		case item.DryRun:
+			result.Success = true
+		default:
+			result.Success = true
+			deleted++
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"deleted": deleted,
+		"failed":  len(results) - deleted,
+	})
+}
+
 // RenameFile renames or moves a file
 func RenameFile(c *gin.Context) {
-	client, err := getSFTPClient(c)
+	client, err := getWritableSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
@@ -210,8 +560,19 @@ func RenameFile(c *gin.Context) {
 		return
 	}
 
-	if err := client.Rename(req.OldPath, req.NewPath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if req.OldPath, err = client.ResolvePath(req.OldPath); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.NewPath, err = client.ResolvePath(req.NewPath); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = client.Rename(req.OldPath, req.NewPath)
+	recordAudit(c, models.AuditRename, req.OldPath+" -> "+req.NewPath, err == nil)
+	if err != nil {
+		sftpError(c, err)
 		return
 	}
 
@@ -226,7 +587,7 @@ func RenameFile(c *gin.Context) {
 func ReadFileContent(c *gin.Context) {
 	client, err := getSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
@@ -236,34 +597,65 @@ func ReadFileContent(c *gin.Context) {
 		return
 	}
 
-	info, err := client.Stat(path)
+	path, err = client.ResolvePath(path)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if info.Size() > 20*1024*1024 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large (max 10MB)"})
+	// The read itself stops at the limit, so a file that grows while it is
+	// read, or a /proc file reporting size 0, cannot exceed it
+	limit := config.Get().MaxEditFileSize
+	content, err := client.ReadFileContent(path, limit)
+	if errors.Is(err, sftp.ErrTooLarge) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    fmt.Sprintf("File too large (max %d bytes)", limit),
+			"max_size": limit,
+		})
 		return
 	}
-
-	content, err := client.ReadFileContent(path)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
+		return
+	}
+
+	if sftp.IsBinary([]byte(content)) {
+		mimeType := sftp.DetectContentMime(path, []byte(content))
+
+		// force=true returns the raw bytes for hex viewers
+		if c.Query("force") != "true" {
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{
+				"error":     "File is not a text file",
+				"is_binary": true,
+				"mime_type": mimeType,
+				"size":      len(content),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"path":      path,
+			"content":   base64.StdEncoding.EncodeToString([]byte(content)),
+			"encoding":  "base64",
+			"is_binary": true,
+			"mime_type": mimeType,
+			"size":      len(content),
+		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"path":    path,
-		"content": content,
-		"size":    info.Size(),
+		"path":      path,
+		"content":   content,
+		"size":      len(content),
+		"is_binary": false,
 	})
 }
 
 func WriteFileContent(c *gin.Context) {
-	client, err := getSFTPClient(c)
+	client, err := getWritableSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
@@ -273,8 +665,22 @@ func WriteFileContent(c *gin.Context) {
 		return
 	}
 
+	req.Path, err = client.ResolvePath(req.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if limit := config.Get().MaxEditFileSize; int64(len(req.Content)) > limit {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    fmt.Sprintf("Content too large (max %d bytes)", limit),
+			"max_size": limit,
+		})
+		return
+	}
+
 	if err := client.WriteFileContent(req.Path, req.Content); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
@@ -289,7 +695,7 @@ func WriteFileContent(c *gin.Context) {
 func SearchFiles(c *gin.Context) {
 	client, err := getSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
@@ -299,173 +705,598 @@ func SearchFiles(c *gin.Context) {
 		return
 	}
 
-	path := c.DefaultQuery("path", "/")
+	path, err := client.ResolvePath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	opts := sftp.SearchOptions{
+		Type:  c.Query("type"),
+		Regex: c.Query("regex") == "true",
+	}
+	if opts.Type != "" && opts.Type != sftp.SearchTypeFile && opts.Type != sftp.SearchTypeDir {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Type must be file or dir"})
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if opts.Limit, err = strconv.Atoi(limit); err != nil || opts.Limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
 
-	files, err := client.SearchFiles(path, pattern)
+	result, err := client.SearchFiles(path, pattern, opts)
+	if errors.Is(err, sftp.ErrInvalidPattern) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"pattern": pattern,
-		"path":    path,
-		"files":   files,
-		"total":   len(files),
+		"pattern":   pattern,
+		"path":      path,
+		"files":     result.Files,
+		"total":     result.Total,
+		"truncated": result.Truncated,
+		"timed_out": result.TimedOut,
+		"errors":    result.Errors,
 	})
 }
 
-// GetDirectorySize returns the size of a directory
-func GetDirectorySize(c *gin.Context) {
+// GrepFiles searches file contents below a directory
+func GrepFiles(c *gin.Context) {
 	client, err := getSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
-	path := c.Query("path")
-	if path == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Path is required"})
+	query := c.Query("query")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query is required"})
 		return
 	}
 
-	result, err := client.GetDirectorySize(path)
+	path, err := client.ResolvePath(c.Query("path"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
+	opts := sftp.GrepOptions{CaseSensitive: c.Query("case_sensitive") == "true"}
+	if maxResults := c.Query("max_results"); maxResults != "" {
+		if opts.MaxResults, err = strconv.Atoi(maxResults); err != nil || opts.MaxResults < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid max_results"})
+			return
+		}
+	}
+
+	result, err := client.SearchFileContents(path, query, opts)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"query":     query,
+		"path":      path,
+		"matches":   result.Matches,
+		"total":     result.Total,
+		"truncated": result.Truncated,
+	})
 }
 
-// ChangePermissions changes file permissions
-func ChangePermissions(c *gin.Context) {
+// StatPath returns the details of a single file or directory, with owner
+// and group names, or 404 when it does not exist. It lets clients check a
+// path without listing its parent.
+func StatPath(c *gin.Context) {
 	client, err := getSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
-	var req models.ChmodRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Path is required"})
+		return
+	}
+
+	path, err = client.ResolvePath(path)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := client.Chmod(req.Path, req.Permission); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	info, err := client.StatPath(path)
+	if err != nil {
+		sftpError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":    "Permissions changed",
-		"path":       req.Path,
-		"permission": req.Permission,
-	})
+	c.JSON(http.StatusOK, info)
 }
 
-// CopyFile copies a file within the server
-func CopyFile(c *gin.Context) {
+// GetDirectorySize returns the size of a directory
+func GetDirectorySize(c *gin.Context) {
 	client, err := getSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
-	var req struct {
-		Source      string `json:"source" binding:"required"`
-		Destination string `json:"destination" binding:"required"`
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Path is required"})
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	path, err = client.ResolvePath(path)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := client.CopyFile(req.Source, req.Destination); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	result, err := client.CachedDirectorySize(path, c.Query("refresh") == "true")
+	if err != nil {
+		sftpError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message":     "File copied",
-		"source":      req.Source,
-		"destination": req.Destination,
-	})
+	c.JSON(http.StatusOK, result)
 }
 
-// UploadFolder uploads a full folder preserving relative paths
-func UploadFolder(c *gin.Context) {
+// GetChildDirectorySizes returns the sizes of the subdirectories of a
+// directory for the file browser. Sizes not yet known are computed in the
+// background; while computing is set the client should poll again.
+func GetChildDirectorySizes(c *gin.Context) {
 	client, err := getSFTPClient(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		sftpError(c, err)
 		return
 	}
 
-	form, err := c.MultipartForm()
+	path, err := client.ResolvePath(c.Query("path"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form data"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	files := form.File["files"]
-	if len(files) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No files provided"})
+	result, err := client.ChildDirectorySizes(path)
+	if err != nil {
+		sftpError(c, err)
 		return
 	}
 
-	basePath := c.PostForm("path")
-	if basePath == "" {
-		basePath = "/"
+	c.JSON(http.StatusOK, result)
+}
+
+// ChangePermissions changes file permissions, optionally for a whole tree
+func ChangePermissions(c *gin.Context) {
+	client, err := getWritableSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
 	}
 
-	// relativePaths is parallel to files — contains webkitRelativePath values
-	relativePaths := form.Value["paths"]
+	var req models.ChmodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	var uploaded []string
-	var failed []string
+	req.Path, err = client.ResolvePath(req.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	for i, fileHeader := range files {
-		file, err := fileHeader.Open()
-		if err != nil {
-			failed = append(failed, fileHeader.Filename)
-			continue
+	modes := sftp.ChmodModes{File: req.FilePermission, Dir: req.DirPermission}
+	if req.Permission != 0 {
+		if modes.File == nil {
+			modes.File = &req.Permission
 		}
-
-		var remotePath string
-		if i < len(relativePaths) && relativePaths[i] != "" {
-			remotePath = filepath.Join(basePath, relativePaths[i])
-		} else {
-			remotePath = filepath.Join(basePath, fileHeader.Filename)
+		if modes.Dir == nil {
+			modes.Dir = &req.Permission
 		}
+	}
+	if modes.File == nil && modes.Dir == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "permission, file_permission or dir_permission is required"})
+		return
+	}
 
-		if err := client.UploadFile(remotePath, file, fileHeader.Size); err != nil {
-			failed = append(failed, fileHeader.Filename)
-		} else {
-			uploaded = append(uploaded, remotePath)
+	result, err := client.ChmodRecursive(req.Path, modes, req.Recursive)
+	recordAudit(c, models.AuditChmod, chmodTarget(req.Path, modes, req.Recursive), err == nil && result.FailedCount == 0)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Permissions changed",
+		"path":         req.Path,
+		"permission":   req.Permission,
+		"recursive":    req.Recursive,
+		"changed":      result.Changed,
+		"failed":       result.Failed,
+		"failed_count": result.FailedCount,
+	})
+}
+
+// chmodTarget describes a permission change for the audit log
+func chmodTarget(path string, modes sftp.ChmodModes, recursive bool) string {
+	target := path
+	if modes.File != nil {
+		target += fmt.Sprintf(" f=%o", *modes.File)
+	}
+	if modes.Dir != nil {
+		target += fmt.Sprintf(" d=%o", *modes.Dir)
+	}
+	if recursive {
+		target += " -R"
+	}
+	return target
+}
+
+// ChangeOwner changes the owner and group of a file or directory tree
+func ChangeOwner(c *gin.Context) {
+	client, err := getWritableSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	var req models.ChownRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.UID == nil && req.GID == nil && req.User == "" && req.Group == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "uid, gid, user or group is required"})
+		return
+	}
+
+	req.Path, err = client.ResolvePath(req.Path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid, gid, err := client.ResolveOwner(req.User, req.Group)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.UID != nil {
+		uid = *req.UID
+	}
+	if req.GID != nil {
+		gid = *req.GID
+	}
+
+	// SFTP always sets both IDs, so keep the current value of the missing one
+	if uid < 0 || gid < 0 {
+		currentUID, currentGID, err := client.Owner(req.Path)
+		if err != nil {
+			sftpError(c, err)
+			return
+		}
+		if uid < 0 {
+			uid = currentUID
+		}
+		if gid < 0 {
+			gid = currentGID
 		}
+	}
 
-		file.Close()
+	var changed int
+	if req.Recursive {
+		changed, err = client.ChownRecursive(req.Path, uid, gid)
+	} else if err = client.Chown(req.Path, uid, gid); err == nil {
+		changed = 1
+	}
+	recordAudit(c, models.AuditChown, fmt.Sprintf("%s %d:%d", req.Path, uid, gid), err == nil)
+
+	if err != nil {
+		c.JSON(sftpErrorStatus(err), gin.H{"error": err.Error(), "changed": changed})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"uploaded": uploaded,
-		"failed":   failed,
-		"total":    len(files),
+		"message": "Owner changed",
+		"path":    req.Path,
+		"uid":     uid,
+		"gid":     gid,
+		"changed": changed,
 	})
 }
 
-// UploadMultipleFiles uploads multiple files
-func UploadMultipleFiles(c *gin.Context) {
-	client, err := getSFTPClient(c)
+// TouchFile sets the access and modification times of a file
+func TouchFile(c *gin.Context) {
+	client, err := getWritableSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	var req models.TouchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req.Path, err = client.ResolvePath(req.Path)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	form, err := c.MultipartForm()
+	now := time.Now()
+	atime, err := parseFileTime(req.Atime, now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid atime: " + err.Error()})
+		return
+	}
+	mtime, err := parseFileTime(req.Mtime, now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mtime: " + err.Error()})
+		return
+	}
+
+	created, err := client.Touch(req.Path, atime, mtime, req.Create)
+	if errors.Is(err, os.ErrNotExist) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Times updated",
+		"path":    req.Path,
+		"atime":   atime.UTC().Format(time.RFC3339),
+		"mtime":   mtime.UTC().Format(time.RFC3339),
+		"created": created,
+	})
+}
+
+// parseFileTime accepts RFC 3339 timestamps, with "now" or empty meaning now.
+// SFTP stores whole seconds, so the result is truncated to match.
+func parseFileTime(value string, now time.Time) (time.Time, error) {
+	if value == "" || strings.EqualFold(value, "now") {
+		return now.Truncate(time.Second), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Truncate(time.Second), nil
+}
+
+// CreateSymlink creates a symbolic link
+func CreateSymlink(c *gin.Context) {
+	client, err := getWritableSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	var req models.SymlinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := client.CreateSymlink(req.Target, req.LinkPath, req.Force)
+	switch {
+	case errors.Is(err, sftp.ErrPathOutsideRoot):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		sftpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Symlink created",
+		"link":    link,
+	})
+}
+
+// CopyFile copies a file within the server
+func CopyFile(c *gin.Context) {
+	client, err := getWritableSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	var req struct {
+		Source      string `json:"source" binding:"required"`
+		Destination string `json:"destination" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Source, err = client.ResolvePath(req.Source); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Destination, err = client.ResolvePath(req.Destination); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := client.CopyFile(req.Source, req.Destination); err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "File copied",
+		"source":      req.Source,
+		"destination": req.Destination,
+	})
+}
+
+// parseUploadForm reads a multipart upload of at most MAX_UPLOAD_SIZE bytes,
+// answering 413 for larger bodies. Only MAX_MULTIPART_MEMORY bytes are held
+// in memory; the rest of the files are spooled to temp files, which net/http
+// removes once the request is done.
+//
+// The parts are spooled rather than streamed to SFTP one by one because the
+// upload handlers check every file's size against the quota and free space,
+// and every destination against the root, before writing anything, and then
+// upload the files concurrently. Disk use is bounded by MAX_UPLOAD_SIZE.
+func parseUploadForm(c *gin.Context) (*multipart.Form, bool) {
+	cfg := config.Get()
+
+	if cfg.MaxUploadSize > 0 {
+		if c.Request.ContentLength > cfg.MaxUploadSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":    "Upload exceeds the maximum request size",
+				"max_size": cfg.MaxUploadSize,
+			})
+			return nil, false
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxUploadSize)
+	}
+
+	err := c.Request.ParseMultipartForm(cfg.MaxMultipartMemory)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":    "Upload exceeds the maximum request size",
+			"max_size": cfg.MaxUploadSize,
+		})
+		return nil, false
+	}
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form data"})
+		return nil, false
+	}
+	return c.Request.MultipartForm, true
+}
+
+// checkUploadSpace answers 413 and returns false when files break the per-file
+// limit or the server's upload quota, or do not fit in the free space of dir.
+// The free space check is skipped when the server cannot report it.
+func checkUploadSpace(c *gin.Context, client *sftp.SFTPClient, dir string, files []*multipart.FileHeader) bool {
+	cfg := config.Get()
+
+	var total int64
+	for _, file := range files {
+		if cfg.UploadMaxFileSize > 0 && file.Size > cfg.UploadMaxFileSize {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+				"error":    fmt.Sprintf("%s exceeds the maximum file size", file.Filename),
+				"max_size": cfg.UploadMaxFileSize,
+			})
+			return false
+		}
+		total += file.Size
+	}
+
+	quota := uploadQuota(client)
+	if quota > 0 && total > quota {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error": "Upload exceeds the server's upload quota",
+			"size":  total,
+			"quota": quota,
+		})
+		return false
+	}
+
+	available, err := client.AvailableBytes(dir)
+	if err != nil {
+		utils.AppLogger.Warning("Could not check free space for %s: %v", dir, err)
+		return true
+	}
+	if uint64(total) > available {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":     "Not enough free space on the destination",
+			"size":      total,
+			"available": available,
+		})
+		return false
+	}
+	return true
+}
+
+// uploadQuota returns the bytes one upload may write to the client's server,
+// 0 being unlimited
+func uploadQuota(client *sftp.SFTPClient) int64 {
+	if server := client.Server(); server.UploadQuota != nil {
+		return *server.UploadQuota
+	}
+	return config.Get().UploadQuota
+}
+
+// extractLimits holds an extraction to the same limits as an upload into
+// dir: the per-file size, the server's upload quota and the free space of
+// dir when the server can report it
+func extractLimits(client *sftp.SFTPClient, dir string) sftp.ExtractLimits {
+	limits := sftp.ExtractLimits{
+		MaxBytes:    uploadQuota(client),
+		MaxFileSize: config.Get().UploadMaxFileSize,
+	}
+
+	available, err := client.AvailableBytes(dir)
+	if err != nil {
+		utils.AppLogger.Warning("Could not check free space for %s: %v", dir, err)
+		return limits
+	}
+	if available > math.MaxInt64 {
+		available = math.MaxInt64
+	}
+	if limits.MaxBytes <= 0 || int64(available) < limits.MaxBytes {
+		limits.MaxBytes = int64(available)
+	}
+	return limits
+}
+
+// uploadResponse reports a batch upload. Uploaded lists names of the files
+// that succeeded; each failure gives its intended remote path and reason so
+// only those files need to be retried.
+func uploadResponse(c *gin.Context, names, remotePaths []string, errs []error) {
+	uploaded := []string{}
+	failed := []models.FailedPath{}
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, models.FailedPath{Path: remotePaths[i], Error: err.Error()})
+		} else {
+			uploaded = append(uploaded, names[i])
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"uploaded":       uploaded,
+		"failed":         failed,
+		"total":          len(errs),
+		"uploaded_count": len(uploaded),
+		"failed_count":   len(failed),
+	})
+}
+
+// UploadFolder uploads a full folder preserving relative paths
+func UploadFolder(c *gin.Context) {
+	client, err := getWritableSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	form, ok := parseUploadForm(c)
+	if !ok {
 		return
 	}
 
@@ -475,34 +1306,311 @@ func UploadMultipleFiles(c *gin.Context) {
 		return
 	}
 
-	basePath := c.PostForm("path")
-	if basePath == "" {
-		basePath = "/"
+	basePath, err := client.ResolvePath(c.PostForm("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Resolve every destination before uploading so a crafted relative path
+	// rejects the whole request instead of leaving a partial upload behind
+	remotePaths, err := folderUploadPaths(client.ResolvePath, basePath, files, form.Value["paths"])
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	var uploaded []string
-	var failed []string
+	if !checkUploadSpace(c, client, basePath, files) {
+		return
+	}
 
-	for _, fileHeader := range files {
-		file, err := fileHeader.Open()
-		if err != nil {
-			failed = append(failed, fileHeader.Filename)
-			continue
+	jobs := make([]sftp.UploadJob, len(files))
+	for i, fileHeader := range files {
+		jobs[i] = uploadJob(remotePaths[i], fileHeader)
+	}
+	errs := client.UploadFiles(jobs, config.Get().UploadConcurrency)
+
+	for i := range files {
+		recordAudit(c, models.AuditUpload, remotePaths[i], errs[i] == nil)
+	}
+	uploadResponse(c, remotePaths, remotePaths, errs)
+}
+
+// folderUploadPaths returns where each file of a folder upload goes below
+// basePath. relativePaths is parallel to files and holds the browser's
+// webkitRelativePath values; a file without one keeps its base name. resolve
+// checks each destination against the server root, e.g. client.ResolvePath.
+func folderUploadPaths(resolve func(string) (string, error), basePath string, files []*multipart.FileHeader, relativePaths []string) ([]string, error) {
+	remotePaths := make([]string, len(files))
+	for i, fileHeader := range files {
+		relativePath := filepath.Base(fileHeader.Filename)
+		if i < len(relativePaths) && relativePaths[i] != "" {
+			relativePath = relativePaths[i]
 		}
 
-		remotePath := filepath.Join(basePath, fileHeader.Filename)
-		if err := client.UploadFile(remotePath, file, fileHeader.Size); err != nil {
-			failed = append(failed, fileHeader.Filename)
-		} else {
-			uploaded = append(uploaded, fileHeader.Filename)
+		remotePath, err := sftp.SanitizePath(basePath, relativePath)
+		if err == nil {
+			remotePath, err = resolve(remotePath)
 		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", relativePath, err)
+		}
+		remotePaths[i] = remotePath
+	}
+	return remotePaths, nil
+}
+
+// UploadMultipleFiles uploads multiple files
+func UploadMultipleFiles(c *gin.Context) {
+	client, err := getWritableSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	form, ok := parseUploadForm(c)
+	if !ok {
+		return
+	}
+
+	files := form.File["files"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No files provided"})
+		return
+	}
+
+	basePath, err := client.ResolvePath(c.PostForm("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !checkUploadSpace(c, client, basePath, files) {
+		return
+	}
+
+	jobs := make([]sftp.UploadJob, len(files))
+	for i, fileHeader := range files {
+		jobs[i] = uploadJob(filepath.Join(basePath, filepath.Base(fileHeader.Filename)), fileHeader)
+	}
+	errs := client.UploadFiles(jobs, config.Get().UploadConcurrency)
+
+	names := make([]string, len(files))
+	remotePaths := make([]string, len(files))
+	for i, fileHeader := range files {
+		recordAudit(c, models.AuditUpload, jobs[i].RemotePath, errs[i] == nil)
+		names[i] = fileHeader.Filename
+		remotePaths[i] = jobs[i].RemotePath
+	}
+	uploadResponse(c, names, remotePaths, errs)
+}
+
+// GetFilesystemStats returns the total, used and available space of the
+// filesystem holding path, in bytes and human-readable form
+func GetFilesystemStats(c *gin.Context) {
+	client, err := getSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	path, err := client.ResolvePath(c.Query("path"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := client.StatVFS(path)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// GetFileChecksum returns the sha256 or md5 hash of a remote file
+func GetFileChecksum(c *gin.Context) {
+	client, err := getSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Path is required"})
+		return
+	}
+
+	algo := c.DefaultQuery("algo", "sha256")
+	if !sftp.IsChecksumAlgorithm(algo) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported algorithm, expected sha256 or md5"})
+		return
+	}
+
+	path, err = client.ResolvePath(path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := client.Checksum(path, algo)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetFilePreviewInfo returns the MIME type of a file and whether it can be previewed
+func GetFilePreviewInfo(c *gin.Context) {
+	client, err := getSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	path := c.Query("path")
+	if path == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Path is required"})
+		return
+	}
+
+	path, err = client.ResolvePath(path)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := client.DetectMime(path)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// ExtractArchive unpacks a zip or tar(.gz) archive on the server, held to
+// the limits of an upload into the destination
+func ExtractArchive(c *gin.Context) {
+	client, err := getWritableSFTPClient(c)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	var req models.ExtractRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Path, err = client.ResolvePath(req.Path); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-		file.Close()
+	if req.Destination == "" {
+		req.Destination = filepath.Dir(req.Path)
+	}
+	if req.Destination, err = client.ResolvePath(req.Destination); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	count, err := client.ExtractArchive(req.Path, req.Destination, extractLimits(client, req.Destination))
+	if errors.Is(err, sftp.ErrUnsupportedArchive) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, sftp.ErrArchiveTooLarge) || errors.Is(err, sftp.ErrArchiveTooManyFiles) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":     err.Error(),
+			"extracted": count,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(sftpErrorStatus(err), gin.H{
+			"error":     err.Error(),
+			"extracted": count,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Archive extracted",
+		"path":        req.Path,
+		"destination": req.Destination,
+		"extracted":   count,
+	})
+}
+
+// TransferBetweenServers copies or moves a file from one server to another,
+// streaming it through the API host without staging it
+func TransferBetweenServers(c *gin.Context) {
+	var req models.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A move deletes the source, so it must be writable too
+	src, err := getSFTPClientByID(req.SourceServerID, req.Move)
+	if err != nil {
+		c.JSON(sftpErrorStatus(err), gin.H{"error": "source: " + err.Error()})
+		return
+	}
+	dst, err := getSFTPClientByID(req.DestinationServerID, true)
+	if err != nil {
+		c.JSON(sftpErrorStatus(err), gin.H{"error": "destination: " + err.Error()})
+		return
+	}
+
+	if req.SourcePath, err = src.ResolvePath(req.SourcePath); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.DestinationPath, err = dst.ResolvePath(req.DestinationPath); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The copy replaces the destination, so copying a file onto itself would
+	// gain nothing and moving it would then delete the only copy
+	if req.SourceServerID == req.DestinationServerID && req.SourcePath == req.DestinationPath {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source and destination are the same file"})
+		return
+	}
+
+	written, err := sftp.TransferBetweenServers(src, req.SourcePath, dst, req.DestinationPath, req.PreserveMode)
+	recordServerAudit(c, req.DestinationServerID, models.AuditUpload, req.DestinationPath, err == nil)
+	if err != nil {
+		sftpError(c, err)
+		return
+	}
+
+	if req.Move {
+		err := src.DeleteFile(req.SourcePath)
+		recordServerAudit(c, req.SourceServerID, models.AuditDelete, req.SourcePath, err == nil)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":         "File copied but source could not be removed",
+				"detail":        err.Error(),
+				"bytes_written": written,
+			})
+			return
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"uploaded": uploaded,
-		"failed":   failed,
-		"total":    len(files),
+		"message":       "File transferred",
+		"source":        req.SourcePath,
+		"destination":   req.DestinationPath,
+		"bytes_written": written,
+		"moved":         req.Move,
 	})
 }
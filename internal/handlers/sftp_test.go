@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"monitoring/internal/models"
+	"monitoring/internal/sftp"
+)
+
+func TestFolderUploadPaths(t *testing.T) {
+	const root = "/srv/data"
+	resolve := func(p string) (string, error) {
+		return sftp.SanitizePath(root, p)
+	}
+	files := func(names ...string) []*multipart.FileHeader {
+		headers := make([]*multipart.FileHeader, len(names))
+		for i, name := range names {
+			headers[i] = &multipart.FileHeader{Filename: name}
+		}
+		return headers
+	}
+
+	tests := []struct {
+		name     string
+		basePath string
+		files    []*multipart.FileHeader
+		relative []string
+		want     []string
+		err      bool
+	}{
+		{
+			name:     "relative paths",
+			basePath: "/srv/data/uploads",
+			files:    files("a.txt", "b.txt"),
+			relative: []string{"site/a.txt", "site/css/b.txt"},
+			want:     []string{"/srv/data/uploads/site/a.txt", "/srv/data/uploads/site/css/b.txt"},
+		},
+		{
+			name:     "missing relative path keeps base name",
+			basePath: "/srv/data/uploads",
+			files:    files("../../a.txt", "b.txt"),
+			relative: []string{""},
+			want:     []string{"/srv/data/uploads/a.txt", "/srv/data/uploads/b.txt"},
+		},
+		{
+			name:     "dot segments inside base",
+			basePath: "/srv/data/uploads",
+			files:    files("a.txt"),
+			relative: []string{"site/../a.txt"},
+			want:     []string{"/srv/data/uploads/a.txt"},
+		},
+		{
+			name:     "escapes base",
+			basePath: "/srv/data/uploads",
+			files:    files("a.txt"),
+			relative: []string{"../a.txt"},
+			err:      true,
+		},
+		{
+			name:     "escapes root",
+			basePath: "/srv/data",
+			files:    files("ok.txt", "passwd"),
+			relative: []string{"ok.txt", "../../etc/passwd"},
+			err:      true,
+		},
+		{
+			name:     "absolute path",
+			basePath: "/srv/data",
+			files:    files("passwd"),
+			relative: []string{"/etc/passwd"},
+			err:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := folderUploadPaths(resolve, tt.basePath, tt.files, tt.relative)
+		if tt.err {
+			if !errors.Is(err, sftp.ErrPathOutsideRoot) {
+				t.Errorf("%s: error = %v, want ErrPathOutsideRoot", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: path %d = %q, want %q", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseByteRange(t *testing.T) {
+	const size = 1000
+
+	tests := []struct {
+		header  string
+		start   int64
+		length  int64
+		partial bool
+		err     bool
+	}{
+		{"", 0, size, false, false},
+		{"bytes=0-0", 0, 1, true, false},
+		{"bytes=0-999", 0, size, true, false},
+		{"bytes=0-1000", 0, size, true, false},
+		{"bytes=999-999", 999, 1, true, false},
+		{"bytes=999-", 999, 1, true, false},
+		{"bytes=1000-", 0, 0, false, true},
+		{"bytes=1000-1000", 0, 0, false, true},
+		{"bytes=100-", 100, 900, true, false},
+		{"bytes=100-199", 100, 100, true, false},
+		{"bytes=-1", 999, 1, true, false},
+		{"bytes=-500", 500, 500, true, false},
+		{"bytes=-1000", 0, size, true, false},
+		{"bytes=-5000", 0, size, true, false},
+		{"bytes=-0", 0, 0, false, true},
+		{"bytes=200-100", 0, 0, false, true},
+		{"bytes=-", 0, 0, false, true},
+		{"bytes=abc-", 0, 0, false, true},
+		{"bytes=100", 0, 0, false, true},
+		{"bytes=0-99,200-299", 0, size, false, false},
+		{"items=0-99", 0, size, false, false},
+	}
+
+	for _, tt := range tests {
+		start, length, partial, err := parseByteRange(tt.header, size)
+		if (err != nil) != tt.err {
+			t.Errorf("parseByteRange(%q) error = %v, want error %v", tt.header, err, tt.err)
+			continue
+		}
+		if tt.err {
+			continue
+		}
+		if start != tt.start || length != tt.length || partial != tt.partial {
+			t.Errorf("parseByteRange(%q) = %d, %d, %v; want %d, %d, %v",
+				tt.header, start, length, partial, tt.start, tt.length, tt.partial)
+		}
+	}
+}
+
+func TestParseByteRangeEmptyFile(t *testing.T) {
+	for _, header := range []string{"bytes=0-", "bytes=0-0", "bytes=-1"} {
+		if _, _, _, err := parseByteRange(header, 0); err == nil {
+			t.Errorf("parseByteRange(%q) on an empty file succeeded", header)
+		}
+	}
+	if _, length, partial, err := parseByteRange("", 0); err != nil || partial || length != 0 {
+		t.Errorf("no Range header on an empty file = %d, %v, %v", length, partial, err)
+	}
+}
+
+func TestEditFileSizeLimit(t *testing.T) {
+	t.Setenv("MAX_EDIT_FILE_SIZE", "100")
+	server := setupTestServer(t)
+	dir := t.TempDir()
+
+	for _, size := range []int{99, 100, 101} {
+		path := filepath.Join(dir, fmt.Sprintf("write-%d.txt", size))
+		body, _ := json.Marshal(models.ContentRequest{Path: path, Content: strings.Repeat("a", size)})
+		req := httptest.NewRequest(http.MethodPut, "/files/content", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := serveTest(WriteFileContent, server.ID, req)
+
+		_, statErr := os.Stat(path)
+		if size <= 100 && (w.Code != http.StatusOK || statErr != nil) {
+			t.Errorf("writing %d bytes: status %d, file error %v; want 200 and the file", size, w.Code, statErr)
+		}
+		if size > 100 && (w.Code != http.StatusBadRequest || statErr == nil || !strings.Contains(w.Body.String(), `"max_size":100`)) {
+			t.Errorf("writing %d bytes: status %d, body %s; want 400 with max_size and no file", size, w.Code, w.Body)
+		}
+	}
+
+	for _, size := range []int{99, 100, 101} {
+		path := filepath.Join(dir, fmt.Sprintf("read-%d.txt", size))
+		if err := os.WriteFile(path, []byte(strings.Repeat("a", size)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/files/content?path="+url.QueryEscape(path), nil)
+		w := serveTest(ReadFileContent, server.ID, req)
+
+		want := http.StatusOK
+		if size > 100 {
+			want = http.StatusBadRequest
+		}
+		if w.Code != want {
+			t.Errorf("reading %d bytes: status %d, body %s; want %d", size, w.Code, w.Body, want)
+		}
+	}
+}
+
+// uploadBody builds a multipart body with one file of size bytes to go to dir
+func uploadBody(t *testing.T, dir string, size int) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("path", dir)
+	part, err := writer.CreateFormFile("files", "big.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(bytes.Repeat([]byte("x"), size))
+	writer.Close()
+	return &body, writer.FormDataContentType()
+}
+
+func TestUploadOversizedBody(t *testing.T) {
+	t.Setenv("MAX_UPLOAD_SIZE", "1024")
+	server := setupTestServer(t)
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"within limit", 512, false, http.StatusOK},
+		{"declared length over limit", 4096, false, http.StatusRequestEntityTooLarge},
+		{"chunked body over limit", 4096, true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		body, contentType := uploadBody(t, dir, tt.size)
+		req := httptest.NewRequest(http.MethodPost, "/files/upload", body)
+		req.Header.Set("Content-Type", contentType)
+		if tt.chunked {
+			// Without a declared length only MaxBytesReader can stop the body
+			req.ContentLength = -1
+		}
+		os.Remove(filepath.Join(dir, "big.bin"))
+
+		w := serveTest(UploadMultipleFiles, server.ID, req)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, body %s; want %d", tt.name, w.Code, w.Body, tt.want)
+		}
+		_, err := os.Stat(filepath.Join(dir, "big.bin"))
+		if uploaded := err == nil; uploaded != (tt.want == http.StatusOK) {
+			t.Errorf("%s: file uploaded = %v", tt.name, uploaded)
+		}
+	}
+}
+
+func TestTransferBetweenServers(t *testing.T) {
+	server := setupTestServer(t)
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source.txt")
+	const content = "keep me"
+
+	transfer := func(from, to string, move bool) int {
+		body, _ := json.Marshal(models.TransferRequest{
+			SourceServerID:      server.ID,
+			SourcePath:          from,
+			DestinationServerID: server.ID,
+			DestinationPath:     to,
+			Move:                move,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/files/transfer", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return serveTest(TransferBetweenServers, server.ID, req).Code
+	}
+
+	if err := os.WriteFile(source, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "existing.txt"), []byte("old content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(source, filepath.Join(dir, "alias.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		to   string
+		move bool
+		want int
+	}{
+		{"copy onto itself", source, false, http.StatusBadRequest},
+		{"move onto itself", source, true, http.StatusBadRequest},
+		{"same path spelled differently", filepath.Join(dir, "sub", "..", "source.txt"), true, http.StatusBadRequest},
+		{"copy onto a link to itself", filepath.Join(dir, "alias.txt"), false, http.StatusOK},
+		{"copy over another file", filepath.Join(dir, "existing.txt"), false, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if code := transfer(source, tt.to, tt.move); code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, code, tt.want)
+		}
+		if data, err := os.ReadFile(source); err != nil || string(data) != content {
+			t.Fatalf("%s: source now holds %q, %v", tt.name, data, err)
+		}
+		if tt.want == http.StatusOK {
+			if data, err := os.ReadFile(tt.to); err != nil || string(data) != content {
+				t.Errorf("%s: destination holds %q, %v", tt.name, data, err)
+			}
+		}
+	}
+
+	if code := transfer(dir, filepath.Join(t.TempDir(), "copy"), false); code != http.StatusBadRequest {
+		t.Errorf("directory source: status %d, want 400", code)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".part") {
+			t.Errorf("temporary file %s left behind", entry.Name())
+		}
+	}
+}
+
+// writeZip creates a zip archive with a file of each given size, all zeros
+// so a large entry compresses to almost nothing
+func writeZip(t *testing.T, path string, sizes map[string]int) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	archive := zip.NewWriter(file)
+	for name, size := range sizes {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(make([]byte, size)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractArchiveLimits(t *testing.T) {
+	t.Setenv("UPLOAD_MAX_FILE_SIZE", "1000")
+	t.Setenv("UPLOAD_QUOTA", "1500")
+	server := setupTestServer(t)
+	dir := t.TempDir()
+
+	extract := func(sizes map[string]int) (int, map[string]interface{}) {
+		archive := filepath.Join(dir, "archive.zip")
+		writeZip(t, archive, sizes)
+		dest := t.TempDir()
+
+		body, _ := json.Marshal(models.ExtractRequest{Path: archive, Destination: dest})
+		req := httptest.NewRequest(http.MethodPost, "/files/extract", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := serveTest(ExtractArchive, server.ID, req)
+
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	tests := []struct {
+		name  string
+		sizes map[string]int
+		want  int
+	}{
+		{"within limits", map[string]int{"a": 1000, "b": 500}, http.StatusOK},
+		{"file over the size limit", map[string]int{"a": 1001}, http.StatusRequestEntityTooLarge},
+		{"bomb", map[string]int{"bomb": 50 << 20}, http.StatusRequestEntityTooLarge},
+		{"files over the quota", map[string]int{"a": 1000, "b": 501}, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		if code, resp := extract(tt.sizes); code != tt.want {
+			t.Errorf("%s: status %d, want %d: %v", tt.name, code, tt.want, resp)
+		}
+	}
+
+	many := make(map[string]int, 10001)
+	for i := 0; i <= 10000; i++ {
+		many[fmt.Sprintf("d%d/", i)] = 0
+	}
+	if code, resp := extract(many); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("too many entries: status %d, want 413: %v", code, resp)
+	}
+}
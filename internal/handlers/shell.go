@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+	"monitoring/internal/ssh"
+	"monitoring/internal/utils"
+	ws "monitoring/internal/websocket"
+)
+
+// shellControl is a message sent by the client:
+//
+//	{"type": "shell_input", "data": "ls -la\r"}
+//	{"type": "shell_resize", "cols": 120, "rows": 40}
+//
+// Terminal output is sent back as
+//
+//	{"type": "shell_output", "payload": {"data": "..."}}
+//
+// and a final {"type": "shell_exit", "payload": {"exit_code": 0}} before the
+// connection is closed.
+type shellControl struct {
+	Type ws.MessageType `json:"type"`
+	Data string         `json:"data"`
+	Cols int            `json:"cols"`
+	Rows int            `json:"rows"`
+}
+
+// shellConn relays one shell over one WebSocket connection
+type shellConn struct {
+	conn    *websocket.Conn
+	shell   *ssh.Shell
+	writeMu sync.Mutex
+}
+
+// ShellWebSocket opens an interactive shell on a pseudo-terminal. The
+// initial size and terminal type come from the cols, rows and term query
+// parameters. Shells cannot be checked command by command, so they are
+// refused while a command policy is configured.
+func ShellWebSocket(c *gin.Context) {
+	if ssh.Policy.Restricted() {
+		recordAudit(c, models.AuditSSHShell, "", false)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Interactive shells are disabled while a command policy is configured"})
+		return
+	}
+
+	if !acquireWSConnection(c) {
+		return
+	}
+	defer ws.Hub.ReleaseConnection(c.RemoteIP())
+
+	client, err := getSSHClient(c)
+	if err != nil {
+		c.JSON(clientErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	cols, _ := strconv.Atoi(c.Query("cols"))
+	rows, _ := strconv.Atoi(c.Query("rows"))
+	shell, err := client.OpenShell(c.Query("term"), cols, rows)
+	recordAudit(c, models.AuditSSHShell, "", err == nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := wsUpgrader().Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		utils.AppLogger.Error("Failed to upgrade to WebSocket: %v", err)
+		shell.Close()
+		return
+	}
+
+	session := &shellConn{conn: conn, shell: shell}
+	defer func() {
+		shell.Close()
+		conn.Close()
+	}()
+
+	go session.relayOutput()
+
+	for {
+		var msg shellControl
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		switch msg.Type {
+		case ws.MessageTypeShellInput:
+			if _, err := shell.Write([]byte(msg.Data)); err != nil {
+				return
+			}
+		case ws.MessageTypeShellResize:
+			if err := shell.Resize(msg.Cols, msg.Rows); err != nil {
+				session.send(ws.MessageTypeError, gin.H{"error": err.Error()})
+			}
+		default:
+			session.send(ws.MessageTypeError, gin.H{"error": "Unknown message type"})
+		}
+	}
+}
+
+// relayOutput forwards terminal output until the shell exits, then reports
+// the exit code and closes the connection so the read loop ends
+func (s *shellConn) relayOutput() {
+	buf := make([]byte, 32*1024)
+	var pending []byte
+	for {
+		n, err := s.shell.Read(buf)
+		if n > 0 {
+			pending = append(pending, buf[:n]...)
+			// JSON strings must be valid UTF-8, keep a split character for the next read
+			cut := utf8Boundary(pending)
+			s.send(ws.MessageTypeShellOutput, gin.H{"data": string(pending[:cut])})
+			pending = append(pending[:0], pending[cut:]...)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	s.send(ws.MessageTypeShellExit, gin.H{"exit_code": s.shell.Wait()})
+	s.writeMu.Lock()
+	s.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "shell exited"),
+		time.Now().Add(time.Second))
+	s.writeMu.Unlock()
+	s.conn.Close()
+}
+
+// utf8Boundary returns the length of data without a trailing incomplete rune
+func utf8Boundary(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if utf8.FullRune(data[i:]) {
+				return len(data)
+			}
+			return i
+		}
+	}
+	return len(data)
+}
+
+// send serializes writes, gorilla connections allow a single writer
+func (s *shellConn) send(msgType ws.MessageType, payload interface{}) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(config.Get().WSWriteTimeout))
+	s.conn.WriteJSON(ws.Message{Type: msgType, Payload: payload})
+}
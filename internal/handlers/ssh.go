@@ -1,20 +1,87 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
+	"monitoring/config"
 	"monitoring/internal/database"
 	"monitoring/internal/models"
 	"monitoring/internal/ssh"
 	"monitoring/internal/utils"
+	"monitoring/internal/winrm"
 )
 
 type ExecuteCommandRequest struct {
-	Command string `json:"command" binding:"required"`
+	Command string            `json:"command" binding:"required"`
+	Timeout int               `json:"timeout"` // Seconds, overrides SSH_COMMAND_TIMEOUT
+	Env     map[string]string `json:"env"`     // Added to the server's environment for this command
+	// Session keeps a working directory apart from other users of the same
+	// server; the X-Session-ID header is used when it is empty
+	Session string `json:"session_id"`
+}
+
+// errInvalidServerID and errServerNotFound reject the serverId of a request
+// before any connection is made; clientErrorStatus maps them to 400 and 404
+var (
+	errInvalidServerID = errors.New("invalid server ID")
+	errServerNotFound  = errors.New("server not found")
+)
+
+// clientErrorStatus maps an error of getSSHClient to an HTTP status
+func clientErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidServerID):
+		return http.StatusBadRequest
+	case errors.Is(err, errServerNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// findServer loads a server, failing with errServerNotFound when it does not exist
+func findServer(serverID uint) (*models.Server, error) {
+	var server models.Server
+	if err := database.DB.First(&server, serverID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errServerNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch server: %w", err)
+	}
+	return &server, nil
+}
+
+// getSSHClient helper to get SSH client for a server
+func getSSHClient(c *gin.Context) (*ssh.SSHClient, error) {
+	serverID, err := strconv.ParseUint(c.Param("serverId"), 10, 32)
+	if err != nil {
+		return nil, errInvalidServerID
+	}
+
+	server, err := findServer(uint(serverID))
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := utils.Decrypt(server.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials")
+	}
+
+	client, err := ssh.Pool.GetClient(server, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to server: %w", err)
+	}
+
+	return client, nil
 }
 
 func ConnectServerSsh(c *gin.Context) {
@@ -31,7 +98,9 @@ func ConnectServerSsh(c *gin.Context) {
 
 }
 
-// ExecuteSSHCommand executes a command on a server via SSH
+// ExecuteSSHCommand executes a command on a server via SSH. Commands that
+// run report their exit code, stdout and stderr with status 200 even when
+// they fail; errors are kept for commands that could not run.
 func ExecuteSSHCommand(c *gin.Context) {
 	serverID, err := strconv.ParseUint(c.Param("serverId"), 10, 32)
 	if err != nil {
@@ -45,6 +114,11 @@ func ExecuteSSHCommand(c *gin.Context) {
 		return
 	}
 
+	if err := models.ValidateEnv(req.Env); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	var server models.Server
 	if err := database.DB.First(&server, serverID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
@@ -57,34 +131,128 @@ func ExecuteSSHCommand(c *gin.Context) {
 		return
 	}
 
+	if allowed, rule := ssh.Policy.Check(req.Command); !allowed {
+		utils.AppLogger.Warning("Command denied on server %d: %s (%s)", server.ID, req.Command, rule)
+		recordAudit(c, models.AuditSSHCommand, req.Command, false)
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "Command not allowed",
+			"rule":  rule,
+		})
+		return
+	}
+
+	timeout := config.Get().SSHCommandTimeout
+	if req.Timeout > 0 {
+		timeout = time.Duration(req.Timeout) * time.Second
+	}
+
+	if server.UsesWinRM() {
+		executeWinRMCommand(c, &server, password, req.Command, timeout)
+		return
+	}
+
 	client, err := ssh.Pool.GetClient(&server, password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to server"})
 		return
 	}
 
+	// A leading sudo is rewritten so it cannot hang waiting for a password
+	command, input, err := client.PrepareSudo(req.Command)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	session := req.Session
+	if session == "" {
+		session = c.GetHeader("X-Session-ID")
+	}
+	currentDir := client.WorkDir(session)
+
 	var fullCommand string
-	if client.CurrentDir != "" {
-		fullCommand = "cd " + client.CurrentDir + " && " + req.Command
+	if currentDir != "" {
+		fullCommand = "cd " + ssh.ShellQuote(currentDir) + " && " + command
 	} else {
-		fullCommand = req.Command
+		fullCommand = command
 	}
 
 	utils.AppLogger.Info("Comando ejecutado: %s", fullCommand)
-	output, err := client.Execute(fullCommand)
+	result, err := client.ExecuteDetailed(fullCommand, input, req.Env, timeout)
+	if err == nil {
+		err = ssh.SudoResultError(result)
+	}
+	recordAudit(c, models.AuditSSHCommand, req.Command, err == nil && result.ExitCode == 0)
+
+	if errors.Is(err, ssh.ErrCommandTimeout) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "command timed out",
+			"timeout": timeout.Seconds(),
+		})
+		return
+	}
+	if errors.Is(err, ssh.ErrSudoPasswordRequired) || errors.Is(err, ssh.ErrSudoPasswordRejected) {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":  "Command failed",
+			"detail": err.Error(),
+		})
+		return
+	}
 
-	if err == nil && strings.HasPrefix(strings.TrimSpace(req.Command), "cd ") {
+	if result.ExitCode == 0 && strings.HasPrefix(strings.TrimSpace(req.Command), "cd ") {
 		var pwdCmd string
-		if client.CurrentDir != "" {
-			pwdCmd = "cd " + client.CurrentDir + " && " + req.Command + " && pwd"
+		if currentDir != "" {
+			pwdCmd = "cd " + ssh.ShellQuote(currentDir) + " && " + req.Command + " && pwd"
 		} else {
 			pwdCmd = req.Command + " && pwd"
 		}
-		if newDir, pwdErr := client.Execute(pwdCmd); pwdErr == nil {
-			client.CurrentDir = strings.TrimSpace(newDir)
+		if newDir, pwdErr := client.ExecuteWithTimeout(pwdCmd, timeout); pwdErr == nil {
+			currentDir = strings.TrimSpace(newDir)
+			client.SetWorkDir(session, currentDir)
 		}
 	}
 
+	// Format output as array of lines for better readability
+	lines := strings.Split(strings.TrimSpace(result.Stdout), "\n")
+
+	c.JSON(http.StatusOK, gin.H{
+		"output":     result.Stdout,
+		"lines":      lines,
+		"stdout":     result.Stdout,
+		"stderr":     result.Stderr,
+		"exit_code":  result.ExitCode,
+		"truncated":  result.Truncated,
+		"command":    req.Command,
+		"currentDir": currentDir,
+	})
+}
+
+// executeWinRMCommand runs a command on a Windows server. WinRM shells are
+// not kept between requests, so there is no working directory to track.
+func executeWinRMCommand(c *gin.Context, server *models.Server, password, command string, timeout time.Duration) {
+	client, err := winrm.Pool.GetClient(server, password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to connect to server"})
+		return
+	}
+
+	utils.AppLogger.Info("Comando ejecutado (WinRM): %s", command)
+	output, err := client.ExecuteWithTimeout(command, timeout)
+	recordAudit(c, models.AuditSSHCommand, command, err == nil)
+
+	if errors.Is(err, winrm.ErrCommandTimeout) {
+		c.JSON(http.StatusGatewayTimeout, gin.H{
+			"error":   "command timed out",
+			"timeout": timeout.Seconds(),
+		})
+		return
+	}
+
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":  "Command failed",
@@ -93,13 +261,66 @@ func ExecuteSSHCommand(c *gin.Context) {
 		return
 	}
 
-	// Format output as array of lines for better readability
+	output = strings.ReplaceAll(output, "\r\n", "\n")
 	lines := strings.Split(strings.TrimSpace(output), "\n")
 
 	c.JSON(http.StatusOK, gin.H{
 		"output":     output,
 		"lines":      lines,
-		"command":    req.Command,
-		"currentDir": client.CurrentDir,
+		"command":    command,
+		"currentDir": "",
 	})
 }
+
+// PingServer measures the SSH round trip to a server with a keepalive request
+// on its live connection. On a cold connection the DNS lookup and dial times
+// are reported separately.
+func PingServer(c *gin.Context) {
+	serverID, err := strconv.ParseUint(c.Param("serverId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return
+	}
+
+	var server models.Server
+	if err := database.DB.First(&server, serverID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
+		return
+	}
+
+	if server.UsesWinRM() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ping is only available for SSH servers"})
+		return
+	}
+
+	password, err := utils.Decrypt(server.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decrypt credentials"})
+		return
+	}
+
+	result := ssh.Pool.Ping(&server, password)
+
+	response := gin.H{
+		"server_id": server.ID,
+		"reachable": result.Reachable,
+		"cold":      result.Cold,
+	}
+	if result.Reachable {
+		response["latency_ms"] = milliseconds(result.Latency)
+	}
+	if result.Cold {
+		response["dns_ms"] = milliseconds(result.DNS)
+		response["dial_ms"] = milliseconds(result.Dial)
+	}
+	if result.Error != "" {
+		response["error"] = result.Error
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// milliseconds converts d to fractional milliseconds for JSON responses
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// runCommand posts command to ExecuteSSHCommand under a session ID
+func runCommand(t *testing.T, serverID uint, session, command string) map[string]interface{} {
+	t.Helper()
+
+	body, _ := json.Marshal(ExecuteCommandRequest{Command: command})
+	req := httptest.NewRequest(http.MethodPost, "/ssh/execute", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Session-ID", session)
+
+	w := serveTest(ExecuteSSHCommand, serverID, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("%s in session %s: status %d, body %s", command, session, w.Code, w.Body)
+		return nil
+	}
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return response
+}
+
+func TestWorkDirPerSession(t *testing.T) {
+	server := setupTestServer(t)
+
+	base := t.TempDir()
+	dirs := make([]string, 8)
+	for i := range dirs {
+		dirs[i] = filepath.Join(base, fmt.Sprintf("user%d", i))
+		if err := os.Mkdir(dirs[i], 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Every user changes directory and runs pwd a few times at once on the
+	// same pooled client
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func(session, dir string) {
+			defer wg.Done()
+			for round := 0; round < 5; round++ {
+				runCommand(t, server.ID, session, "cd "+dir)
+				response := runCommand(t, server.ID, session, "pwd")
+				if response == nil {
+					return
+				}
+				if got := strings.TrimSpace(response["stdout"].(string)); got != dir {
+					t.Errorf("session %s: pwd = %q, want %q", session, got, dir)
+				}
+				if response["currentDir"] != dir {
+					t.Errorf("session %s: currentDir = %v, want %q", session, response["currentDir"], dir)
+				}
+			}
+		}(fmt.Sprintf("user-%d", i), dir)
+	}
+	wg.Wait()
+
+	// A caller without a session ID never changed directory
+	response := runCommand(t, server.ID, "", "pwd")
+	if response != nil && response["currentDir"] != "" {
+		t.Errorf("session-less currentDir = %v, want empty", response["currentDir"])
+	}
+}
+
+func TestServerLookupStatus(t *testing.T) {
+	server := setupTestServer(t)
+
+	tests := []struct {
+		serverID string
+		want     int
+	}{
+		{"abc", http.StatusBadRequest},
+		{"-1", http.StatusBadRequest},
+		{"999", http.StatusNotFound},
+		{fmt.Sprint(server.ID), http.StatusOK},
+	}
+
+	handlers := map[string]gin.HandlerFunc{"ProcessList": ProcessList, "ListFiles": ListFiles}
+	for name, handler := range handlers {
+		for _, tt := range tests {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/?path=/", nil)
+			c.Params = gin.Params{{Key: "serverId", Value: tt.serverID}}
+			handler(c)
+
+			if w.Code != tt.want {
+				t.Errorf("%s for server %q: status %d, want %d: %s", name, tt.serverID, w.Code, tt.want, w.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+)
+
+const (
+	uptimeCacheTTL  = 30 * time.Second
+	uptimeCacheSize = 1024
+)
+
+type uptimeKey struct {
+	serverID uint
+	from, to string // Query values as given, so "now" windows share an entry
+}
+
+type uptimeEntry struct {
+	response   gin.H
+	computedAt time.Time
+}
+
+// uptimeCache keeps recent uptime results, which read every transition in
+// the window, so dashboards polling the same window hit the database once
+var uptimeCache = struct {
+	entries map[uptimeKey]uptimeEntry
+	mu      sync.Mutex
+}{entries: make(map[uptimeKey]uptimeEntry)}
+
+// GetServerUptime returns the share of a window (from and to, RFC3339, by
+// default the last 24 hours) a server was online, the downtime and the
+// number of incidents. Time before the first recorded transition is taken
+// to be in the earliest known state. Results are cached for 30 seconds.
+func GetServerUptime(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid server ID"})
+		return
+	}
+
+	// Checked before the cache so a deleted server stops answering at once
+	var server models.Server
+	if err := database.DB.First(&server, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Server not found"})
+		return
+	}
+
+	key := uptimeKey{serverID: uint(id), from: c.Query("from"), to: c.Query("to")}
+	if response, ok := cachedUptime(key); ok {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	from, to, ok := statusWindow(c)
+	if !ok {
+		return
+	}
+
+	initial, events, err := statusHistory(&server, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch status history"})
+		return
+	}
+	uptime := models.ComputeUptime(initial, events, from, to)
+
+	response := gin.H{
+		"server_id":         id,
+		"from":              from,
+		"to":                to,
+		"uptime_percent":    uptime.Percent,
+		"downtime_seconds":  uptime.Downtime.Seconds(),
+		"monitored_seconds": uptime.Monitored.Seconds(),
+		"incidents":         uptime.Incidents,
+		"computed_at":       time.Now(),
+	}
+	storeUptime(key, response)
+	c.JSON(http.StatusOK, response)
+}
+
+func cachedUptime(key uptimeKey) (gin.H, bool) {
+	uptimeCache.mu.Lock()
+	defer uptimeCache.mu.Unlock()
+
+	entry, ok := uptimeCache.entries[key]
+	if !ok || time.Since(entry.computedAt) > uptimeCacheTTL {
+		return nil, false
+	}
+	return entry.response, true
+}
+
+// storeUptime caches a result, first dropping expired entries when the
+// cache is full
+func storeUptime(key uptimeKey, response gin.H) {
+	uptimeCache.mu.Lock()
+	defer uptimeCache.mu.Unlock()
+
+	if len(uptimeCache.entries) >= uptimeCacheSize {
+		for k, entry := range uptimeCache.entries {
+			if time.Since(entry.computedAt) > uptimeCacheTTL {
+				delete(uptimeCache.entries, k)
+			}
+		}
+	}
+	if len(uptimeCache.entries) < uptimeCacheSize {
+		uptimeCache.entries[key] = uptimeEntry{response: response, computedAt: time.Now()}
+	}
+}
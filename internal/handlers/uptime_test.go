@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/internal/database"
+)
+
+func TestUptimeOfDeletedServer(t *testing.T) {
+	server := setupTestServer(t)
+
+	uptime := func() int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/servers/uptime", nil)
+		c.Params = gin.Params{{Key: "id", Value: strconv.FormatUint(uint64(server.ID), 10)}}
+		GetServerUptime(c)
+		return w.Code
+	}
+
+	if code := uptime(); code != http.StatusOK {
+		t.Fatalf("uptime status %d, want 200", code)
+	}
+	if err := database.DB.Delete(&server).Error; err != nil {
+		t.Fatal(err)
+	}
+	if code := uptime(); code != http.StatusNotFound {
+		t.Errorf("uptime of a deleted server: status %d, want 404", code)
+	}
+}
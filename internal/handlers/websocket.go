@@ -6,31 +6,73 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 
+	"monitoring/config"
+	"monitoring/internal/middleware"
 	"monitoring/internal/utils"
 	ws "monitoring/internal/websocket"
 )
 
-var wsUpgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
+// wsUpgrader builds an upgrader with the configured buffer sizes
+func wsUpgrader() *websocket.Upgrader {
+	cfg := config.Get()
+	return &websocket.Upgrader{
+		ReadBufferSize:  cfg.WSReadBuffer,
+		WriteBufferSize: cfg.WSWriteBuffer,
+		// Gorilla answers 403 when this returns false
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || middleware.OriginAllowed(origin)
+		},
+	}
+}
+
+// acquireWSConnection counts an upgrade against the caller's IP, answering
+// 429 before the upgrade when the IP already has WS_MAX_PER_IP connections.
+// The IP is the peer address, not ClientIP, so a forged X-Forwarded-For
+// cannot spread connections over made-up addresses. Accepted connections
+// are released with ws.Hub.ReleaseConnection(c.RemoteIP()).
+func acquireWSConnection(c *gin.Context) bool {
+	if ws.Hub.AcquireConnection(c.RemoteIP()) {
 		return true
-	},
+	}
+
+	utils.AppLogger.Warning("Refused WebSocket connection from %s: too many open connections", c.RemoteIP())
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": "Too many WebSocket connections from this address",
+		"limit": config.Get().WSMaxPerIP,
+	})
+	return false
 }
 
 // MonitorWebSocket handles WebSocket connections for real-time metrics
 func MonitorWebSocket(c *gin.Context) {
-	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if !acquireWSConnection(c) {
+		return
+	}
+
+	conn, err := wsUpgrader().Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		utils.AppLogger.Error("Failed to upgrade to WebSocket: %v", err)
+		ws.Hub.ReleaseConnection(c.RemoteIP())
 		return
 	}
 
 	clientID := utils.GenerateID()
-	client := ws.NewClient(clientID, conn, ws.Hub)
+	client := ws.NewClient(clientID, c.RemoteIP(), conn, ws.Hub)
 
 	ws.Hub.Register(client)
 
 	go client.WritePump()
 	go client.ReadPump()
 }
+
+// GetWebSocketStats returns the connected metric clients, the number of
+// watched servers and the open connections per client IP
+func GetWebSocketStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"clients":     ws.Hub.GetClientStats(),
+		"rooms":       ws.Hub.GetRoomCount(),
+		"connections": ws.Hub.GetConnectionCounts(),
+		"max_per_ip":  config.Get().WSMaxPerIP,
+	})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/config"
+	"monitoring/internal/utils"
+	ws "monitoring/internal/websocket"
+)
+
+func TestAcquireWSConnectionIgnoresForwardedFor(t *testing.T) {
+	t.Setenv("WS_MAX_PER_IP", "2")
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	utils.InitLogger(utils.LogError + 1)
+	ws.InitHub()
+	gin.SetMode(gin.TestMode)
+
+	codes := make([]int, 0, 3)
+	for _, forwarded := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/ws", nil)
+		c.Request.RemoteAddr = "192.0.2.10:40000"
+		c.Request.Header.Set("X-Forwarded-For", forwarded)
+
+		if acquireWSConnection(c) {
+			codes = append(codes, http.StatusOK)
+		} else {
+			codes = append(codes, w.Code)
+		}
+	}
+
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Errorf("status codes = %v, want [200 200 429]", codes)
+	}
+	if counts := ws.Hub.GetConnectionCounts(); len(counts) != 1 || counts["192.0.2.10"] != 2 {
+		t.Errorf("connection counts = %v, want only 192.0.2.10 with 2", counts)
+	}
+}
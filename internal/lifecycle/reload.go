@@ -0,0 +1,52 @@
+package lifecycle
+
+import (
+	"strings"
+
+	"monitoring/config"
+	"monitoring/internal/monitor"
+	"monitoring/internal/utils"
+)
+
+// Reload re-reads the configuration and applies what can change while
+// running: the log level and the metrics interval of every worker. Other
+// settings take effect the next time they are read.
+func Reload() {
+	changes, ignored := config.Reload()
+
+	for _, field := range ignored {
+		utils.AppLogger.Warning("Configuration reload: %s only changes after a restart, keeping the current value", field)
+	}
+
+	if len(changes) == 0 {
+		utils.AppLogger.Info("Configuration reloaded, nothing changed")
+		return
+	}
+
+	descriptions := make([]string, len(changes))
+	for i, change := range changes {
+		descriptions[i] = change.String()
+	}
+	utils.AppLogger.Info("Configuration reloaded: %s", strings.Join(descriptions, ", "))
+
+	cfg := config.Get()
+	for _, change := range changes {
+		switch change.Field {
+		case "LogLevel":
+			level, err := utils.ParseLogLevel(cfg.LogLevel)
+			if err != nil {
+				utils.AppLogger.Warning("Keeping log level %s: %v", utils.AppLogger.Level(), err)
+				continue
+			}
+			utils.AppLogger.SetLevel(level)
+		case "MetricsInterval":
+			if cfg.MetricsInterval <= 0 {
+				utils.AppLogger.Warning("Ignoring non-positive metrics interval %s", cfg.MetricsInterval)
+				continue
+			}
+			if monitor.Pool != nil {
+				monitor.Pool.SetInterval(cfg.MetricsInterval)
+			}
+		}
+	}
+}
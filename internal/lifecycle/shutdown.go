@@ -0,0 +1,118 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"monitoring/config"
+	"monitoring/internal/audit"
+	"monitoring/internal/monitor"
+	"monitoring/internal/scheduler"
+	"monitoring/internal/sftp"
+	"monitoring/internal/ssh"
+	"monitoring/internal/utils"
+	"monitoring/internal/websocket"
+	"monitoring/internal/winrm"
+)
+
+// Serve runs srv until SIGINT or SIGTERM, then drains HTTP requests and shuts
+// the rest of the application down within SHUTDOWN_TIMEOUT. SIGHUP reloads
+// the configuration.
+func Serve(srv *http.Server) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+wait:
+	for {
+		select {
+		case err := <-errCh:
+			return err
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				Reload()
+				continue
+			}
+			utils.AppLogger.Info("Received %s, shutting down", sig)
+			break wait
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Get().ShutdownTimeout)
+	defer cancel()
+
+	// WebSocket connections are hijacked and not tracked by srv, Shutdown closes them
+	if err := srv.Shutdown(ctx); err != nil {
+		utils.AppLogger.Warning("HTTP server did not drain in time: %v", err)
+	}
+	return Shutdown(ctx)
+}
+
+// drainGrace is how long jobs still running at the deadline get to finish
+// once their connections are closed
+const drainGrace = 5 * time.Second
+
+// Shutdown stops monitoring and scheduled jobs, disconnects WebSocket
+// clients, waits for SFTP transfers and closes the connection pools. It
+// returns once everything is drained or ctx expires; pools are closed either
+// way.
+func Shutdown(ctx context.Context) error {
+	if monitor.Pool != nil {
+		monitor.Pool.StopAll()
+	}
+	jobsRunning := false
+	if scheduler.Jobs != nil {
+		if err := scheduler.Jobs.Stop(ctx); err != nil {
+			utils.AppLogger.Warning("Shutdown deadline reached with scheduled jobs still running")
+			jobsRunning = true
+		}
+	}
+
+	if websocket.Hub != nil {
+		websocket.Hub.CloseAll()
+	}
+
+	err := sftp.WaitForTransfers(ctx)
+	if err != nil {
+		utils.AppLogger.Warning("Shutdown deadline reached with transfers still running")
+	}
+
+	if sftp.Pool != nil {
+		sftp.Pool.CloseAll()
+	}
+	if ssh.Pool != nil {
+		ssh.Pool.CloseAll()
+	}
+	if winrm.Pool != nil {
+		winrm.Pool.CloseAll()
+	}
+
+	// Closing the pools makes leftover jobs fail fast; give them a moment to
+	// record their runs before the audit log closes. Later entries are dropped.
+	if jobsRunning {
+		grace, cancel := context.WithTimeout(context.Background(), drainGrace)
+		if scheduler.Jobs.Stop(grace) != nil {
+			utils.AppLogger.Warning("Scheduled jobs still running after the connection pools closed")
+		}
+		cancel()
+	}
+
+	if audit.Log != nil {
+		audit.Log.Close()
+	}
+
+	utils.AppLogger.Info("Shutdown complete")
+	return err
+}
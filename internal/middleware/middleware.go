@@ -2,17 +2,41 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"monitoring/config"
+	"monitoring/internal/utils"
 )
 
-// CORS returns a middleware for handling CORS
+// CORS returns a middleware for handling CORS against ALLOWED_ORIGINS.
+// Requests from other origins are rejected with 403; requests without an
+// Origin header (same-origin or non-browser clients) pass through.
 func CORS() gin.HandlerFunc {
+	if len(config.Get().AllowedOrigins) == 0 {
+		utils.AppLogger.Warning("ALLOWED_ORIGINS is empty, accepting requests from any origin")
+	}
+
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
+		origin := c.GetHeader("Origin")
+
+		if len(config.Get().AllowedOrigins) == 0 {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else if origin != "" {
+			c.Header("Vary", "Origin")
+			if !OriginAllowed(origin) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Origin not allowed"})
+				return
+			}
+			// Credentialed requests need the exact origin rather than "*"
+			c.Header("Access-Control-Allow-Origin", origin)
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Requested-With, X-Session-ID, Range")
 		c.Header("Access-Control-Max-Age", "86400")
 
 		if c.Request.Method == "OPTIONS" {
@@ -24,6 +48,23 @@ func CORS() gin.HandlerFunc {
 	}
 }
 
+// OriginAllowed reports whether a browser origin may call the API. An empty
+// ALLOWED_ORIGINS allows everything to preserve the old behavior.
+func OriginAllowed(origin string) bool {
+	allowed := config.Get().AllowedOrigins
+	if len(allowed) == 0 {
+		return true
+	}
+
+	origin = strings.TrimSuffix(origin, "/")
+	for _, candidate := range allowed {
+		if candidate == "*" || strings.EqualFold(strings.TrimSuffix(candidate, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
 // Logger returns a middleware for logging requests
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/config"
+	"monitoring/internal/utils"
+)
+
+func TestCORSPreflightAllowsRequestHeaders(t *testing.T) {
+	t.Setenv("ALLOWED_ORIGINS", "https://dashboard.example.com")
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	utils.InitLogger(utils.LogError + 1)
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(CORS())
+	router.GET("/files/download", func(c *gin.Context) {})
+
+	req := httptest.NewRequest(http.MethodOptions, "/files/download", nil)
+	req.Header.Set("Origin", "https://dashboard.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("preflight status %d, want 204", w.Code)
+	}
+	allowed := strings.Split(w.Header().Get("Access-Control-Allow-Headers"), ", ")
+	for _, header := range []string{"Content-Type", "Authorization", "X-Session-ID", "Range"} {
+		found := false
+		for _, name := range allowed {
+			found = found || strings.EqualFold(name, header)
+		}
+		if !found {
+			t.Errorf("preflight does not allow %s: %v", header, allowed)
+		}
+	}
+}
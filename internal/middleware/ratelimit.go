@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+	"monitoring/internal/ratelimit"
+)
+
+// ServerRateLimit limits requests per server on routes with a :serverId
+// parameter (the SSH command and SFTP groups), answering 429 with
+// Retry-After when the server's bucket is empty. Unknown servers pass
+// through so the handler can report them.
+func ServerRateLimit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		serverID, err := strconv.ParseUint(c.Param("serverId"), 10, 32)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		var server models.Server
+		if err := database.DB.Select("id", "rate_limit", "rate_burst").First(&server, serverID).Error; err != nil {
+			c.Next()
+			return
+		}
+
+		if allowed, delay := ratelimit.Servers.Allow(&server); !allowed {
+			retryAfter := ratelimit.RetryAfterSeconds(delay)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Too many requests for this server",
+				"retry_after": retryAfter,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
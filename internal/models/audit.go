@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditSSHCommand AuditAction = "ssh_command"
+	AuditSSHShell   AuditAction = "ssh_shell"
+	AuditUpload     AuditAction = "sftp_upload"
+	AuditDelete     AuditAction = "sftp_delete"
+	AuditRename     AuditAction = "sftp_rename"
+	AuditChmod      AuditAction = "sftp_chmod"
+	AuditChown      AuditAction = "sftp_chown"
+)
+
+// AuditEntry records an operation performed against a server
+type AuditEntry struct {
+	ID        uint        `gorm:"primaryKey" json:"id"`
+	Timestamp time.Time   `gorm:"index" json:"timestamp"`
+	Actor     string      `gorm:"type:varchar(100)" json:"actor"`
+	ServerID  uint        `gorm:"index" json:"server_id"`
+	Action    AuditAction `gorm:"type:varchar(30);index" json:"action"`
+	Target    string      `gorm:"type:text" json:"target"`
+	Success   bool        `json:"success"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_entries"
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+type CustomParseType string
+
+const (
+	CustomParseNumber CustomParseType = "number"
+	CustomParseString CustomParseType = "string"
+)
+
+// CustomMetric is a command run on every collection tick whose output is
+// reported in MetricSnapshot.Custom under Name
+type CustomMetric struct {
+	ID        uint            `gorm:"primaryKey" json:"id"`
+	ServerID  uint            `gorm:"uniqueIndex:idx_custom_metric_server_name;not null" json:"server_id"`
+	Name      string          `gorm:"type:varchar(50);uniqueIndex:idx_custom_metric_server_name;not null" json:"name"`
+	Command   string          `gorm:"type:varchar(1000);not null" json:"command"`
+	ParseType CustomParseType `gorm:"type:varchar(10);default:'number'" json:"parse_type"`
+	Timeout   int             `json:"timeout"` // Seconds, 0 uses CUSTOM_METRIC_TIMEOUT
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func (CustomMetric) TableName() string {
+	return "custom_metrics"
+}
+
+// CustomMetricRequest for API input
+type CustomMetricRequest struct {
+	Name      string          `json:"name" binding:"required,max=50"`
+	Command   string          `json:"command" binding:"required,max=1000"`
+	ParseType CustomParseType `json:"parse_type"`
+	Timeout   int             `json:"timeout"`
+}
@@ -15,6 +15,14 @@ type FileInfo struct {
 	ModTime     time.Time   `json:"mod_time"`
 	Owner       string      `json:"owner"`
 	Group       string      `json:"group"`
+	OwnerName   string      `json:"owner_name,omitempty"` // Only resolved for single paths
+	GroupName   string      `json:"group_name,omitempty"`
+	MimeType    string      `json:"mime_type,omitempty"`
+	Previewable bool        `json:"previewable,omitempty"`
+	IsSymlink   bool        `json:"is_symlink"`
+	LinkTarget  string      `json:"link_target,omitempty"`
+	TargetIsDir bool        `json:"target_is_dir,omitempty"`
+	LinkBroken  bool        `json:"link_broken,omitempty"`
 }
 
 // DirectoryRequest for creating directories
@@ -28,10 +36,48 @@ type RenameRequest struct {
 	NewPath string `json:"new_path" binding:"required"`
 }
 
-// DeleteRequest for deleting files/directories
+// DeleteRequest for deleting files/directories. A recursive delete of a
+// non-empty directory needs the Confirm token returned by a DryRun of it.
 type DeleteRequest struct {
 	Path      string `json:"path" binding:"required"`
 	Recursive bool   `json:"recursive"`
+	DryRun    bool   `json:"dry_run"`
+	Confirm   string `json:"confirm"`
+}
+
+// DeleteEntry is a file or directory a delete would remove
+type DeleteEntry struct {
+	Path  string `json:"path"`
+	IsDir bool   `json:"is_dir"`
+	Size  int64  `json:"size"`
+}
+
+// DeletePreview lists what deleting Path would remove. Entries holds at
+// most MaxPreviewEntries, the counts and size cover the whole tree.
+type DeletePreview struct {
+	Path        string        `json:"path"`
+	Entries     []DeleteEntry `json:"entries"`
+	Files       int           `json:"files"`
+	Directories int           `json:"directories"`
+	TotalSize   int64         `json:"total_size"`
+	Truncated   bool          `json:"truncated"`
+	Confirm     string        `json:"confirm"`
+}
+
+// MaxPreviewEntries caps the entries listed in a delete preview
+const MaxPreviewEntries = 10000
+
+// BulkDeleteRequest for deleting several files/directories at once
+type BulkDeleteRequest struct {
+	Items []DeleteRequest `json:"items" binding:"required,min=1,max=1000,dive"`
+}
+
+// BulkDeleteResult is the outcome for one path of a bulk delete
+type BulkDeleteResult struct {
+	Path    string         `json:"path"`
+	Success bool           `json:"success"`
+	Error   string         `json:"error,omitempty"`
+	Preview *DeletePreview `json:"preview,omitempty"`
 }
 
 // ContentRequest for reading/writing file content
@@ -40,10 +86,75 @@ type ContentRequest struct {
 	Content string `json:"content"`
 }
 
-// ChmodRequest for changing file permissions
+// ChmodRequest for changing file permissions. FilePermission and
+// DirPermission replace Permission for that kind of entry, like find -type;
+// a kind with no mode at all is left unchanged.
 type ChmodRequest struct {
-	Path       string      `json:"path" binding:"required"`
-	Permission os.FileMode `json:"permission" binding:"required"`
+	Path           string       `json:"path" binding:"required"`
+	Permission     os.FileMode  `json:"permission"`
+	FilePermission *os.FileMode `json:"file_permission"`
+	DirPermission  *os.FileMode `json:"dir_permission"`
+	Recursive      bool         `json:"recursive"`
+}
+
+// FilesystemStats describes the filesystem holding a path. Available is
+// what an unprivileged user can still write, Free includes reserved blocks.
+type FilesystemStats struct {
+	Path           string  `json:"path"`
+	Total          uint64  `json:"total"`
+	Used           uint64  `json:"used"`
+	Free           uint64  `json:"free"`
+	Available      uint64  `json:"available"`
+	UsedPercent    float64 `json:"used_percent"`
+	TotalHuman     string  `json:"total_human"`
+	UsedHuman      string  `json:"used_human"`
+	FreeHuman      string  `json:"free_human"`
+	AvailableHuman string  `json:"available_human"`
+	Source         string  `json:"source"` // statvfs or df
+}
+
+// FailedPath is an entry a bulk operation could not process
+type FailedPath struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// ChmodResult reports a recursive permission change. Failed lists at most
+// the first MaxReportedFailures entries, FailedCount counts all of them.
+type ChmodResult struct {
+	Changed     int          `json:"changed"`
+	Failed      []FailedPath `json:"failed"`
+	FailedCount int          `json:"failed_count"`
+}
+
+// MaxReportedFailures caps the entries listed in bulk operation results
+const MaxReportedFailures = 100
+
+// ChownRequest for changing file ownership. UID/GID take precedence over
+// User/Group names; whichever is omitted keeps its current value.
+type ChownRequest struct {
+	Path      string `json:"path" binding:"required"`
+	UID       *int   `json:"uid"`
+	GID       *int   `json:"gid"`
+	User      string `json:"user"`
+	Group     string `json:"group"`
+	Recursive bool   `json:"recursive"`
+}
+
+// TouchRequest for setting file times. Times are RFC 3339 or "now", which
+// is also used when a time is omitted.
+type TouchRequest struct {
+	Path   string `json:"path" binding:"required"`
+	Atime  string `json:"atime"`
+	Mtime  string `json:"mtime"`
+	Create bool   `json:"create"` // Create an empty file if it does not exist
+}
+
+// SymlinkRequest for creating a symbolic link
+type SymlinkRequest struct {
+	Target   string `json:"target" binding:"required"` // Relative targets resolve from the link's directory
+	LinkPath string `json:"link_path" binding:"required"`
+	Force    bool   `json:"force"` // Replace an existing link or file
 }
 
 // SearchRequest for searching files
@@ -54,14 +165,82 @@ type SearchRequest struct {
 
 // SearchResult represents a search result
 type SearchResult struct {
-	Files []FileInfo `json:"files"`
-	Total int        `json:"total"`
+	Files     []FileInfo `json:"files"`
+	Total     int        `json:"total"`
+	Truncated bool       `json:"truncated"` // Limit or timeout reached before the walk finished
+	TimedOut  bool       `json:"timed_out"`
+	Errors    []string   `json:"errors"` // Paths that could not be read
+}
+
+// GrepMatch is a line found by a content search
+type GrepMatch struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// GrepResult for content search across files
+type GrepResult struct {
+	Matches   []GrepMatch `json:"matches"`
+	Total     int         `json:"total"`
+	Truncated bool        `json:"truncated"`
 }
 
 // DirectorySizeResult for directory size
 type DirectorySizeResult struct {
-	Path       string `json:"path"`
-	Size       int64  `json:"size"`
-	FileCount  int    `json:"file_count"`
-	DirCount   int    `json:"dir_count"`
+	Path       string  `json:"path"`
+	Size       int64   `json:"size"`
+	FileCount  int     `json:"file_count"`
+	DirCount   int     `json:"dir_count"`
+	Cached     bool    `json:"cached"`
+	AgeSeconds float64 `json:"age_seconds"` // Time since the size was computed
+}
+
+// ChildDirectorySize is the size of one subdirectory. Size and the counts
+// are zero while Computing is set.
+type ChildDirectorySize struct {
+	Name string `json:"name"`
+	DirectorySizeResult
+	Computing bool `json:"computing"`
+	TimedOut  bool `json:"timed_out"` // Too large to size within the crawl time
+}
+
+// ChildDirectorySizesResult for the sizes of a directory's subdirectories
+type ChildDirectorySizesResult struct {
+	Path        string               `json:"path"`
+	Directories []ChildDirectorySize `json:"directories"`
+	Computing   bool                 `json:"computing"` // Poll again for the missing sizes
+	Truncated   bool                 `json:"truncated"` // Only the first subdirectories were sized
+}
+
+// ChecksumResult for file integrity verification
+type ChecksumResult struct {
+	Path      string `json:"path"`
+	Algorithm string `json:"algorithm"`
+	Hash      string `json:"hash"`
+	Size      int64  `json:"size"`
+}
+
+// PreviewInfo describes how a file can be rendered by the UI
+type PreviewInfo struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	MimeType    string `json:"mime_type"`
+	Previewable bool   `json:"previewable"`
+}
+
+// ExtractRequest for unpacking a remote archive
+type ExtractRequest struct {
+	Path        string `json:"path" binding:"required"`
+	Destination string `json:"destination"`
+}
+
+// TransferRequest copies a file from one server to another
+type TransferRequest struct {
+	SourceServerID      uint   `json:"source_server_id" binding:"required"`
+	SourcePath          string `json:"source_path" binding:"required"`
+	DestinationServerID uint   `json:"destination_server_id" binding:"required"`
+	DestinationPath     string `json:"destination_path" binding:"required"`
+	PreserveMode        bool   `json:"preserve_mode"`
+	Move                bool   `json:"move"` // Delete the source after a successful copy
 }
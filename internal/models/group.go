@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ServerGroup is a named collection of servers
+type ServerGroup struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"name"`
+	Description string    `gorm:"type:varchar(255)" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (ServerGroup) TableName() string {
+	return "server_groups"
+}
+
+// CreateGroupRequest for API input
+type CreateGroupRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// AssignGroupRequest for API input, a nil GroupID removes the server from its group
+type AssignGroupRequest struct {
+	GroupID *uint `json:"group_id"`
+}
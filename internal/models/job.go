@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+type JobTrigger string
+
+const (
+	JobTriggerSchedule JobTrigger = "schedule"
+	JobTriggerManual   JobTrigger = "manual"
+)
+
+// ScheduledJob runs Command on a server whenever Schedule fires. The Last*
+// fields summarise the most recent run, JobRun keeps the history.
+type ScheduledJob struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	ServerID     uint       `gorm:"index;not null" json:"server_id"`
+	Name         string     `gorm:"type:varchar(100);not null" json:"name"`
+	Schedule     string     `gorm:"type:varchar(100);not null" json:"schedule"` // Cron expression
+	Command      string     `gorm:"type:varchar(1000);not null" json:"command"`
+	Timeout      int        `json:"timeout"` // Seconds, 0 uses SSH_COMMAND_TIMEOUT
+	Enabled      bool       `gorm:"default:true" json:"enabled"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	LastExitCode *int       `json:"last_exit_code"`
+	LastError    string     `gorm:"type:text" json:"last_error,omitempty"`
+	NextRunAt    *time.Time `gorm:"-" json:"next_run_at,omitempty"` // Filled from the scheduler
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (ScheduledJob) TableName() string {
+	return "scheduled_jobs"
+}
+
+// JobRun is the result of one execution of a scheduled job. ExitCode is nil
+// when the command could not be run, Error then says why.
+type JobRun struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	JobID      uint       `gorm:"index;not null" json:"job_id"`
+	ServerID   uint       `gorm:"index" json:"server_id"`
+	Trigger    JobTrigger `gorm:"column:trigger_type;type:varchar(10)" json:"trigger"` // trigger is reserved in MySQL
+	StartedAt  time.Time  `gorm:"index" json:"started_at"`
+	FinishedAt time.Time  `json:"finished_at"`
+	ExitCode   *int       `json:"exit_code"`
+	Stdout     string     `gorm:"type:text" json:"stdout"`
+	Stderr     string     `gorm:"type:text" json:"stderr"`
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+}
+
+func (JobRun) TableName() string {
+	return "job_runs"
+}
+
+// ScheduledJobRequest for API input
+type ScheduledJobRequest struct {
+	Name     string `json:"name" binding:"required,max=100"`
+	Schedule string `json:"schedule" binding:"required,max=100"`
+	Command  string `json:"command" binding:"required,max=1000"`
+	Timeout  int    `json:"timeout"`
+	Enabled  *bool  `json:"enabled"` // Defaults to true
+}
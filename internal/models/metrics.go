@@ -0,0 +1,55 @@
+package models
+
+// ContainerStat describes a Docker container and its resource usage
+type ContainerStat struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Image      string  `json:"image"`
+	Status     string  `json:"status"`
+	Running    bool    `json:"running"`
+	CPUPercent float64 `json:"cpu_percent"`
+	MemUsage   uint64  `json:"mem_usage"`
+	MemLimit   uint64  `json:"mem_limit"`
+	MemPercent float64 `json:"mem_percent"`
+}
+
+// GPUStat describes the utilization of a single GPU. Memory is in MB.
+type GPUStat struct {
+	Index       int     `json:"index"`
+	Name        string  `json:"name"`
+	Utilization float64 `json:"utilization"`
+	MemUsed     uint64  `json:"mem_used"`
+	MemTotal    uint64  `json:"mem_total"`
+	Temperature float64 `json:"temperature"`
+}
+
+// DiskIOStat describes the activity of a block device. Rates are bytes per
+// second and Utilization is the percentage of time the device was busy.
+type DiskIOStat struct {
+	Device      string  `json:"device"`
+	ReadRate    float64 `json:"read_rate"`
+	WriteRate   float64 `json:"write_rate"`
+	Utilization float64 `json:"utilization"`
+}
+
+// InodeStat describes inode usage of a mounted filesystem
+type InodeStat struct {
+	Filesystem string  `json:"filesystem"`
+	Mount      string  `json:"mount"`
+	Total      uint64  `json:"total"`
+	Used       uint64  `json:"used"`
+	Free       uint64  `json:"free"`
+	Percent    float64 `json:"percent"`
+}
+
+// OpenPort is a listening TCP or UDP socket. Process and PID are empty when
+// the owner is hidden, as other users' processes are without root.
+type OpenPort struct {
+	Protocol string `json:"protocol"` // tcp or udp
+	IPv6     bool   `json:"ipv6"`
+	Address  string `json:"address"` // "*" listens on every address
+	Port     int    `json:"port"`
+	State    string `json:"state"` // LISTEN for TCP, UNCONN or empty for UDP
+	Process  string `json:"process,omitempty"`
+	PID      int    `json:"pid,omitempty"`
+}
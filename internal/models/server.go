@@ -1,6 +1,12 @@
 package models
 
 import (
+	"fmt"
+	"net"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -18,96 +24,476 @@ const (
 	ConnWinRM ConnectionType = "WinRM"
 	ConnSFTP  ConnectionType = "SFTP"
 
-	StatusOnline  ServerStatus = "online"
-	StatusOffline ServerStatus = "offline"
-	StatusError   ServerStatus = "error"
+	StatusOnline      ServerStatus = "online"
+	StatusOffline     ServerStatus = "offline"
+	StatusError       ServerStatus = "error"
+	StatusMaintenance ServerStatus = "maintenance" // Monitoring paused
+	StatusDisabled    ServerStatus = "disabled"    // Enabled is false, no worker runs
+	StatusPending     ServerStatus = "pending"     // No metrics collected yet, only sent over WebSocket
 )
 
 type Server struct {
-	ID         uint           `gorm:"primaryKey" json:"id"`
-	IPAddress  string         `gorm:"column:ip_address;type:varchar(20);not null" json:"ip_address"`
-	Password   string         `gorm:"type:varchar(255)" json:"-"`
-	Port       string         `gorm:"type:varchar(10);default:'22'" json:"port"`
-	Sys        ServerSys      `gorm:"type:varchar(1);default:'L'" json:"sys"`
-	Connection ConnectionType `gorm:"type:varchar(10);default:'SSH'" json:"connection"`
-	Username   string         `gorm:"type:varchar(50)" json:"username"`
-	Name       string         `gorm:"type:varchar(100)" json:"name"`
-	Status     ServerStatus   `gorm:"type:varchar(20);default:'offline'" json:"status"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uint           `gorm:"primaryKey" json:"id"`
+	IPAddress    string         `gorm:"column:ip_address;type:varchar(255);not null;index" json:"ip_address"`
+	Password     string         `gorm:"type:varchar(255)" json:"-"`
+	Port         string         `gorm:"type:varchar(10);default:'22'" json:"port"`
+	Sys          ServerSys      `gorm:"type:varchar(1);default:'L'" json:"sys"`
+	Connection   ConnectionType `gorm:"type:varchar(10);default:'SSH'" json:"connection"`
+	Username     string         `gorm:"type:varchar(50);index" json:"username"`
+	Name         string         `gorm:"type:varchar(100);index" json:"name"`
+	Status       ServerStatus   `gorm:"type:varchar(20);default:'offline';index" json:"status"`
+	RootPath     string         `gorm:"type:varchar(255)" json:"root_path"`
+	NetInterface string         `gorm:"type:varchar(30)" json:"net_interface"`
+	Tags         string         `gorm:"type:varchar(255)" json:"-"` // Stored as ",tag1,tag2," for LIKE filtering
+	GroupID      *uint          `gorm:"index" json:"group_id"`
+	JumpHost     string         `gorm:"type:varchar(255)" json:"jump_host"` // Bastion the SSH connection goes through
+	JumpPort     string         `gorm:"type:varchar(10)" json:"jump_port"`
+	JumpUser     string         `gorm:"type:varchar(50)" json:"jump_user"`                    // Defaults to Username
+	JumpPassword string         `gorm:"type:varchar(255)" json:"-"`                           // Encrypted, defaults to Password
+	UseSudo      bool           `gorm:"default:false" json:"use_sudo"`                        // Pipe a password to commands starting with sudo
+	SudoPassword string         `gorm:"type:varchar(255)" json:"-"`                           // Encrypted, defaults to Password
+	SSHPreset    string         `gorm:"column:ssh_preset;type:varchar(20)" json:"ssh_preset"` // Algorithm preset, empty uses SSH_PRESET
+	RateLimit    *float64       `json:"rate_limit"`                                           // Overrides SERVER_RATE_LIMIT when set
+	RateBurst    *int           `json:"rate_burst"`
+	UploadQuota  *int64         `json:"upload_quota"`                      // Bytes per upload request, overrides UPLOAD_QUOTA when set
+	Enabled      bool           `gorm:"default:true;index" json:"enabled"` // Disabled servers keep their settings but are not monitored
+	ReadOnly     bool           `gorm:"default:false" json:"read_only"`    // SFTP handlers refuse to modify files
+	// TLSSkipVerify accepts any certificate on the WinRM HTTPS endpoint,
+	// e.g. the self-signed one Windows creates for its listener
+	TLSSkipVerify bool `gorm:"column:tls_skip_verify;default:false" json:"tls_skip_verify"`
+	// Shell wraps every command, e.g. "/bin/bash -lc"; Env is exported before it
+	Shell string            `gorm:"type:varchar(100)" json:"shell"`
+	Env   map[string]string `gorm:"type:text;serializer:json" json:"env"`
+	// MetricCommands replaces collector commands (see MetricCommandNames)
+	// on platforms where the Linux defaults do not work
+	MetricCommands map[string]string `gorm:"type:text;serializer:json" json:"metric_commands"`
+	// ProxyJump is an ssh-style "user@bastion:port" used instead of the Jump
+	// fields. SSHConfigPath names an ssh_config file in SSH_KEY_DIR consulted
+	// for the HostName, Port, User and ProxyJump of host aliases.
+	ProxyJump     string `gorm:"type:varchar(255)" json:"proxy_jump"`
+	SSHConfigPath string `gorm:"column:ssh_config_path;type:varchar(255)" json:"ssh_config_path"`
+	// KeyPath names a private key in SSH_KEY_DIR, falling back to the
+	// IdentityFile of the ssh_config entry. AuthOrder restricts login to
+	// "password" or "key"; empty tries the key first, then the password.
+	KeyPath   string         `gorm:"type:varchar(255)" json:"key_path"`
+	AuthOrder string         `gorm:"type:varchar(10)" json:"auth_order"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 func (Server) TableName() string {
 	return "servers"
 }
 
+// UsesWinRM reports whether the server is reached over WinRM instead of SSH
+func (s *Server) UsesWinRM() bool {
+	return s.Connection == ConnWinRM || s.Sys == SysWindows
+}
+
+// TagList returns the server tags as a slice
+func (s *Server) TagList() []string {
+	tags := []string{}
+	for _, tag := range strings.Split(s.Tags, ",") {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// SetTags normalizes and stores tags, dropping blanks and duplicates
+func (s *Server) SetTags(tags []string) {
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) == 0 {
+		s.Tags = ""
+		return
+	}
+	s.Tags = "," + strings.Join(normalized, ",") + ","
+}
+
+// NormalizeHost trims an address and checks it is an IPv4 or IPv6 address
+// (brackets and zones allowed) or a DNS hostname
+func NormalizeHost(host string) (string, error) {
+	host = strings.TrimSpace(host)
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	if host == "" {
+		return "", fmt.Errorf("ip_address is required")
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.String(), nil
+	}
+	if !isHostname(host) {
+		return "", fmt.Errorf("ip_address %q is not a valid IP address or hostname", host)
+	}
+	return strings.ToLower(strings.TrimSuffix(host, ".")), nil
+}
+
+// isHostname checks RFC 1123 hostname syntax
+func isHostname(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	if len(host) == 0 || len(host) > 253 {
+		return false
+	}
+
+	labels := strings.Split(host, ".")
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+
+	// A dotted all-numeric name would be a malformed IPv4 address
+	_, err := strconv.Atoi(labels[len(labels)-1])
+	return err != nil
+}
+
+// NormalizePort trims a port, defaulting to 22, and checks it is 1-65535
+func NormalizePort(port string) (string, error) {
+	port = strings.TrimSpace(port)
+	if port == "" {
+		return "22", nil
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("port %q must be an integer between 1 and 65535", port)
+	}
+	return strconv.Itoa(n), nil
+}
+
+// JumpSpec is a parsed ProxyJump. User and Port are empty when not given.
+type JumpSpec struct {
+	User string
+	Host string
+	Port string
+}
+
+// ParseProxyJump reads an ssh ProxyJump value, [user@]host[:port] with IPv6
+// hosts in brackets, or an ssh:// URI of the same form. Empty and "none"
+// mean no jump host and return nil. Only a single hop is supported.
+func ParseProxyJump(spec string) (*JumpSpec, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || strings.EqualFold(spec, "none") {
+		return nil, nil
+	}
+	if strings.Contains(spec, ",") {
+		return nil, fmt.Errorf("proxy_jump %q lists several hops, only one jump host is supported", spec)
+	}
+
+	jump := &JumpSpec{}
+	rest := strings.TrimPrefix(spec, "ssh://")
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		jump.User, rest = rest[:i], rest[i+1:]
+		if jump.User == "" {
+			return nil, fmt.Errorf("proxy_jump %q has an empty user", spec)
+		}
+	}
+
+	host := rest
+	if strings.HasPrefix(rest, "[") || strings.Count(rest, ":") == 1 {
+		var err error
+		if host, jump.Port, err = net.SplitHostPort(rest); err != nil && strings.HasPrefix(rest, "[") && strings.HasSuffix(rest, "]") {
+			host, err = rest, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("proxy_jump %q: %v", spec, err)
+		}
+	}
+
+	var err error
+	if jump.Host, err = NormalizeHost(host); err != nil {
+		return nil, fmt.Errorf("proxy_jump host %q is not a valid IP address or hostname", host)
+	}
+	if jump.Port != "" {
+		if jump.Port, err = NormalizePort(jump.Port); err != nil {
+			return nil, fmt.Errorf("proxy_jump %v", err)
+		}
+	}
+	return jump, nil
+}
+
+// NormalizeProxyJump checks a ProxyJump value and returns it in canonical
+// form, empty when there is no jump host
+func NormalizeProxyJump(spec string) (string, error) {
+	jump, err := ParseProxyJump(spec)
+	if err != nil || jump == nil {
+		return "", err
+	}
+	return jump.String(), nil
+}
+
+// String formats the spec the way ParseProxyJump reads it
+func (j *JumpSpec) String() string {
+	s := j.Host
+	if j.Port != "" {
+		s = net.JoinHostPort(j.Host, j.Port)
+	} else if strings.Contains(j.Host, ":") {
+		s = "[" + j.Host + "]"
+	}
+	if j.User != "" {
+		s = j.User + "@" + s
+	}
+	return s
+}
+
+var (
+	envNamePattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	shellWordPattern = regexp.MustCompile(`^[A-Za-z0-9_/.+=-]+$`)
+)
+
+// ValidateShell checks a command wrapper such as "/bin/bash -lc". It is
+// placed before the quoted command unquoted, so only plain words are allowed.
+func ValidateShell(shell string) error {
+	for _, word := range strings.Fields(shell) {
+		if !shellWordPattern.MatchString(word) {
+			return fmt.Errorf("shell %q may only contain a program path and plain flags", shell)
+		}
+	}
+	return nil
+}
+
+// ValidateEnv checks that every variable name is a valid shell identifier.
+// Values are quoted when used, so any value is accepted.
+func ValidateEnv(env map[string]string) error {
+	for name := range env {
+		if !envNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+	}
+	return nil
+}
+
+// Collectors whose command can be overridden, and the output each must print
+const (
+	MetricCommandCPU    = "cpu"    // Usage percentage
+	MetricCommandMemory = "memory" // Total, used and free MB
+	MetricCommandDisk   = "disk"   // Total, used and free GB
+	MetricCommandUptime = "uptime" // Seconds since boot
+)
+
+// MetricCommandNames lists the collectors accepted in metric command overrides
+var MetricCommandNames = []string{MetricCommandCPU, MetricCommandMemory, MetricCommandDisk, MetricCommandUptime}
+
+// ValidateMetricCommands checks that every override names a known collector
+func ValidateMetricCommands(commands map[string]string) error {
+	for name := range commands {
+		known := false
+		for _, candidate := range MetricCommandNames {
+			known = known || name == candidate
+		}
+		if !known {
+			return fmt.Errorf("unknown metric collector %q, expected one of %s", name, strings.Join(MetricCommandNames, ", "))
+		}
+	}
+	return nil
+}
+
+// NormalizeTag lowercases a tag and strips the comma separator
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(strings.ReplaceAll(tag, ",", "")))
+}
+
 // ServerDTO for API responses
 type ServerDTO struct {
-	ID         uint           `json:"id"`
-	IPAddress  string         `json:"ip_address"`
-	Port       string         `json:"port"`
-	Sys        ServerSys      `json:"sys"`
-	Connection ConnectionType `json:"connection"`
-	Username   string         `json:"username"`
-	Name       string         `json:"name"`
-	Status     ServerStatus   `json:"status"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
+	ID             uint              `json:"id"`
+	IPAddress      string            `json:"ip_address"`
+	Port           string            `json:"port"`
+	Sys            ServerSys         `json:"sys"`
+	Connection     ConnectionType    `json:"connection"`
+	Username       string            `json:"username"`
+	Name           string            `json:"name"`
+	Status         ServerStatus      `json:"status"`
+	RootPath       string            `json:"root_path"`
+	NetInterface   string            `json:"net_interface"`
+	Tags           []string          `json:"tags"`
+	GroupID        *uint             `json:"group_id"`
+	JumpHost       string            `json:"jump_host,omitempty"`
+	JumpPort       string            `json:"jump_port,omitempty"`
+	JumpUser       string            `json:"jump_user,omitempty"`
+	ProxyJump      string            `json:"proxy_jump,omitempty"`
+	SSHConfigPath  string            `json:"ssh_config_path,omitempty"`
+	KeyPath        string            `json:"key_path,omitempty"`
+	AuthOrder      string            `json:"auth_order,omitempty"`
+	UseSudo        bool              `json:"use_sudo"`
+	SSHPreset      string            `json:"ssh_preset,omitempty"`
+	RateLimit      *float64          `json:"rate_limit,omitempty"`
+	RateBurst      *int              `json:"rate_burst,omitempty"`
+	UploadQuota    *int64            `json:"upload_quota,omitempty"`
+	Enabled        bool              `json:"enabled"`
+	ReadOnly       bool              `json:"read_only"`
+	TLSSkipVerify  bool              `json:"tls_skip_verify"`
+	Shell          string            `json:"shell,omitempty"`
+	Env            map[string]string `json:"env,omitempty"`
+	MetricCommands map[string]string `json:"metric_commands,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
 }
 
 func (s *Server) ToDTO() ServerDTO {
 	return ServerDTO{
-		ID:         s.ID,
-		IPAddress:  s.IPAddress,
-		Port:       s.Port,
-		Sys:        s.Sys,
-		Connection: s.Connection,
-		Username:   s.Username,
-		Name:       s.Name,
-		Status:     s.Status,
-		CreatedAt:  s.CreatedAt,
-		UpdatedAt:  s.UpdatedAt,
+		ID:             s.ID,
+		IPAddress:      s.IPAddress,
+		Port:           s.Port,
+		Sys:            s.Sys,
+		Connection:     s.Connection,
+		Username:       s.Username,
+		Name:           s.Name,
+		Status:         s.Status,
+		RootPath:       s.RootPath,
+		NetInterface:   s.NetInterface,
+		Tags:           s.TagList(),
+		GroupID:        s.GroupID,
+		JumpHost:       s.JumpHost,
+		JumpPort:       s.JumpPort,
+		JumpUser:       s.JumpUser,
+		ProxyJump:      s.ProxyJump,
+		SSHConfigPath:  s.SSHConfigPath,
+		KeyPath:        s.KeyPath,
+		AuthOrder:      s.AuthOrder,
+		UseSudo:        s.UseSudo,
+		SSHPreset:      s.SSHPreset,
+		RateLimit:      s.RateLimit,
+		RateBurst:      s.RateBurst,
+		UploadQuota:    s.UploadQuota,
+		Enabled:        s.Enabled,
+		ReadOnly:       s.ReadOnly,
+		TLSSkipVerify:  s.TLSSkipVerify,
+		Shell:          s.Shell,
+		Env:            s.Env,
+		MetricCommands: s.MetricCommands,
+		CreatedAt:      s.CreatedAt,
+		UpdatedAt:      s.UpdatedAt,
 	}
 }
 
 // CreateServerRequest for API input
 type CreateServerRequest struct {
-	IPAddress  string         `json:"ip_address" binding:"required"`
-	Password   string         `json:"password" binding:"required"`
-	Port       string         `json:"port"`
-	Sys        ServerSys      `json:"sys"`
-	Connection ConnectionType `json:"connection"`
-	Username   string         `json:"username" binding:"required"`
-	Name       string         `json:"name" binding:"required"`
+	IPAddress      string            `json:"ip_address" binding:"required"`
+	Password       string            `json:"password" binding:"required"`
+	Port           string            `json:"port"`
+	Sys            ServerSys         `json:"sys"`
+	Connection     ConnectionType    `json:"connection"`
+	Username       string            `json:"username" binding:"required"`
+	Name           string            `json:"name" binding:"required"`
+	RootPath       string            `json:"root_path"`
+	NetInterface   string            `json:"net_interface"`
+	Tags           []string          `json:"tags"`
+	GroupID        *uint             `json:"group_id"`
+	JumpHost       string            `json:"jump_host"`
+	JumpPort       string            `json:"jump_port"`
+	JumpUser       string            `json:"jump_user"`
+	JumpPassword   string            `json:"jump_password"`
+	ProxyJump      string            `json:"proxy_jump"` // user@bastion:port, instead of jump_host
+	SSHConfigPath  string            `json:"ssh_config_path"`
+	KeyPath        string            `json:"key_path"`   // Private key in SSH_KEY_DIR
+	AuthOrder      string            `json:"auth_order"` // password, key or empty for key then password
+	UseSudo        bool              `json:"use_sudo"`
+	SudoPassword   string            `json:"sudo_password"`
+	SSHPreset      string            `json:"ssh_preset"`
+	RateLimit      *float64          `json:"rate_limit"`
+	RateBurst      *int              `json:"rate_burst"`
+	UploadQuota    *int64            `json:"upload_quota"`
+	Enabled        *bool             `json:"enabled"` // Defaults to true
+	ReadOnly       bool              `json:"read_only"`
+	TLSSkipVerify  bool              `json:"tls_skip_verify"`
+	Shell          string            `json:"shell"`
+	Env            map[string]string `json:"env"`
+	MetricCommands map[string]string `json:"metric_commands"`
+	// AllowDuplicate skips the check for a server with the same address,
+	// port and username, to monitor one host under several names
+	AllowDuplicate bool `json:"allow_duplicate"`
 }
 
 // UpdateServerRequest for API input
 type UpdateServerRequest struct {
-	IPAddress  string         `json:"ip_address"`
-	Password   string         `json:"password"`
-	Port       string         `json:"port"`
-	Sys        ServerSys      `json:"sys"`
-	Connection ConnectionType `json:"connection"`
-	Username   string         `json:"username"`
-	Name       string         `json:"name"`
+	IPAddress      string            `json:"ip_address"`
+	Password       string            `json:"password"`
+	Port           string            `json:"port"`
+	Sys            ServerSys         `json:"sys"`
+	Connection     ConnectionType    `json:"connection"`
+	Username       string            `json:"username"`
+	Name           string            `json:"name"`
+	RootPath       *string           `json:"root_path"`
+	NetInterface   *string           `json:"net_interface"`
+	Tags           *[]string         `json:"tags"`
+	JumpHost       *string           `json:"jump_host"` // Empty removes the jump host
+	JumpPort       *string           `json:"jump_port"`
+	JumpUser       *string           `json:"jump_user"`
+	JumpPassword   *string           `json:"jump_password"`
+	ProxyJump      *string           `json:"proxy_jump"`      // Empty removes it
+	SSHConfigPath  *string           `json:"ssh_config_path"` // Empty removes it
+	KeyPath        *string           `json:"key_path"`        // Empty removes it
+	AuthOrder      *string           `json:"auth_order"`
+	UseSudo        *bool             `json:"use_sudo"`
+	SudoPassword   *string           `json:"sudo_password"` // Empty falls back to the login password
+	SSHPreset      *string           `json:"ssh_preset"`
+	RateLimit      *float64          `json:"rate_limit"`   // Negative clears the override
+	RateBurst      *int              `json:"rate_burst"`   // Negative clears the override
+	UploadQuota    *int64            `json:"upload_quota"` // Negative clears the override
+	Enabled        *bool             `json:"enabled"`
+	ReadOnly       *bool             `json:"read_only"`
+	TLSSkipVerify  *bool             `json:"tls_skip_verify"`
+	Shell          *string           `json:"shell"`           // Empty runs commands in the login shell
+	Env            map[string]string `json:"env"`             // Replaces the stored variables, {} clears them
+	MetricCommands map[string]string `json:"metric_commands"` // Replaces the stored overrides, {} clears them
 }
 
 // MetricSnapshot for real-time WebSocket broadcast (not stored in DB)
 type MetricSnapshot struct {
-	ServerID    uint    `json:"server_id"`
-	ServerName  string  `json:"server_name"`
-	CPUUsage    float64 `json:"cpu_usage"`
-	MemTotal    uint64  `json:"mem_total"`
-	MemUsed     uint64  `json:"mem_used"`
-	MemFree     uint64  `json:"mem_free"`
-	MemPercent  float64 `json:"mem_percent"`
-	DiskTotal   uint64  `json:"disk_total"`
-	DiskUsed    uint64  `json:"disk_used"`
-	DiskFree    uint64  `json:"disk_free"`
-	DiskPercent float64 `json:"disk_percent"`
-	NetRX       uint64  `json:"net_rx"`
-	NetTX       uint64  `json:"net_tx"`
-	Uptime      uint64  `json:"uptime"`
-	Timestamp   int64   `json:"timestamp"`
+	ServerID     uint    `json:"server_id"`
+	ServerName   string  `json:"server_name"`
+	CPUUsage     float64 `json:"cpu_usage"`
+	MemTotal     uint64  `json:"mem_total"`
+	MemUsed      uint64  `json:"mem_used"`
+	MemFree      uint64  `json:"mem_free"`
+	MemPercent   float64 `json:"mem_percent"`
+	DiskTotal    uint64  `json:"disk_total"`
+	DiskUsed     uint64  `json:"disk_used"`
+	DiskFree     uint64  `json:"disk_free"`
+	DiskPercent  float64 `json:"disk_percent"`
+	NetRX        uint64  `json:"net_rx"`
+	NetTX        uint64  `json:"net_tx"`
+	NetRXBytes   uint64  `json:"net_rx_bytes"`
+	NetTXBytes   uint64  `json:"net_tx_bytes"`
+	NetRXRate    float64 `json:"net_rx_rate"` // Bytes per second
+	NetTXRate    float64 `json:"net_tx_rate"` // Bytes per second
+	NetInterface string  `json:"net_interface"`
+	Uptime       uint64  `json:"uptime"`
+	Timestamp    int64   `json:"timestamp"`
+
+	Temperatures map[string]float64     `json:"temperatures"`
+	GPUs         []GPUStat              `json:"gpus"`
+	DiskIO       []DiskIOStat           `json:"disk_io"`
+	Inodes       []InodeStat            `json:"inodes"`
+	Custom       map[string]interface{} `json:"custom,omitempty"` // Values of the server's CustomMetric commands
+}
+
+// SystemInfo describes the remote host. It rarely changes, so it is
+// collected once and cached until refreshed.
+type SystemInfo struct {
+	Hostname    string    `json:"hostname"`
+	OS          string    `json:"os"`
+	Kernel      string    `json:"kernel"`
+	Arch        string    `json:"arch"`
+	CPUModel    string    `json:"cpu_model"`
+	CollectedAt time.Time `json:"collected_at"`
 }
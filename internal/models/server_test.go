@@ -0,0 +1,79 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+		ok   bool
+	}{
+		{"192.168.1.10", "192.168.1.10", true},
+		{"  10.0.0.1 ", "10.0.0.1", true},
+		{"::1", "::1", true},
+		{"[::1]", "::1", true},
+		{"2001:DB8:0:0:0:0:0:1", "2001:db8::1", true},
+		{" [2001:db8::1] ", "2001:db8::1", true},
+		{"fe80::1%eth0", "fe80::1%eth0", true},
+		{"::ffff:192.0.2.1", "::ffff:192.0.2.1", true},
+		{"web-01.Example.COM", "web-01.example.com", true},
+		{"db.internal.", "db.internal", true},
+		{"localhost", "localhost", true},
+		{"a." + strings.Repeat("b", 63) + ".io", "a." + strings.Repeat("b", 63) + ".io", true},
+		{"", "", false},
+		{"   ", "", false},
+		{"[]", "", false},
+		{"2001:db8::g", "", false},
+		{"2001:db8:::1", "", false},
+		{"[2001:db8::1", "", false},
+		{"256.1.1.1", "", false},
+		{"1.2.3", "", false},
+		{"-web.example.com", "", false},
+		{"web-.example.com", "", false},
+		{"web..example.com", "", false},
+		{"web_01.example.com", "", false},
+		{"host name", "", false},
+		{"host:22", "", false},
+		{strings.Repeat("b", 64) + ".io", "", false},
+		{strings.Repeat("a.", 127) + "io", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizeHost(tt.host)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("NormalizeHost(%q) = %q, %v; want %q, ok %v", tt.host, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNormalizePort(t *testing.T) {
+	tests := []struct {
+		port string
+		want string
+		ok   bool
+	}{
+		{"", "22", true},
+		{"  ", "22", true},
+		{"22", "22", true},
+		{" 2222 ", "2222", true},
+		{"1", "1", true},
+		{"65535", "65535", true},
+		{"0022", "22", true},
+		{"0", "", false},
+		{"65536", "", false},
+		{"-1", "", false},
+		{"abc", "", false},
+		{"22a", "", false},
+		{"2.2", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := NormalizePort(tt.port)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("NormalizePort(%q) = %q, %v; want %q, ok %v", tt.port, got, err, tt.want, tt.ok)
+		}
+	}
+}
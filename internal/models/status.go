@@ -0,0 +1,82 @@
+package models
+
+import "time"
+
+// StatusEvent records a server moving from one status to another. Only
+// real transitions are stored, so a server that stays online adds nothing.
+type StatusEvent struct {
+	ID        uint         `gorm:"primaryKey" json:"id"`
+	ServerID  uint         `gorm:"index:idx_status_events_server_time,priority:1" json:"server_id"`
+	From      ServerStatus `gorm:"column:from_status;type:varchar(20)" json:"from"` // Empty for a new server
+	To        ServerStatus `gorm:"column:to_status;type:varchar(20)" json:"to"`
+	Timestamp time.Time    `gorm:"index:idx_status_events_server_time,priority:2" json:"timestamp"`
+}
+
+func (StatusEvent) TableName() string {
+	return "status_events"
+}
+
+// UptimeReport summarises a server's availability over a window
+type UptimeReport struct {
+	Percent   float64       // Share of monitored time spent online
+	Monitored time.Duration // Window minus maintenance, disabled and pre-creation time
+	Downtime  time.Duration // Time spent offline or in error
+	Incidents int           // Periods of downtime overlapping the window
+}
+
+// isDown reports whether a status counts as an outage
+func isDown(status ServerStatus) bool {
+	return status == StatusOffline || status == StatusError
+}
+
+// ComputeUptime walks the transitions of [from, to). initial is the status
+// at from and events are the transitions inside the window in time order.
+// Maintenance and disabled time are left out, so planned downtime does not
+// count against the server, as is time before the server was added (empty
+// status). A window with no monitored time is 100% up.
+func ComputeUptime(initial ServerStatus, events []StatusEvent, from, to time.Time) UptimeReport {
+	var report UptimeReport
+	var online time.Duration
+
+	status, start := initial, from
+	if isDown(status) {
+		report.Incidents++
+	}
+	account := func(end time.Time) {
+		if !end.After(start) {
+			return
+		}
+		span := end.Sub(start)
+		switch {
+		case status == StatusMaintenance, status == StatusDisabled, status == "":
+			return
+		case status == StatusOnline:
+			online += span
+		case isDown(status):
+			report.Downtime += span
+		}
+		report.Monitored += span
+	}
+
+	for _, event := range events {
+		if event.Timestamp.Before(from) {
+			status = event.To
+			continue
+		}
+		if !event.Timestamp.Before(to) {
+			break
+		}
+		account(event.Timestamp)
+		if isDown(event.To) && !isDown(status) {
+			report.Incidents++
+		}
+		status, start = event.To, event.Timestamp
+	}
+	account(to)
+
+	report.Percent = 100
+	if report.Monitored > 0 {
+		report.Percent = float64(online) / float64(report.Monitored) * 100
+	}
+	return report
+}
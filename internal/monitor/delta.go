@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+
+	"monitoring/internal/models"
+)
+
+// deltaEpsilon is the smallest numeric change that is broadcast in delta mode
+const deltaEpsilon = 0.05
+
+// deltaSkipFields identify the snapshot and are sent on every delta message
+var deltaSkipFields = map[string]bool{
+	"server_id":   true,
+	"server_name": true,
+	"timestamp":   true,
+}
+
+// metricsDelta tracks the metric values clients last received from a worker
+type metricsDelta struct {
+	known map[string]interface{}
+}
+
+// diff returns the JSON fields of snapshot that changed beyond deltaEpsilon
+// since they were last sent, and records them as sent. Fields that drift by
+// less than the epsilon accumulate until the total change is large enough.
+func (d *metricsDelta) diff(snapshot *models.MetricSnapshot) (map[string]interface{}, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	var current map[string]interface{}
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, err
+	}
+
+	if d.known == nil {
+		d.known = make(map[string]interface{})
+	}
+
+	changes := make(map[string]interface{})
+	for field, value := range current {
+		if deltaSkipFields[field] {
+			continue
+		}
+
+		previous, ok := d.known[field]
+		if ok && !valueChanged(previous, value) {
+			continue
+		}
+
+		changes[field] = value
+		d.known[field] = value
+	}
+
+	return changes, nil
+}
+
+func valueChanged(previous, current interface{}) bool {
+	a, aNum := previous.(float64)
+	b, bNum := current.(float64)
+	if aNum && bNum {
+		return math.Abs(a-b) > deltaEpsilon
+	}
+	return !reflect.DeepEqual(previous, current)
+}
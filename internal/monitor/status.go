@@ -0,0 +1,22 @@
+package monitor
+
+import (
+	"time"
+
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+	"monitoring/internal/utils"
+)
+
+// RecordStatusChange stores a status transition of a server in its history.
+// Nothing is stored when the status did not change.
+func RecordStatusChange(serverID uint, from, to models.ServerStatus) {
+	if from == to {
+		return
+	}
+
+	event := &models.StatusEvent{ServerID: serverID, From: from, To: to, Timestamp: time.Now()}
+	if err := database.DB.Create(event).Error; err != nil {
+		utils.AppLogger.Error("Failed to record status change of server %d: %v", serverID, err)
+	}
+}
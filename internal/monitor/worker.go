@@ -11,19 +11,78 @@ import (
 	"monitoring/internal/ssh"
 	"monitoring/internal/utils"
 	"monitoring/internal/websocket"
+	"monitoring/internal/winrm"
 )
 
+// reconnectBackoff is the pause after a worker exhausts its reconnect attempts
+const reconnectBackoff = 30 * time.Second
+
 // Worker monitors a single server
 type Worker struct {
 	server    *models.Server
 	password  string
-	sshClient *ssh.SSHClient
-	collector *ssh.MetricCollector
+	conn      connection // *ssh.SSHClient or *winrm.WinRMClient
+	collector metricCollector
 	ctx       context.Context
 	cancel    context.CancelFunc
 	logger    *utils.ContextLogger
 	running   bool
 	mu        sync.Mutex
+
+	// Previous network counters, used to derive throughput
+	prevNetRX   uint64
+	prevNetTX   uint64
+	prevNetTime time.Time
+
+	// Fields already sent to clients in delta mode
+	delta metricsDelta
+
+	lastMetrics *models.MetricSnapshot // Most recent successful collection
+	reconnects  uint64
+
+	// Reliability counters, failures cover both reconnects and collections
+	failures            uint64
+	consecutiveFailures uint64 // Reset by a successful collection
+	lastError           string
+	lastErrorAt         time.Time
+	lastSuccess         time.Time
+
+	// Paused workers keep their connection but skip collection and status updates
+	paused       bool
+	pauseChanged chan bool
+
+	interval        time.Duration // Collection period, METRICS_INTERVAL when started
+	intervalChanged chan time.Duration
+}
+
+// WorkerState is a point-in-time view of a worker for observability
+type WorkerState struct {
+	ServerID            uint
+	ServerName          string
+	Running             bool
+	Paused              bool
+	Reconnects          uint64
+	Failures            uint64
+	ConsecutiveFailures uint64
+	LastError           string
+	LastErrorAt         time.Time
+	LastSuccess         time.Time
+	LastMetrics         *models.MetricSnapshot
+}
+
+// connection is the transport a worker uses to reach its server
+type connection interface {
+	IsConnected() bool
+}
+
+// metricCollector is implemented by the SSH and WinRM collectors
+type metricCollector interface {
+	CollectAll() (*models.MetricSnapshot, error)
+}
+
+// customCollector runs user-defined metric commands, SSH only for now
+type customCollector interface {
+	CollectCustom(metrics []models.CustomMetric) map[string]interface{}
 }
 
 // WorkerPool manages all monitoring workers
@@ -49,7 +108,7 @@ func InitWorkerPool() {
 // StartAll starts monitoring for all active servers
 func (p *WorkerPool) StartAll() error {
 	var servers []models.Server
-	if err := database.DB.Find(&servers).Error; err != nil {
+	if err := database.DB.Where("enabled = ?", true).Find(&servers).Error; err != nil {
 		return err
 	}
 
@@ -83,6 +142,11 @@ func (p *WorkerPool) AddWorker(server *models.Server, password string) error {
 		ctx:      ctx,
 		cancel:   cancel,
 		logger:   utils.AppLogger.WithContext(server.ID, server.Name),
+
+		pauseChanged: make(chan bool, 1),
+
+		interval:        config.Get().MetricsInterval,
+		intervalChanged: make(chan time.Duration, 1),
 	}
 
 	p.workers[server.ID] = worker
@@ -127,6 +191,64 @@ func (p *WorkerPool) GetWorkerStatus(serverID uint) bool {
 	return false
 }
 
+// SetInterval moves every worker whose collection period differs to interval
+func (p *WorkerPool) SetInterval(interval time.Duration) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, worker := range p.workers {
+		worker.SetInterval(interval)
+	}
+}
+
+// SetPaused pauses or resumes a worker, returning false if it does not exist
+func (p *WorkerPool) SetPaused(serverID uint, paused bool) bool {
+	p.mu.RLock()
+	worker, exists := p.workers[serverID]
+	p.mu.RUnlock()
+
+	if !exists {
+		return false
+	}
+	worker.SetPaused(paused)
+	return true
+}
+
+// IsPaused reports whether monitoring of a server is paused
+func (p *WorkerPool) IsPaused(serverID uint) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if worker, exists := p.workers[serverID]; exists {
+		return worker.IsPaused()
+	}
+	return false
+}
+
+// State returns the state of one worker, false if the server is not monitored
+func (p *WorkerPool) State(serverID uint) (WorkerState, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	worker, exists := p.workers[serverID]
+	if !exists {
+		return WorkerState{}, false
+	}
+	return worker.state(), true
+}
+
+// States returns the state of every worker without contacting any server
+func (p *WorkerPool) States() []WorkerState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	states := make([]WorkerState, 0, len(p.workers))
+	for _, worker := range p.workers {
+		states = append(states, worker.state())
+	}
+	return states
+}
+
 func (w *Worker) Run() {
 	w.mu.Lock()
 	w.running = true
@@ -138,81 +260,312 @@ func (w *Worker) Run() {
 		w.mu.Unlock()
 	}()
 
+	if !w.server.UsesWinRM() {
+		ssh.Pool.Pin(w.server.ID)
+		defer ssh.Pool.Unpin(w.server.ID)
+	}
+
 	if err := w.connect(); err != nil {
 		w.logger.Error("Initial connection failed: %v", err)
+		w.recordFailure(err)
 		w.updateServerStatus(models.StatusError)
 	} else {
 		w.updateServerStatus(models.StatusOnline)
 	}
 
-	ticker := time.NewTicker(config.AppConfig.MetricsInterval)
+	w.mu.Lock()
+	interval := w.interval
+	w.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	reconnectAttempts := 0
-	maxReconnectAttempts := 3
+
+	// While backoff is set ticks are skipped; it shares the select with
+	// ctx.Done so a stopped worker exits at once
+	var backoff <-chan time.Time
+	collect := func() {
+		if w.collect(&reconnectAttempts) {
+			backoff = time.After(reconnectBackoff)
+		}
+	}
 
 	for {
 		select {
 		case <-w.ctx.Done():
 			w.logger.Info("Worker stopping")
 			return
-		case <-ticker.C:
-			if w.sshClient == nil || !w.sshClient.IsConnected() {
-				reconnectAttempts++
-				if reconnectAttempts > maxReconnectAttempts {
-					w.logger.Error("Max reconnect attempts reached")
-					w.updateServerStatus(models.StatusError)
-					reconnectAttempts = 0
-					time.Sleep(30 * time.Second)
-					continue
-				}
-
-				w.logger.Warning("Connection lost, reconnecting (%d/%d)", reconnectAttempts, maxReconnectAttempts)
-				if err := w.connect(); err != nil {
-					w.logger.Error("Reconnection failed: %v", err)
-					w.updateServerStatus(models.StatusError)
-					continue
-				}
-				reconnectAttempts = 0
-				w.updateServerStatus(models.StatusOnline)
+		case paused := <-w.pauseChanged:
+			if paused {
+				w.logger.Info("Monitoring paused")
+				w.updateServerStatus(models.StatusMaintenance)
+				continue
 			}
 
-			metrics, err := w.collector.CollectAll()
-			if err != nil {
-				w.logger.Error("Failed to collect metrics: %v", err)
+			w.logger.Info("Monitoring resumed")
+			if w.conn != nil && w.conn.IsConnected() {
+				w.updateServerStatus(models.StatusOnline)
+			}
+			backoff = nil
+			collect()
+		case interval := <-w.intervalChanged:
+			w.logger.Info("Collection interval changed to %s", interval)
+			ticker.Reset(interval)
+		case <-backoff:
+			backoff = nil
+			if !w.IsPaused() {
+				collect()
+			}
+		case <-ticker.C:
+			if w.IsPaused() || backoff != nil {
 				continue
 			}
+			collect()
+		}
+	}
+}
 
-			websocket.Hub.BroadcastMetrics(metrics)
+// collect reconnects if needed, then gathers and broadcasts one snapshot.
+// It returns true once reconnecting has failed maxReconnectAttempts times in
+// a row and the worker should back off for reconnectBackoff.
+func (w *Worker) collect(reconnectAttempts *int) bool {
+	const maxReconnectAttempts = 3
+
+	if w.conn == nil || !w.conn.IsConnected() {
+		*reconnectAttempts++
+		if *reconnectAttempts > maxReconnectAttempts {
+			w.logger.Error("Max reconnect attempts reached, retrying in %s", reconnectBackoff)
+			w.updateServerStatus(models.StatusError)
+			*reconnectAttempts = 0
+			return true
 		}
+
+		w.logger.Warning("Connection lost, reconnecting (%d/%d)", *reconnectAttempts, maxReconnectAttempts)
+		w.mu.Lock()
+		w.reconnects++
+		w.mu.Unlock()
+		if err := w.connect(); err != nil {
+			w.logger.Error("Reconnection failed: %v", err)
+			w.recordFailure(err)
+			w.updateServerStatus(models.StatusError)
+			return false
+		}
+		*reconnectAttempts = 0
+		w.updateServerStatus(models.StatusOnline)
+	}
+
+	metrics, err := w.collector.CollectAll()
+	if err != nil {
+		w.logger.Error("Failed to collect metrics: %v", err)
+		w.recordFailure(err)
+		return false
+	}
+
+	w.applyNetworkRates(metrics)
+	w.collectCustom(metrics)
+
+	w.mu.Lock()
+	w.lastMetrics = metrics
+	w.lastSuccess = time.Now()
+	w.consecutiveFailures = 0
+	w.mu.Unlock()
+
+	w.broadcast(metrics)
+	return false
+}
+
+// recordFailure counts a failed connection or collection
+func (w *Worker) recordFailure(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.failures++
+	w.consecutiveFailures++
+	w.lastError = err.Error()
+	w.lastErrorAt = time.Now()
+}
+
+// collectCustom adds the server's custom metric values to the snapshot.
+// Definitions are read on every tick so edits apply without a restart.
+func (w *Worker) collectCustom(metrics *models.MetricSnapshot) {
+	collector, ok := w.collector.(customCollector)
+	if !ok {
+		return
+	}
+
+	var custom []models.CustomMetric
+	if err := database.DB.Where("server_id = ?", w.server.ID).Find(&custom).Error; err != nil {
+		w.logger.Error("Failed to load custom metrics: %v", err)
+		return
+	}
+	if len(custom) > 0 {
+		metrics.Custom = collector.CollectCustom(custom)
 	}
 }
 
+// connect picks SSH or WinRM based on the server's connection type
 func (w *Worker) connect() error {
-	client, err := ssh.Pool.GetClient(w.server, w.password)
+	if w.server.UsesWinRM() {
+		client, err := winrm.Pool.GetClient(w.server, w.password)
+		if err != nil {
+			return err
+		}
+
+		w.conn = client
+		w.collector = winrm.NewMetricCollector(client)
+		return nil
+	}
+
+	// Stop cancels w.ctx, which abandons a dial to an unresponsive host
+	client, err := ssh.Pool.GetClientContext(w.ctx, w.server, w.password)
 	if err != nil {
 		return err
 	}
 
-	w.sshClient = client
+	w.conn = client
 	w.collector = ssh.NewMetricCollector(client)
 	return nil
 }
 
-// updateServerStatus updates the server status in database
+// broadcast sends a snapshot to clients, only the changed fields in delta mode
+func (w *Worker) broadcast(metrics *models.MetricSnapshot) {
+	if !config.Get().WSDeltaMode {
+		websocket.Hub.BroadcastMetrics(metrics)
+		return
+	}
+
+	changes, err := w.delta.diff(metrics)
+	if err != nil {
+		w.logger.Error("Failed to compute metrics delta: %v", err)
+		websocket.Hub.BroadcastMetrics(metrics)
+		return
+	}
+
+	websocket.Hub.BroadcastMetricsDelta(metrics, changes)
+}
+
+// applyNetworkRates derives bytes/sec throughput from the previous sample.
+// A counter that went backwards (reboot, interface reset) yields a zero rate.
+func (w *Worker) applyNetworkRates(metrics *models.MetricSnapshot) {
+	now := time.Now()
+
+	// Network collection failed, start over on the next sample
+	if metrics.NetRXBytes == 0 && metrics.NetTXBytes == 0 {
+		w.prevNetTime = time.Time{}
+		return
+	}
+
+	if !w.prevNetTime.IsZero() {
+		elapsed := now.Sub(w.prevNetTime).Seconds()
+		if elapsed > 0 {
+			if metrics.NetRXBytes >= w.prevNetRX {
+				metrics.NetRXRate = float64(metrics.NetRXBytes-w.prevNetRX) / elapsed
+			}
+			if metrics.NetTXBytes >= w.prevNetTX {
+				metrics.NetTXRate = float64(metrics.NetTXBytes-w.prevNetTX) / elapsed
+			}
+		}
+	}
+
+	w.prevNetRX = metrics.NetRXBytes
+	w.prevNetTX = metrics.NetTXBytes
+	w.prevNetTime = now
+}
+
+// updateServerStatus updates the server status in database and records
+// transitions in its history. A stopped worker leaves it alone, e.g. so it
+// does not undo a server being disabled.
 func (w *Worker) updateServerStatus(status models.ServerStatus) {
+	if w.ctx.Err() != nil {
+		return
+	}
+	previous := w.server.Status
 	w.server.Status = status
 	database.DB.Model(&models.Server{}).Where("id = ?", w.server.ID).Update("status", status)
+	RecordStatusChange(w.server.ID, previous, status)
 }
 
 // Stop stops the worker
 func (w *Worker) Stop() {
 	w.cancel()
-	if w.sshClient != nil {
+	if w.conn == nil {
+		return
+	}
+
+	if w.server.UsesWinRM() {
+		winrm.Pool.RemoveClient(w.server.ID)
+	} else {
 		ssh.Pool.RemoveClient(w.server.ID)
 	}
 }
 
+func (w *Worker) state() WorkerState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return WorkerState{
+		ServerID:            w.server.ID,
+		ServerName:          w.server.Name,
+		Running:             w.running,
+		Paused:              w.paused,
+		Reconnects:          w.reconnects,
+		Failures:            w.failures,
+		ConsecutiveFailures: w.consecutiveFailures,
+		LastError:           w.lastError,
+		LastErrorAt:         w.lastErrorAt,
+		LastSuccess:         w.lastSuccess,
+		LastMetrics:         w.lastMetrics,
+	}
+}
+
+// SetPaused pauses or resumes collection without tearing the worker down.
+// Resuming triggers a collection right away.
+func (w *Worker) SetPaused(paused bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.paused == paused {
+		return
+	}
+	w.paused = paused
+
+	// Only the latest state matters, replace one the worker has not picked
+	// up. Sending under mu keeps the slot free, so this never blocks while
+	// the worker is busy dialing or collecting.
+	select {
+	case <-w.pauseChanged:
+	default:
+	}
+	w.pauseChanged <- paused
+}
+
+// SetInterval restarts the collection ticker with a new period
+func (w *Worker) SetInterval(interval time.Duration) {
+	w.mu.Lock()
+	changed := w.interval != interval
+	w.interval = interval
+	w.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	// Only the latest period matters, replace one the worker has not picked up
+	select {
+	case <-w.intervalChanged:
+	default:
+	}
+	w.intervalChanged <- interval
+}
+
+// IsPaused returns whether collection is paused
+func (w *Worker) IsPaused() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.paused
+}
+
 // IsRunning returns whether the worker is running
 func (w *Worker) IsRunning() bool {
 	w.mu.Lock()
@@ -0,0 +1,153 @@
+package monitor
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"monitoring/config"
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+	"monitoring/internal/ssh"
+	"monitoring/internal/utils"
+	"monitoring/internal/websocket"
+)
+
+func setupWorkerTest(t *testing.T) {
+	t.Helper()
+	t.Setenv("SSH_TIMEOUT", "60")
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	utils.InitLogger(utils.LogError + 1)
+
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/test.db"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.DB = db
+	if err := database.AutoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	ssh.InitPool()
+	websocket.InitHub()
+	InitWorkerPool()
+	t.Cleanup(Pool.StopAll)
+}
+
+// silentHost accepts TCP connections and never answers, so an SSH dial to
+// it hangs in the handshake until SSH_TIMEOUT. Each accepted connection is
+// reported on the returned channel.
+func silentHost(t *testing.T) (net.Listener, <-chan struct{}) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	accepted := make(chan struct{}, 16)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+			accepted <- struct{}{}
+		}
+	}()
+	return listener, accepted
+}
+
+func TestRemoveWorkerDuringDial(t *testing.T) {
+	setupWorkerTest(t)
+	listener, accepted := silentHost(t)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	server := models.Server{Name: "unreachable", IPAddress: host, Port: port, Username: "root", Enabled: true}
+	if err := database.DB.Create(&server).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Pool.AddWorker(&server, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	Pool.mu.RLock()
+	worker := Pool.workers[server.ID]
+	Pool.mu.RUnlock()
+
+	select {
+	case <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker never dialed the server")
+	}
+
+	// What DeleteServer does once the row is gone
+	start := time.Now()
+	database.DB.Delete(&server)
+	Pool.RemoveWorker(server.ID)
+
+	for worker.IsRunning() {
+		if time.Since(start) > time.Second {
+			t.Fatal("worker still running a second after it was removed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSetPausedWhileDialing(t *testing.T) {
+	setupWorkerTest(t)
+	listener, accepted := silentHost(t)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	server := models.Server{Name: "unreachable", IPAddress: host, Port: port, Username: "root", Enabled: true}
+	if err := database.DB.Create(&server).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Pool.AddWorker(&server, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	Pool.mu.RLock()
+	worker := Pool.workers[server.ID]
+	Pool.mu.RUnlock()
+
+	select {
+	case <-accepted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker never dialed the server")
+	}
+
+	// The worker is stuck in the handshake and reads none of these
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for _, paused := range []bool{true, false, true, false, true} {
+			worker.SetPaused(paused)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("SetPaused blocked while the worker was dialing")
+	}
+	if !worker.IsPaused() {
+		t.Error("worker not paused after the last SetPaused(true)")
+	}
+	if paused := <-worker.pauseChanged; !paused {
+		t.Error("pending pause change is not the latest one")
+	}
+
+	// Let the worker exit before the next test replaces the globals it uses
+	Pool.RemoveWorker(server.ID)
+	for worker.IsRunning() {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+)
+
+// ServerLimiter keeps a token bucket per server for command and SFTP requests
+type ServerLimiter struct {
+	limiters map[uint]*rate.Limiter
+	mu       sync.Mutex
+}
+
+var Servers *ServerLimiter
+
+// InitServerLimiter initializes the per-server rate limiter
+func InitServerLimiter() {
+	Servers = &ServerLimiter{
+		limiters: make(map[uint]*rate.Limiter),
+	}
+}
+
+// Limits returns the requests per second and burst that apply to a server.
+// Per-server values override SERVER_RATE_LIMIT/SERVER_RATE_BURST; a rate of
+// zero disables limiting.
+func Limits(server *models.Server) (float64, int) {
+	limit := config.Get().ServerRateLimit
+	burst := config.Get().ServerRateBurst
+	if server.RateLimit != nil {
+		limit = *server.RateLimit
+	}
+	if server.RateBurst != nil {
+		burst = *server.RateBurst
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return limit, burst
+}
+
+// Allow takes a token for the server. When none is available it returns
+// false and the time until the next one.
+func (l *ServerLimiter) Allow(server *models.Server) (bool, time.Duration) {
+	limit, burst := Limits(server)
+	if limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	limiter, ok := l.limiters[server.ID]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit), burst)
+		l.limiters[server.ID] = limiter
+	} else if limiter.Limit() != rate.Limit(limit) || limiter.Burst() != burst {
+		// Limits were edited since the bucket was created
+		limiter.SetLimit(rate.Limit(limit))
+		limiter.SetBurst(burst)
+	}
+	l.mu.Unlock()
+
+	reservation := limiter.Reserve()
+	if !reservation.OK() {
+		return false, time.Second
+	}
+	if delay := reservation.Delay(); delay > 0 {
+		reservation.Cancel()
+		return false, delay
+	}
+	return true, 0
+}
+
+// Remove drops the bucket of a deleted server
+func (l *ServerLimiter) Remove(serverID uint) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.limiters, serverID)
+}
+
+// RetryAfterSeconds rounds a delay up to the whole seconds used by Retry-After
+func RetryAfterSeconds(delay time.Duration) int {
+	return int(math.Max(1, math.Ceil(delay.Seconds())))
+}
@@ -0,0 +1,37 @@
+package ratelimit
+
+import (
+	"os"
+	"testing"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+)
+
+func TestAllowDisabledByDefault(t *testing.T) {
+	t.Setenv("SERVER_RATE_LIMIT", "")
+	os.Unsetenv("SERVER_RATE_LIMIT")
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	InitServerLimiter()
+
+	server := &models.Server{ID: 1}
+	for i := 0; i < 1000; i++ {
+		if allowed, _ := Servers.Allow(server); !allowed {
+			t.Fatalf("request %d refused without SERVER_RATE_LIMIT", i+1)
+		}
+	}
+
+	limit, burst := 2.0, 3
+	server.RateLimit, server.RateBurst = &limit, &burst
+	refused := 0
+	for i := 0; i < 10; i++ {
+		if allowed, _ := Servers.Allow(server); !allowed {
+			refused++
+		}
+	}
+	if refused != 7 {
+		t.Errorf("server limited to a burst of 3 refused %d of 10 requests, want 7", refused)
+	}
+}
@@ -0,0 +1,28 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule is a parsed cron expression. Next returns the first time after
+// the given one that the schedule fires, or the zero time when it never does.
+type Schedule = cron.Schedule
+
+// Parse reads a standard five field cron expression (minute, hour, day of
+// month, month, day of week) with *, lists, ranges, steps and month or day
+// names, one of the @hourly style descriptors, or "@every <duration>" of at
+// least a minute.
+func Parse(expr string) (Schedule, error) {
+	schedule, err := cron.ParseStandard(strings.TrimSpace(expr))
+	if err != nil {
+		return nil, err
+	}
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok && every.Delay < time.Minute {
+		return nil, fmt.Errorf("@every must be at least 1m")
+	}
+	return schedule, nil
+}
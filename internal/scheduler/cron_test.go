@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr  string
+		valid bool
+	}{
+		{"* * * * *", true},
+		{"  */15 2-4 * * 1-5 ", true},
+		{"0 0 1,15 * *", true},
+		{"30 6 * jan-mar mon,fri", true},
+		{"@hourly", true},
+		{"@daily", true},
+		{"@every 90m", true},
+		{"@every 1m", true},
+		{"@every 59s", false},
+		{"@every soon", false},
+		{"", false},
+		{"* * * *", false},
+		{"* * * * * *", false},
+		{"60 * * * *", false},
+		{"* 24 * * *", false},
+		{"* * 0 * *", false},
+		{"* * * 13 *", false},
+		{"5-1 * * * *", false},
+		{"*/0 * * * *", false},
+		{"@sometimes", false},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if (err == nil) != tt.valid {
+			t.Errorf("Parse(%q) error = %v, want valid %v", tt.expr, err, tt.valid)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"* * * * *", "2024-03-10 08:15", "2024-03-10 08:16"},
+		{"*/15 * * * *", "2024-03-10 08:45", "2024-03-10 09:00"},
+		{"0 0 * * *", "2024-12-31 23:59", "2025-01-01 00:00"},
+		{"0 0 1 * *", "2024-01-31 12:00", "2024-02-01 00:00"},
+		{"0 12 31 * *", "2024-04-01 00:00", "2024-05-31 12:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"0 9 * * mon-fri", "2024-03-08 09:00", "2024-03-11 09:00"},
+		// Both day fields restricted: either one matching is enough
+		{"0 0 13 * fri", "2024-09-01 00:00", "2024-09-06 00:00"},
+		{"0 0 13 * fri", "2024-09-10 00:00", "2024-09-13 00:00"},
+		// Only the day of month restricted: the weekday * does not widen it
+		{"0 0 13 * *", "2024-09-01 00:00", "2024-09-13 00:00"},
+		{"@weekly", "2024-03-06 10:00", "2024-03-10 00:00"},
+		{"@every 90m", "2024-03-10 08:15", "2024-03-10 09:45"},
+	}
+
+	for _, tt := range tests {
+		schedule, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		if got := schedule.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("%q from %s: next = %s, want %s", tt.expr, tt.from, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+}
+
+func TestScheduleNeverFires(t *testing.T) {
+	schedule, err := Parse("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next := schedule.Next(time.Now()); !next.IsZero() {
+		t.Errorf("February 30 fires at %s, want the zero time", next)
+	}
+}
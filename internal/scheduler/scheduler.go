@@ -0,0 +1,329 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"monitoring/config"
+	"monitoring/internal/audit"
+	"monitoring/internal/database"
+	"monitoring/internal/models"
+	"monitoring/internal/ssh"
+	"monitoring/internal/utils"
+)
+
+const (
+	// MaxRunsKept is how many runs of each job stay in the history
+	MaxRunsKept = 50
+
+	// maxOutputSize caps the stdout and stderr stored for a run
+	maxOutputSize = 64 * 1024
+
+	// idleWait is how long the loop sleeps when no job is scheduled
+	idleWait = time.Hour
+)
+
+// ErrJobRunning is returned when a job is started while it is still running
+var ErrJobRunning = errors.New("job is already running")
+
+type entry struct {
+	job      models.ScheduledJob
+	schedule Schedule
+	next     time.Time
+}
+
+// Scheduler runs enabled jobs when their schedule fires. A job never
+// overlaps itself: a run that is due while the previous one is still going
+// is skipped.
+type Scheduler struct {
+	entries map[uint]*entry
+	running map[uint]bool // Jobs with a run in progress, scheduled or manual
+	mu      sync.Mutex
+	wake    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	runs    sync.WaitGroup
+}
+
+var Jobs *Scheduler
+
+// InitScheduler creates the scheduler; Start loads the jobs and runs it
+func InitScheduler() {
+	ctx, cancel := context.WithCancel(context.Background())
+	Jobs = &Scheduler{
+		entries: make(map[uint]*entry),
+		running: make(map[uint]bool),
+		wake:    make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Start schedules every enabled job and starts the scheduling loop
+func (s *Scheduler) Start() error {
+	var jobs []models.ScheduledJob
+	if err := database.DB.Where("enabled = ?", true).Find(&jobs).Error; err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if err := s.Set(job); err != nil {
+			utils.AppLogger.Error("Failed to schedule job %d: %v", job.ID, err)
+		}
+	}
+
+	go s.loop()
+	return nil
+}
+
+// Stop ends the loop and waits for running jobs until ctx expires
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Set schedules job, replacing its previous definition. Disabled jobs are
+// removed from the schedule.
+func (s *Scheduler) Set(job models.ScheduledJob) error {
+	if !job.Enabled {
+		s.Remove(job.ID)
+		return nil
+	}
+
+	schedule, err := Parse(job.Schedule)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.entries[job.ID] = &entry{job: job, schedule: schedule, next: schedule.Next(time.Now())}
+	s.mu.Unlock()
+
+	s.notify()
+	return nil
+}
+
+// Remove unschedules a job. A run in progress finishes.
+func (s *Scheduler) Remove(jobID uint) {
+	s.mu.Lock()
+	delete(s.entries, jobID)
+	s.mu.Unlock()
+}
+
+// RemoveServer unschedules every job of a server
+func (s *Scheduler) RemoveServer(serverID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, e := range s.entries {
+		if e.job.ServerID == serverID {
+			delete(s.entries, id)
+		}
+	}
+}
+
+// NextRun returns when a job fires next, nil when it is not scheduled
+func (s *Scheduler) NextRun(jobID uint) *time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[jobID]
+	if !ok || e.next.IsZero() {
+		return nil
+	}
+	next := e.next
+	return &next
+}
+
+// RunNow runs job immediately and waits for the result
+func (s *Scheduler) RunNow(job models.ScheduledJob, actor string) (*models.JobRun, error) {
+	s.mu.Lock()
+	if s.running[job.ID] {
+		s.mu.Unlock()
+		return nil, ErrJobRunning
+	}
+	s.running[job.ID] = true
+	s.runs.Add(1)
+	s.mu.Unlock()
+
+	return s.execute(job, models.JobTriggerManual, actor), nil
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) loop() {
+	for {
+		wait := idleWait
+		s.mu.Lock()
+		for _, e := range s.entries {
+			if e.next.IsZero() {
+				continue
+			}
+			if until := time.Until(e.next); until < wait {
+				wait = until
+			}
+		}
+		s.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+			s.runDue(time.Now())
+		}
+	}
+}
+
+// runDue starts every job whose time has come and moves it to its next time
+func (s *Scheduler) runDue(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, e := range s.entries {
+		if e.next.IsZero() || e.next.After(now) {
+			continue
+		}
+		e.next = e.schedule.Next(now)
+
+		if s.running[id] {
+			utils.AppLogger.Warning("Job %d (%s) is still running, skipping this run", id, e.job.Name)
+			continue
+		}
+		s.running[id] = true
+		s.runs.Add(1)
+		go s.execute(e.job, models.JobTriggerSchedule, "scheduler")
+	}
+}
+
+// execute runs a job and records the result. The caller has marked the job
+// as running and added it to s.runs.
+func (s *Scheduler) execute(job models.ScheduledJob, trigger models.JobTrigger, actor string) *models.JobRun {
+	defer s.runs.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, job.ID)
+		s.mu.Unlock()
+	}()
+
+	run := &models.JobRun{
+		JobID:     job.ID,
+		ServerID:  job.ServerID,
+		Trigger:   trigger,
+		StartedAt: time.Now(),
+	}
+
+	var server models.Server
+	if err := database.DB.First(&server, job.ServerID).Error; err != nil {
+		run.Error = "server not found"
+	} else if !server.Enabled && trigger == models.JobTriggerSchedule {
+		utils.AppLogger.Debug("Skipping job %d, server %d is disabled", job.ID, server.ID)
+		return nil
+	} else {
+		s.runCommand(&server, job, run)
+		audit.Log.Record(&models.AuditEntry{
+			Actor:    actor,
+			ServerID: server.ID,
+			Action:   models.AuditSSHCommand,
+			Target:   job.Command,
+			Success:  run.Error == "" && run.ExitCode != nil && *run.ExitCode == 0,
+		})
+	}
+	run.FinishedAt = time.Now()
+
+	s.record(job, run)
+	return run
+}
+
+// runCommand executes the job's command and fills in the run
+func (s *Scheduler) runCommand(server *models.Server, job models.ScheduledJob, run *models.JobRun) {
+	if server.UsesWinRM() {
+		run.Error = "scheduled jobs are only supported on SSH servers"
+		return
+	}
+	if allowed, rule := ssh.Policy.Check(job.Command); !allowed {
+		run.Error = fmt.Sprintf("command not allowed (%s)", rule)
+		return
+	}
+
+	password, err := utils.Decrypt(server.Password)
+	if err != nil {
+		run.Error = "failed to decrypt credentials"
+		return
+	}
+
+	client, err := ssh.Pool.GetClient(server, password)
+	if err != nil {
+		run.Error = fmt.Sprintf("failed to connect to server: %v", err)
+		return
+	}
+
+	timeout := config.Get().SSHCommandTimeout
+	if job.Timeout > 0 {
+		timeout = time.Duration(job.Timeout) * time.Second
+	}
+
+	result, err := client.ExecuteDetailed(job.Command, "", nil, timeout)
+	if err != nil {
+		run.Error = err.Error()
+		return
+	}
+
+	exitCode := result.ExitCode
+	run.ExitCode = &exitCode
+	run.Stdout = truncateOutput(result.Stdout)
+	run.Stderr = truncateOutput(result.Stderr)
+	if result.Truncated {
+		run.Error = ssh.ErrOutputTruncated.Error()
+	}
+}
+
+// record stores the run, updates the job's summary and trims old runs
+func (s *Scheduler) record(job models.ScheduledJob, run *models.JobRun) {
+	if err := database.DB.Create(run).Error; err != nil {
+		utils.AppLogger.Error("Failed to store run of job %d: %v", job.ID, err)
+	}
+
+	database.DB.Model(&models.ScheduledJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+		"last_run_at":    run.StartedAt,
+		"last_exit_code": run.ExitCode,
+		"last_error":     run.Error,
+	})
+
+	var oldest []uint
+	database.DB.Model(&models.JobRun{}).Where("job_id = ?", job.ID).
+		Order("id DESC").Offset(MaxRunsKept).Limit(1).Pluck("id", &oldest)
+	if len(oldest) > 0 {
+		database.DB.Where("job_id = ? AND id <= ?", job.ID, oldest[0]).Delete(&models.JobRun{})
+	}
+}
+
+func truncateOutput(output string) string {
+	if len(output) <= maxOutputSize {
+		return output
+	}
+	return output[:maxOutputSize] + "\n[output truncated]"
+}
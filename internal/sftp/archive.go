@@ -0,0 +1,264 @@
+package sftp
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/sftp"
+)
+
+var ErrUnsupportedArchive = errors.New("unsupported archive format, expected zip, tar or tar.gz")
+
+type archiveFormat int
+
+const (
+	formatUnknown archiveFormat = iota
+	formatZip
+	formatTar
+	formatTarGz
+)
+
+// Extraction errors, answered like an upload that is too large
+var (
+	ErrArchiveTooLarge     = errors.New("archive expands past the extraction size limit")
+	ErrArchiveTooManyFiles = errors.New("archive has more entries than the extraction limit")
+)
+
+// maxArchiveEntries caps the entries read from one archive, directories and
+// skipped entries included
+const maxArchiveEntries = 10000
+
+// ExtractLimits bound what an extraction may write. Sizes are counted as the
+// data is written, not taken from the archive headers, which can lie. Zero
+// means unlimited.
+type ExtractLimits struct {
+	MaxBytes    int64 // All files together
+	MaxFileSize int64 // Each file
+}
+
+// extraction tracks one ExtractArchive call against its limits
+type extraction struct {
+	client  *sftp.Client
+	destDir string
+	limits  ExtractLimits
+	written int64
+	entries int
+	count   int // Files written
+}
+
+// ExtractArchive unpacks a remote zip, tar or tar.gz archive into destDir and
+// returns the number of files written. Entries resolving outside destDir, more
+// than maxArchiveEntries entries or data past limits abort the extraction;
+// the file being written when a limit is hit is removed.
+func (c *SFTPClient) ExtractArchive(remotePath, destDir string, limits ExtractLimits) (int, error) {
+	defer c.invalidateDirSize(destDir)
+
+	client, err := c.conn()
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := client.Open(remotePath)
+	if err != nil {
+		return 0, classify(fmt.Errorf("failed to open archive: %w", err))
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, classify(fmt.Errorf("failed to stat archive: %w", err))
+	}
+
+	if err := client.MkdirAll(destDir); err != nil {
+		return 0, classify(fmt.Errorf("failed to create directory: %w", err))
+	}
+
+	x := &extraction{client: client, destDir: destDir, limits: limits}
+	switch detectArchiveFormat(file, remotePath) {
+	case formatZip:
+		err = x.zip(file, info.Size())
+	case formatTarGz:
+		gz, gzErr := gzip.NewReader(file)
+		if gzErr != nil {
+			return 0, classify(fmt.Errorf("failed to read gzip stream: %w", gzErr))
+		}
+		defer gz.Close()
+		err = x.tar(gz)
+	case formatTar:
+		err = x.tar(file)
+	default:
+		return 0, ErrUnsupportedArchive
+	}
+	return x.count, err
+}
+
+// detectArchiveFormat checks magic bytes first and falls back to the extension
+func detectArchiveFormat(file *sftp.File, name string) archiveFormat {
+	header := make([]byte, 262)
+	n, _ := file.ReadAt(header, 0)
+	header = header[:n]
+
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		return formatZip
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return formatTarGz
+	case len(header) >= 262 && string(header[257:262]) == "ustar":
+		return formatTar
+	}
+
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return formatZip
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return formatTarGz
+	case strings.HasSuffix(lower, ".tar"):
+		return formatTar
+	}
+	return formatUnknown
+}
+
+// entryPath joins an archive entry name to destDir, rejecting zip-slip paths
+func entryPath(destDir, name string) (string, error) {
+	target := path.Join(destDir, name)
+	if !IsWithin(destDir, target) {
+		return "", fmt.Errorf("illegal path in archive: %s", name)
+	}
+	return target, nil
+}
+
+func (x *extraction) zip(file *sftp.File, size int64) error {
+	reader, err := zip.NewReader(file, size)
+	if err != nil {
+		return classify(fmt.Errorf("failed to read zip archive: %w", err))
+	}
+	if len(reader.File) > maxArchiveEntries {
+		return ErrArchiveTooManyFiles
+	}
+
+	for _, entry := range reader.File {
+		target, err := x.next(entry.Name)
+		if err != nil {
+			return err
+		}
+
+		if entry.FileInfo().IsDir() {
+			if err := x.client.MkdirAll(target); err != nil {
+				return classify(fmt.Errorf("failed to create directory: %w", err))
+			}
+			continue
+		}
+
+		src, err := entry.Open()
+		if err != nil {
+			return classify(fmt.Errorf("failed to read %s: %w", entry.Name, err))
+		}
+		err = x.write(target, src, entry.Mode())
+		src.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *extraction) tar(r io.Reader) error {
+	reader := tar.NewReader(r)
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return classify(fmt.Errorf("failed to read tar archive: %w", err))
+		}
+
+		target, err := x.next(header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := x.client.MkdirAll(target); err != nil {
+				return classify(fmt.Errorf("failed to create directory: %w", err))
+			}
+		case tar.TypeReg:
+			if err := x.write(target, reader, os.FileMode(header.Mode).Perm()); err != nil {
+				return err
+			}
+		default:
+			// Links and special files are skipped so they cannot point outside destDir
+		}
+	}
+}
+
+// next counts an entry against maxArchiveEntries and returns its target path
+func (x *extraction) next(name string) (string, error) {
+	x.entries++
+	if x.entries > maxArchiveEntries {
+		return "", ErrArchiveTooManyFiles
+	}
+	return entryPath(x.destDir, name)
+}
+
+// allowance returns the bytes the next file may hold, -1 when unlimited
+func (x *extraction) allowance() int64 {
+	allowed := int64(-1)
+	if x.limits.MaxFileSize > 0 {
+		allowed = x.limits.MaxFileSize
+	}
+	if x.limits.MaxBytes > 0 {
+		if left := x.limits.MaxBytes - x.written; allowed < 0 || left < allowed {
+			allowed = left
+		}
+	}
+	return allowed
+}
+
+// write writes a single extracted file and applies its mode. A file that
+// would break a limit is removed and ErrArchiveTooLarge returned.
+func (x *extraction) write(target string, src io.Reader, mode os.FileMode) error {
+	if err := x.client.MkdirAll(path.Dir(target)); err != nil {
+		return classify(fmt.Errorf("failed to create directory: %w", err))
+	}
+
+	dst, err := x.client.Create(target)
+	if err != nil {
+		return classify(fmt.Errorf("failed to create %s: %w", target, err))
+	}
+
+	allowed := x.allowance()
+	if allowed >= 0 {
+		// One byte past the allowance tells a file at the limit from a larger one
+		src = io.LimitReader(src, allowed+1)
+	}
+	n, err := io.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return classify(fmt.Errorf("failed to write %s: %w", target, err))
+	}
+	if allowed >= 0 && n > allowed {
+		x.client.Remove(target)
+		return ErrArchiveTooLarge
+	}
+	x.written += n
+	x.count++
+
+	if perm := mode.Perm(); perm != 0 {
+		x.client.Chmod(target, perm)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package sftp
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"monitoring/internal/models"
+	sshclient "monitoring/internal/ssh"
+)
+
+// checksumTools maps supported algorithms to the remote tool and local hash
+var checksumTools = map[string]struct {
+	command string
+	newHash func() hash.Hash
+}{
+	"sha256": {"sha256sum", sha256.New},
+	"md5":    {"md5sum", md5.New},
+}
+
+// IsChecksumAlgorithm reports whether algo is supported by Checksum
+func IsChecksumAlgorithm(algo string) bool {
+	_, ok := checksumTools[algo]
+	return ok
+}
+
+// Checksum hashes a remote file, preferring the remote sha256sum/md5sum tools
+// and falling back to streaming the file over SFTP
+func (c *SFTPClient) Checksum(path, algo string) (*models.ChecksumResult, error) {
+	tool, ok := checksumTools[algo]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm: %s", algo)
+	}
+
+	info, err := c.Stat(path)
+	if err != nil {
+		return nil, classify(fmt.Errorf("failed to stat file: %w", err))
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("cannot checksum a directory")
+	}
+
+	result := &models.ChecksumResult{
+		Path:      path,
+		Algorithm: algo,
+		Size:      info.Size(),
+	}
+
+	output, err := c.sshClient.Execute(tool.command + " " + sshclient.ShellQuote(path))
+	if err == nil {
+		if fields := strings.Fields(output); len(fields) > 0 {
+			result.Hash = fields[0]
+			return result, nil
+		}
+	}
+
+	result.Hash, err = c.hashOverSFTP(path, tool.newHash())
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *SFTPClient) hashOverSFTP(path string, h hash.Hash) (string, error) {
+	client, err := c.conn()
+	if err != nil {
+		return "", err
+	}
+
+	file, err := client.Open(path)
+	if err != nil {
+		return "", classify(fmt.Errorf("failed to open file: %w", err))
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", classify(fmt.Errorf("failed to read file: %w", err))
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,78 @@
+package sftp
+
+import (
+	"os"
+
+	"monitoring/internal/models"
+)
+
+// ChmodModes are the permissions applied by ChmodRecursive. A nil mode
+// leaves that kind of entry unchanged.
+type ChmodModes struct {
+	File *os.FileMode
+	Dir  *os.FileMode
+}
+
+func (m ChmodModes) forEntry(info os.FileInfo) *os.FileMode {
+	if info.IsDir() {
+		return m.Dir
+	}
+	return m.File
+}
+
+// ChmodRecursive applies modes to path and, when recursive is set, to
+// everything below it. Entries that fail are reported and the walk goes on.
+// Symlinks are skipped, since SETSTAT would follow them out of the tree.
+func (c *SFTPClient) ChmodRecursive(path string, modes ChmodModes, recursive bool) (*models.ChmodResult, error) {
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.ChmodResult{Failed: []models.FailedPath{}}
+	fail := func(entry string, err error) {
+		result.FailedCount++
+		if len(result.Failed) < models.MaxReportedFailures {
+			result.Failed = append(result.Failed, models.FailedPath{Path: entry, Error: err.Error()})
+		}
+	}
+
+	if !recursive {
+		info, err := client.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if mode := modes.forEntry(info); mode != nil {
+			if err := client.Chmod(path, *mode); err != nil {
+				return nil, err
+			}
+			result.Changed++
+		}
+		return result, nil
+	}
+
+	walker := client.Walk(path)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			fail(walker.Path(), err)
+			continue
+		}
+
+		info := walker.Stat()
+		if info.Mode()&os.ModeSymlink != 0 || !IsWithin(path, walker.Path()) {
+			continue
+		}
+
+		mode := modes.forEntry(info)
+		if mode == nil {
+			continue
+		}
+		if err := client.Chmod(walker.Path(), *mode); err != nil {
+			fail(walker.Path(), err)
+			continue
+		}
+		result.Changed++
+	}
+
+	return result, nil
+}
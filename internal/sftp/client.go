@@ -1,12 +1,14 @@
 package sftp
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/sftp"
 
@@ -19,9 +21,13 @@ import (
 type SFTPClient struct {
 	sshClient  *sshclient.SSHClient
 	sftpClient *sftp.Client
-	mu         sync.Mutex
+	rootPath   string       // Paths are jailed below this directory
+	realRoot   string       // rootPath with symlinks resolved, set on first use
+	mu         sync.RWMutex // Guards sftpClient, rootPath and realRoot only
 }
 
+var errClientClosed = errors.New("sftp client closed")
+
 // SFTPPool manages a pool of SFTP connections
 type SFTPPool struct {
 	clients map[uint]*SFTPClient
@@ -38,13 +44,37 @@ func InitPool() {
 
 // GetClient returns an existing SFTP client or creates a new one
 func (p *SFTPPool) GetClient(server *models.Server, password string) (*SFTPClient, error) {
+	p.mu.RLock()
+	client, exists := p.clients[server.ID]
+	p.mu.RUnlock()
+
+	// The liveness check runs outside the pool lock so a hung server only
+	// delays its own requests
+	if exists {
+		if client.alive() {
+			client.setRootPath(server.RootPath)
+			return client, nil
+		}
+
+		utils.AppLogger.Warning("SFTP client for server %d is no longer responding, reconnecting", server.ID)
+		// Lets the SSH pool notice a dead connection and dial a new one
+		client.sshClient.TestConnection()
+		client.Close()
+
+		p.mu.Lock()
+		if p.clients[server.ID] == client {
+			delete(p.clients, server.ID)
+		}
+		p.mu.Unlock()
+	}
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
+	// Another request may have reconnected in the meantime
 	if client, exists := p.clients[server.ID]; exists {
-		if client.sftpClient != nil {
-			return client, nil
-		}
+		client.setRootPath(server.RootPath)
+		return client, nil
 	}
 
 	// Get SSH client from pool
@@ -53,15 +83,21 @@ func (p *SFTPPool) GetClient(server *models.Server, password string) (*SFTPClien
 		return nil, fmt.Errorf("failed to get SSH client: %w", err)
 	}
 
-	// Create SFTP client
-	sftpClient, err := sftp.NewClient(sshClient.GetUnderlyingClient())
+	// The SSH client stays out of eviction while this SFTP client lives
+	conn, err := sshClient.Acquire()
 	if err != nil {
+		return nil, fmt.Errorf("failed to get SSH client: %w", err)
+	}
+	sftpClient, err := sftp.NewClient(conn)
+	if err != nil {
+		sshClient.Release()
 		return nil, fmt.Errorf("failed to create SFTP client: %w", err)
 	}
 
-	client := &SFTPClient{
+	client = &SFTPClient{
 		sshClient:  sshClient,
 		sftpClient: sftpClient,
+		rootPath:   server.RootPath,
 	}
 
 	p.clients[server.ID] = client
@@ -92,6 +128,75 @@ func (p *SFTPPool) CloseAll() {
 	}
 }
 
+func (c *SFTPClient) setRootPath(root string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rootPath != root {
+		c.rootPath = root
+		c.realRoot = ""
+	}
+}
+
+// ResolvePath sanitizes a user supplied path against the server root. When
+// a root is set, symlinks along the path are followed too, so a link inside
+// the root cannot lead outside it.
+func (c *SFTPClient) ResolvePath(p string) (string, error) {
+	c.mu.RLock()
+	root := c.rootPath
+	c.mu.RUnlock()
+
+	resolved, err := SanitizePath(root, p)
+	if err != nil {
+		return "", err
+	}
+	if root = path.Clean("/" + root); root == "/" {
+		return resolved, nil
+	}
+
+	if err := c.checkLinks(root, resolved); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// conn returns the underlying client. pkg/sftp clients are safe for concurrent
+// use, so operations share it without serializing on c.mu.
+func (c *SFTPClient) conn() (*sftp.Client, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.sftpClient == nil {
+		return nil, errClientClosed
+	}
+	c.sshClient.Touch()
+	return c.sftpClient, nil
+}
+
+// aliveTimeout bounds the liveness round trip of a pooled client
+const aliveTimeout = 5 * time.Second
+
+// alive reports whether the SFTP session still answers requests within
+// aliveTimeout
+func (c *SFTPClient) alive() bool {
+	client, err := c.conn()
+	if err != nil {
+		return false
+	}
+
+	answered := make(chan error, 1)
+	go func() {
+		_, err := client.Getwd()
+		answered <- err
+	}()
+
+	select {
+	case err := <-answered:
+		return err == nil
+	case <-time.After(aliveTimeout):
+		return false
+	}
+}
+
 // Close closes the SFTP connection
 func (c *SFTPClient) Close() error {
 	c.mu.Lock()
@@ -100,65 +205,129 @@ func (c *SFTPClient) Close() error {
 	if c.sftpClient != nil {
 		err := c.sftpClient.Close()
 		c.sftpClient = nil
+		c.sshClient.Release()
 		return err
 	}
 	return nil
 }
 
 func (c *SFTPClient) ListDirectory(path string) ([]models.FileInfo, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
 
-	entries, err := c.sftpClient.ReadDir(path)
+	entries, err := client.ReadDir(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read directory: %w", err)
+		return nil, classify(fmt.Errorf("failed to read directory: %w", err))
 	}
 
 	var files []models.FileInfo
 	for _, entry := range entries {
-		fileInfo := models.FileInfo{
-			Name:        entry.Name(),
-			Path:        filepath.Join(path, entry.Name()),
-			Size:        entry.Size(),
-			IsDir:       entry.IsDir(),
-			Permissions: entry.Mode(),
-			ModTime:     entry.ModTime(),
-		}
+		files = append(files, newFileInfo(client, filepath.Join(path, entry.Name()), entry))
+	}
 
-		if stat, ok := entry.Sys().(*sftp.FileStat); ok {
-			fileInfo.Owner = fmt.Sprintf("%d", stat.UID)
-			fileInfo.Group = fmt.Sprintf("%d", stat.GID)
-		}
+	return files, nil
+}
 
-		files = append(files, fileInfo)
+// StatPath returns the listing entry of a single path without reading its
+// directory. Symlinks are described, not followed.
+func (c *SFTPClient) StatPath(path string) (*models.FileInfo, error) {
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
 	}
 
-	return files, nil
+	entry, err := client.Lstat(path)
+	if err != nil {
+		return nil, classify(err)
+	}
+
+	fileInfo := newFileInfo(client, path, entry)
+	if fileInfo.Owner != "" {
+		fileInfo.OwnerName, fileInfo.GroupName = c.OwnerNames(fileInfo.Owner, fileInfo.Group)
+	}
+	return &fileInfo, nil
+}
+
+// newFileInfo builds a listing entry from an Lstat result
+func newFileInfo(client *sftp.Client, path string, entry os.FileInfo) models.FileInfo {
+	fileInfo := models.FileInfo{
+		Name:        entry.Name(),
+		Path:        path,
+		Size:        entry.Size(),
+		IsDir:       entry.IsDir(),
+		Permissions: entry.Mode(),
+		ModTime:     entry.ModTime(),
+	}
+
+	if stat, ok := entry.Sys().(*sftp.FileStat); ok {
+		fileInfo.Owner = fmt.Sprintf("%d", stat.UID)
+		fileInfo.Group = fmt.Sprintf("%d", stat.GID)
+	}
+
+	if entry.Mode()&os.ModeSymlink != 0 {
+		resolveSymlink(client, &fileInfo)
+	}
+
+	return fileInfo
+}
+
+// resolveSymlink fills the link fields of a symlink entry
+func resolveSymlink(client *sftp.Client, fileInfo *models.FileInfo) {
+	fileInfo.IsSymlink = true
+
+	target, err := client.ReadLink(fileInfo.Path)
+	if err != nil {
+		fileInfo.LinkBroken = true
+		return
+	}
+	fileInfo.LinkTarget = target
+
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(fileInfo.Path), target)
+	}
+
+	// Stat follows the link, failing when the target does not exist
+	info, err := client.Stat(target)
+	if err != nil {
+		fileInfo.LinkBroken = true
+		return
+	}
+	fileInfo.TargetIsDir = info.IsDir()
 }
 
 // CreateDirectory creates a new directory
 func (c *SFTPClient) CreateDirectory(path string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.invalidateDirSize(path)
 
-	return c.sftpClient.MkdirAll(path)
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
+
+	return classify(client.MkdirAll(path))
 }
 
 // RemoveDirectory removes a directory (recursively if needed)
 func (c *SFTPClient) RemoveDirectory(path string, recursive bool) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.invalidateDirSize(path)
+
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
 
 	if !recursive {
-		return c.sftpClient.RemoveDirectory(path)
+		return classify(client.RemoveDirectory(path))
 	}
 
-	return c.removeRecursive(path)
+	return classify(removeRecursive(client, path))
 }
 
 // removeRecursive removes a directory and all its contents
-func (c *SFTPClient) removeRecursive(path string) error {
-	entries, err := c.sftpClient.ReadDir(path)
+func removeRecursive(client *sftp.Client, path string) error {
+	entries, err := client.ReadDir(path)
 	if err != nil {
 		return err
 	}
@@ -166,39 +335,49 @@ func (c *SFTPClient) removeRecursive(path string) error {
 	for _, entry := range entries {
 		fullPath := filepath.Join(path, entry.Name())
 		if entry.IsDir() {
-			if err := c.removeRecursive(fullPath); err != nil {
+			if err := removeRecursive(client, fullPath); err != nil {
 				return err
 			}
 		} else {
-			if err := c.sftpClient.Remove(fullPath); err != nil {
+			if err := client.Remove(fullPath); err != nil {
 				return err
 			}
 		}
 	}
 
-	return c.sftpClient.RemoveDirectory(path)
+	return classify(client.RemoveDirectory(path))
 }
 
 // UploadFile uploads a file to the remote server
 func (c *SFTPClient) UploadFile(remotePath string, reader io.Reader, size int64) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.invalidateDirSize(remotePath)
+
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
+
+	return uploadFile(client, remotePath, reader)
+}
+
+func uploadFile(client *sftp.Client, remotePath string, reader io.Reader) error {
+	defer trackTransfer()()
 
 	// Ensure parent directory exists
 	dir := filepath.Dir(remotePath)
-	if err := c.sftpClient.MkdirAll(dir); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	if err := client.MkdirAll(dir); err != nil {
+		return classify(fmt.Errorf("failed to create directory: %w", err))
 	}
 
-	file, err := c.sftpClient.Create(remotePath)
+	file, err := client.Create(remotePath)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return classify(fmt.Errorf("failed to create file: %w", err))
 	}
 	defer file.Close()
 
 	_, err = io.Copy(file, reader)
 	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		return classify(fmt.Errorf("failed to write file: %w", err))
 	}
 
 	return nil
@@ -206,18 +385,48 @@ func (c *SFTPClient) UploadFile(remotePath string, reader io.Reader, size int64)
 
 // DownloadFile downloads a file from the remote server
 func (c *SFTPClient) DownloadFile(remotePath string, writer io.Writer) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer trackTransfer()()
+
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
 
-	file, err := c.sftpClient.Open(remotePath)
+	file, err := client.Open(remotePath)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return classify(fmt.Errorf("failed to open file: %w", err))
 	}
 	defer file.Close()
 
 	_, err = io.Copy(writer, file)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return classify(fmt.Errorf("failed to read file: %w", err))
+	}
+
+	return nil
+}
+
+// DownloadRange copies length bytes starting at offset from a remote file
+func (c *SFTPClient) DownloadRange(remotePath string, writer io.Writer, offset, length int64) error {
+	defer trackTransfer()()
+
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
+
+	file, err := client.Open(remotePath)
+	if err != nil {
+		return classify(fmt.Errorf("failed to open file: %w", err))
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return classify(fmt.Errorf("failed to seek file: %w", err))
+	}
+
+	if _, err := io.CopyN(writer, file, length); err != nil {
+		return classify(fmt.Errorf("failed to read file: %w", err))
 	}
 
 	return nil
@@ -225,34 +434,69 @@ func (c *SFTPClient) DownloadFile(remotePath string, writer io.Writer) error {
 
 // DeleteFile deletes a file
 func (c *SFTPClient) DeleteFile(path string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.invalidateDirSize(path)
+
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
+
+	return classify(client.Remove(path))
+}
+
+// Remove deletes path with DeleteFile or RemoveDirectory depending on what
+// it is. Directories with content need recursive.
+func (c *SFTPClient) Remove(path string, recursive bool) error {
+	info, err := c.Stat(path)
+	if err != nil {
+		return err
+	}
 
-	return c.sftpClient.Remove(path)
+	if info.IsDir() {
+		return c.RemoveDirectory(path, recursive)
+	}
+	return c.DeleteFile(path)
 }
 
 // Rename renames or moves a file/directory
 func (c *SFTPClient) Rename(oldPath, newPath string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.invalidateDirSize(oldPath)
+	defer c.invalidateDirSize(newPath)
 
-	return c.sftpClient.Rename(oldPath, newPath)
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
+
+	return classify(client.Rename(oldPath, newPath))
 }
 
-// ReadFileContent reads the content of a text file
-func (c *SFTPClient) ReadFileContent(path string) (string, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// ReadFileContent reads the content of a text file. Reading stops after
+// limit bytes (0 is unlimited) and a longer file fails with ErrTooLarge.
+func (c *SFTPClient) ReadFileContent(path string, limit int64) (string, error) {
+	client, err := c.conn()
+	if err != nil {
+		return "", err
+	}
 
-	file, err := c.sftpClient.Open(path)
+	file, err := client.Open(path)
 	if err != nil {
-		return "", fmt.Errorf("failed to open file: %w", err)
+		return "", classify(fmt.Errorf("failed to open file: %w", err))
 	}
 	defer file.Close()
 
-	content, err := io.ReadAll(file)
+	var reader io.Reader = file
+	if limit > 0 {
+		// One byte past the limit tells a file of exactly limit bytes apart
+		// from a longer one
+		reader = io.LimitReader(file, limit+1)
+	}
+	content, err := io.ReadAll(reader)
 	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
+		return "", classify(fmt.Errorf("failed to read file: %w", err))
+	}
+	if limit > 0 && int64(len(content)) > limit {
+		return "", ErrTooLarge
 	}
 
 	return string(content), nil
@@ -260,18 +504,22 @@ func (c *SFTPClient) ReadFileContent(path string) (string, error) {
 
 // WriteFileContent writes content to a text file
 func (c *SFTPClient) WriteFileContent(path, content string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.invalidateDirSize(path)
+
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
 
-	file, err := c.sftpClient.Create(path)
+	file, err := client.Create(path)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return classify(fmt.Errorf("failed to create file: %w", err))
 	}
 	defer file.Close()
 
 	_, err = file.Write([]byte(content))
 	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+		return classify(fmt.Errorf("failed to write file: %w", err))
 	}
 
 	return nil
@@ -279,73 +527,82 @@ func (c *SFTPClient) WriteFileContent(path, content string) error {
 
 // Chmod changes file permissions
 func (c *SFTPClient) Chmod(path string, mode os.FileMode) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
 
-	return c.sftpClient.Chmod(path, mode)
+	return classify(client.Chmod(path, mode))
 }
 
-// Stat returns file information
-func (c *SFTPClient) Stat(path string) (os.FileInfo, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// Chtimes changes the access and modification times of a file
+func (c *SFTPClient) Chtimes(path string, atime, mtime time.Time) error {
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
 
-	return c.sftpClient.Stat(path)
+	return classify(client.Chtimes(path, atime, mtime))
 }
 
-// SearchFiles searches for files matching a pattern
-func (c *SFTPClient) SearchFiles(basePath, pattern string) ([]models.FileInfo, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	var results []models.FileInfo
-
-	walker := c.sftpClient.Walk(basePath)
-	for walker.Step() {
-		if err := walker.Err(); err != nil {
-			continue
-		}
-
-		info := walker.Stat()
-		name := info.Name()
+// Touch sets the times of a file, creating it empty first when create is set
+// and it does not exist. It reports whether the file was created.
+func (c *SFTPClient) Touch(path string, atime, mtime time.Time, create bool) (bool, error) {
+	client, err := c.conn()
+	if err != nil {
+		return false, err
+	}
 
-		matched, err := filepath.Match(pattern, name)
-		if err != nil {
-			continue
+	created := false
+	if create {
+		if _, err := client.Stat(path); errors.Is(err, os.ErrNotExist) {
+			file, err := client.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+			if err != nil {
+				return false, classify(fmt.Errorf("failed to create file: %w", err))
+			}
+			file.Close()
+			created = true
+			c.invalidateDirSize(path)
 		}
+	}
 
-		if matched || strings.Contains(strings.ToLower(name), strings.ToLower(pattern)) {
-			fileInfo := models.FileInfo{
-				Name:        name,
-				Path:        walker.Path(),
-				Size:        info.Size(),
-				IsDir:       info.IsDir(),
-				Permissions: info.Mode(),
-				ModTime:     info.ModTime(),
-			}
-			results = append(results, fileInfo)
+	return created, classify(client.Chtimes(path, atime, mtime))
+}
 
-			// Limit results to prevent overwhelming responses
-			if len(results) >= 100 {
-				break
-			}
-		}
+// Stat returns file information
+func (c *SFTPClient) Stat(path string) (os.FileInfo, error) {
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
 	}
 
-	return results, nil
+	info, err := client.Stat(path)
+	return info, classify(err)
 }
 
 // GetDirectorySize calculates the total size of a directory
 func (c *SFTPClient) GetDirectorySize(path string) (*models.DirectorySizeResult, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	result, _ := directorySize(client, path, time.Time{})
+	return result, nil
+}
 
-	result := &models.DirectorySizeResult{
+// directorySize walks path, giving up once deadline passes unless it is
+// zero. complete is false when the walk was cut short.
+func directorySize(client *sftp.Client, path string, deadline time.Time) (result *models.DirectorySizeResult, complete bool) {
+	result = &models.DirectorySizeResult{
 		Path: path,
 	}
 
-	walker := c.sftpClient.Walk(path)
+	walker := client.Walk(path)
 	for walker.Step() {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return result, false
+		}
 		if err := walker.Err(); err != nil {
 			continue
 		}
@@ -359,41 +616,45 @@ func (c *SFTPClient) GetDirectorySize(path string) (*models.DirectorySizeResult,
 		}
 	}
 
-	return result, nil
+	return result, true
 }
 
 // CopyFile copies a file within the server
 func (c *SFTPClient) CopyFile(srcPath, dstPath string) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.invalidateDirSize(dstPath)
+
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
 
-	src, err := c.sftpClient.Open(srcPath)
+	src, err := client.Open(srcPath)
 	if err != nil {
-		return fmt.Errorf("failed to open source file: %w", err)
+		return classify(fmt.Errorf("failed to open source file: %w", err))
 	}
 	defer src.Close()
 
 	// Ensure parent directory exists
 	dir := filepath.Dir(dstPath)
-	if err := c.sftpClient.MkdirAll(dir); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	if err := client.MkdirAll(dir); err != nil {
+		return classify(fmt.Errorf("failed to create directory: %w", err))
 	}
 
-	dst, err := c.sftpClient.Create(dstPath)
+	dst, err := client.Create(dstPath)
 	if err != nil {
-		return fmt.Errorf("failed to create destination file: %w", err)
+		return classify(fmt.Errorf("failed to create destination file: %w", err))
 	}
 	defer dst.Close()
 
 	_, err = io.Copy(dst, src)
 	if err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
+		return classify(fmt.Errorf("failed to copy file: %w", err))
 	}
 
 	// Copy permissions
-	srcInfo, err := c.sftpClient.Stat(srcPath)
+	srcInfo, err := client.Stat(srcPath)
 	if err == nil {
-		c.sftpClient.Chmod(dstPath, srcInfo.Mode())
+		client.Chmod(dstPath, srcInfo.Mode())
 	}
 
 	return nil
@@ -401,9 +662,11 @@ func (c *SFTPClient) CopyFile(srcPath, dstPath string) error {
 
 // Exists checks if a file or directory exists
 func (c *SFTPClient) Exists(path string) bool {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	client, err := c.conn()
+	if err != nil {
+		return false
+	}
 
-	_, err := c.sftpClient.Stat(path)
+	_, err = client.Stat(path)
 	return err == nil
 }
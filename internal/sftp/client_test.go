@@ -0,0 +1,177 @@
+package sftp
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// stalledWriter blocks every write until release is closed, holding a
+// download open for as long as the test needs
+type stalledWriter struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newStalledWriter() *stalledWriter {
+	return &stalledWriter{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	select {
+	case <-w.started:
+	default:
+		close(w.started)
+	}
+	<-w.release
+	return len(p), nil
+}
+
+// startStalledDownload begins downloading a file into a stalledWriter and
+// returns once the first bytes are in flight. Closing release finishes it.
+func startStalledDownload(tb testing.TB, client *SFTPClient, dir string) (release func()) {
+	tb.Helper()
+
+	path := filepath.Join(dir, "large.bin")
+	if err := os.WriteFile(path, make([]byte, 4<<20), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+
+	writer := newStalledWriter()
+	done := make(chan error, 1)
+	go func() {
+		done <- client.DownloadFile(path, writer)
+	}()
+
+	select {
+	case <-writer.started:
+	case err := <-done:
+		tb.Fatalf("download ended early: %v", err)
+	case <-time.After(5 * time.Second):
+		tb.Fatal("download did not start")
+	}
+
+	return func() {
+		close(writer.release)
+		if err := <-done; err != nil {
+			tb.Errorf("download failed: %v", err)
+		}
+	}
+}
+
+func TestListDirectoryDuringDownload(t *testing.T) {
+	dir := t.TempDir()
+	client := newTestClient(t, "")
+	release := startStalledDownload(t, client, dir)
+	defer release()
+
+	listed := make(chan error, 1)
+	go func() {
+		_, err := client.ListDirectory(dir)
+		listed <- err
+	}()
+
+	select {
+	case err := <-listed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ListDirectory blocked behind a running download")
+	}
+}
+
+// BenchmarkListDirectoryDuringDownload lists a directory while a download on
+// the same client is stalled mid-transfer. With operations serialized on one
+// lock the first listing would never finish.
+func BenchmarkListDirectoryDuringDownload(b *testing.B) {
+	dir := b.TempDir()
+	client := newTestClient(b, "")
+	release := startStalledDownload(b, client, dir)
+	defer release()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.ListDirectory(dir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDownloadRangeBoundaries(t *testing.T) {
+	dir := t.TempDir()
+	content := make([]byte, 1000)
+	for i := range content {
+		content[i] = byte(i % 251)
+	}
+	path := filepath.Join(dir, "data.bin")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := newTestClient(t, "")
+	tests := []struct {
+		offset, length int64
+	}{
+		{0, 1},
+		{0, 1000},
+		{999, 1},
+		{500, 500},
+		{1, 998},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := client.DownloadRange(path, &buf, tt.offset, tt.length); err != nil {
+			t.Errorf("DownloadRange(%d, %d) error = %v", tt.offset, tt.length, err)
+			continue
+		}
+		if want := content[tt.offset : tt.offset+tt.length]; !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("DownloadRange(%d, %d) returned %d bytes that differ from the file", tt.offset, tt.length, buf.Len())
+		}
+	}
+
+	// Past the end the copy comes up short, which is reported
+	var buf bytes.Buffer
+	if err := client.DownloadRange(path, &buf, 990, 20); err == nil {
+		t.Error("DownloadRange past the end of the file succeeded")
+	}
+}
+
+func TestReadFileContentLimit(t *testing.T) {
+	dir := t.TempDir()
+	client := newTestClient(t, "")
+
+	const limit = 1024
+	tests := []struct {
+		size  int
+		limit int64
+		err   error
+	}{
+		{0, limit, nil},
+		{limit - 1, limit, nil},
+		{limit, limit, nil},
+		{limit + 1, limit, ErrTooLarge},
+		{4 * limit, limit, ErrTooLarge},
+		{4 * limit, 0, nil},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, "file.txt")
+		if err := os.WriteFile(path, bytes.Repeat([]byte("a"), tt.size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		content, err := client.ReadFileContent(path, tt.limit)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%d bytes with limit %d: error = %v, want %v", tt.size, tt.limit, err, tt.err)
+			continue
+		}
+		if tt.err == nil && len(content) != tt.size {
+			t.Errorf("%d bytes with limit %d: read %d bytes", tt.size, tt.limit, len(content))
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package sftp
+
+import (
+	"context"
+	"io"
+
+	sshclient "monitoring/internal/ssh"
+)
+
+// CanCompress reports whether the server has gzip for DownloadCompressed
+func (c *SFTPClient) CanCompress() bool {
+	_, err := c.sshClient.Execute("command -v gzip")
+	return err == nil
+}
+
+// DownloadCompressed streams remotePath gzipped on the server, so only the
+// compressed bytes cross the link. It returns the number of bytes written.
+func (c *SFTPClient) DownloadCompressed(ctx context.Context, remotePath string, writer io.Writer) (int64, error) {
+	defer trackTransfer()()
+
+	return c.sshClient.ExecuteTo(ctx, "gzip -c -- "+sshclient.ShellQuote(remotePath), writer)
+}
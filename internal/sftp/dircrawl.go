@@ -0,0 +1,166 @@
+package sftp
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"monitoring/internal/models"
+	"monitoring/internal/utils"
+)
+
+const (
+	dirCrawlWorkers = 4                    // Subdirectories walked at once by one crawl
+	dirCrawlTimeout = 2 * time.Minute      // Longest a crawl may run
+	dirCrawlMaxDirs = dirSizeCacheSize / 2 // Subdirectories sized per listing, so they fit in the cache
+)
+
+// dirCrawlState tracks the directories being sized in the background and
+// the ones that could not be sized within dirCrawlTimeout
+type dirCrawlState struct {
+	running  map[dirSizeKey]bool
+	timedOut map[dirSizeKey]time.Time
+	mu       sync.Mutex
+}
+
+var dirCrawls = &dirCrawlState{
+	running:  make(map[dirSizeKey]bool),
+	timedOut: make(map[dirSizeKey]time.Time),
+}
+
+// start claims key for a crawl. It fails while another crawl holds the key
+// and, reporting timedOut, for dirSizeCacheTTL after the key timed out.
+func (d *dirCrawlState) start(key dirSizeKey) (started, timedOut bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if at, ok := d.timedOut[key]; ok {
+		if time.Since(at) < dirSizeCacheTTL {
+			return false, true
+		}
+		delete(d.timedOut, key)
+	}
+	if d.running[key] {
+		return false, false
+	}
+	d.running[key] = true
+	return true, false
+}
+
+// finish releases key, remembering it if the walk ran out of time
+func (d *dirCrawlState) finish(key dirSizeKey, timedOut bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.running, key)
+	if timedOut {
+		d.timedOut[key] = time.Now()
+	}
+}
+
+// ChildDirectorySizes returns the sizes of the subdirectories of parent
+// without waiting for them. Sizes are taken from the directory size cache;
+// missing and stale ones are computed by a background crawl and reported
+// as computing until a later call finds them cached.
+func (c *SFTPClient) ChildDirectorySizes(parent string) (*models.ChildDirectorySizesResult, error) {
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := client.ReadDir(parent)
+	if err != nil {
+		return nil, classify(fmt.Errorf("failed to read directory: %w", err))
+	}
+
+	result := &models.ChildDirectorySizesResult{
+		Path:        parent,
+		Directories: []models.ChildDirectorySize{},
+	}
+
+	var pending []dirSizeKey
+	for _, entry := range entries {
+		// Symlinked directories are skipped like the walk itself does
+		if !entry.IsDir() {
+			continue
+		}
+		if len(result.Directories) == dirCrawlMaxDirs {
+			result.Truncated = true
+			break
+		}
+
+		p := filepath.Join(parent, entry.Name())
+		key := c.dirSizeKey(p)
+		child := models.ChildDirectorySize{Name: entry.Name()}
+		child.Path = p
+
+		cached, ok := dirSizes.get(key)
+		if ok {
+			child.DirectorySizeResult = cached.result
+			child.Cached = true
+			child.AgeSeconds = time.Since(cached.computedAt).Seconds()
+		}
+
+		if !ok || time.Since(cached.computedAt) > dirSizeCacheTTL {
+			started, timedOut := dirCrawls.start(key)
+			if started {
+				pending = append(pending, key)
+			}
+			child.TimedOut = timedOut && !ok
+			child.Computing = !ok && !timedOut
+		}
+
+		result.Computing = result.Computing || child.Computing
+		result.Directories = append(result.Directories, child)
+	}
+
+	if len(pending) > 0 {
+		go c.crawlDirSizes(pending)
+	}
+	return result, nil
+}
+
+// crawlDirSizes sizes directories dirCrawlWorkers at a time and caches the
+// results. Walks still running at the deadline are abandoned.
+func (c *SFTPClient) crawlDirSizes(keys []dirSizeKey) {
+	deadline := time.Now().Add(dirCrawlTimeout)
+	workers := make(chan struct{}, dirCrawlWorkers)
+	var wg sync.WaitGroup
+
+	for _, key := range keys {
+		workers <- struct{}{}
+		wg.Add(1)
+		go func(key dirSizeKey) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			c.crawlDirSize(key, deadline)
+		}(key)
+	}
+	wg.Wait()
+}
+
+func (c *SFTPClient) crawlDirSize(key dirSizeKey, deadline time.Time) {
+	// Directories never started are left for the next request to retry
+	if time.Now().After(deadline) {
+		dirCrawls.finish(key, false)
+		return
+	}
+
+	client, err := c.conn()
+	if err != nil {
+		dirCrawls.finish(key, false)
+		return
+	}
+
+	result, complete := directorySize(client, key.path, deadline)
+	if !complete {
+		utils.AppLogger.Warning("Gave up sizing %s on server %d after %v", key.path, key.serverID, dirCrawlTimeout)
+		dirCrawls.finish(key, true)
+		return
+	}
+	dirSizes.put(key, *result)
+	dirCrawls.finish(key, false)
+}
@@ -0,0 +1,153 @@
+package sftp
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"monitoring/internal/models"
+	"monitoring/internal/utils"
+)
+
+const (
+	dirSizeCacheTTL  = 5 * time.Minute
+	dirSizeCacheSize = 256
+)
+
+type dirSizeKey struct {
+	serverID uint
+	path     string
+}
+
+type dirSizeEntry struct {
+	key        dirSizeKey
+	result     models.DirectorySizeResult
+	computedAt time.Time
+	refreshing bool
+}
+
+// dirSizeCache is an LRU of directory sizes shared by all servers
+type dirSizeCache struct {
+	entries map[dirSizeKey]*list.Element
+	order   *list.List // Front is most recently used
+	mu      sync.Mutex
+}
+
+var dirSizes = &dirSizeCache{
+	entries: make(map[dirSizeKey]*list.Element),
+	order:   list.New(),
+}
+
+// get returns a copy of the entry and marks it as recently used
+func (d *dirSizeCache) get(key dirSizeKey) (dirSizeEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	elem, ok := d.entries[key]
+	if !ok {
+		return dirSizeEntry{}, false
+	}
+	d.order.MoveToFront(elem)
+	return *elem.Value.(*dirSizeEntry), true
+}
+
+func (d *dirSizeCache) put(key dirSizeKey, result models.DirectorySizeResult) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry := &dirSizeEntry{key: key, result: result, computedAt: time.Now()}
+	if elem, ok := d.entries[key]; ok {
+		elem.Value = entry
+		d.order.MoveToFront(elem)
+		return
+	}
+
+	d.entries[key] = d.order.PushFront(entry)
+	if d.order.Len() > dirSizeCacheSize {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*dirSizeEntry).key)
+	}
+}
+
+// startRefresh flags an entry as being recomputed, returning false if a
+// refresh is already running
+func (d *dirSizeCache) startRefresh(key dirSizeKey) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	elem, ok := d.entries[key]
+	if !ok {
+		return true
+	}
+	entry := elem.Value.(*dirSizeEntry)
+	if entry.refreshing {
+		return false
+	}
+	entry.refreshing = true
+	return true
+}
+
+// invalidate drops entries whose size may change when p is modified: p's
+// ancestors and, for directories, everything below it
+func (d *dirSizeCache) invalidate(serverID uint, p string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for key, elem := range d.entries {
+		if key.serverID != serverID {
+			continue
+		}
+		if IsWithin(key.path, p) || IsWithin(p, key.path) {
+			d.order.Remove(elem)
+			delete(d.entries, key)
+		}
+	}
+}
+
+func (c *SFTPClient) dirSizeKey(p string) dirSizeKey {
+	return dirSizeKey{serverID: c.sshClient.Server.ID, path: p}
+}
+
+// invalidateDirSize forgets cached sizes affected by a change to p
+func (c *SFTPClient) invalidateDirSize(p string) {
+	dirSizes.invalidate(c.sshClient.Server.ID, p)
+}
+
+// CachedDirectorySize returns the size of a directory from the cache when
+// possible. Stale entries are returned immediately while a background walk
+// refreshes them; refresh forces a synchronous walk.
+func (c *SFTPClient) CachedDirectorySize(p string, refresh bool) (*models.DirectorySizeResult, error) {
+	key := c.dirSizeKey(p)
+
+	if !refresh {
+		if entry, ok := dirSizes.get(key); ok {
+			age := time.Since(entry.computedAt)
+			if age > dirSizeCacheTTL && dirSizes.startRefresh(key) {
+				go c.refreshDirSize(key)
+			}
+
+			result := entry.result
+			result.Cached = true
+			result.AgeSeconds = age.Seconds()
+			return &result, nil
+		}
+	}
+
+	result, err := c.GetDirectorySize(p)
+	if err != nil {
+		return nil, err
+	}
+	dirSizes.put(key, *result)
+	return result, nil
+}
+
+func (c *SFTPClient) refreshDirSize(key dirSizeKey) {
+	result, err := c.GetDirectorySize(key.path)
+	if err != nil {
+		utils.AppLogger.Warning("Failed to refresh size of %s on server %d: %v", key.path, key.serverID, err)
+		dirSizes.invalidate(key.serverID, key.path)
+		return
+	}
+	dirSizes.put(key, *result)
+}
@@ -0,0 +1,78 @@
+package sftp
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/sftp"
+)
+
+// Error kinds of failed operations, matched with errors.Is. The original
+// error stays in the chain and in the message.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrPermission = errors.New("permission denied")
+	ErrExists     = errors.New("already exists")
+	ErrNoSpace    = errors.New("no space left on device")
+)
+
+// ErrTooLarge is returned when a file is read with a size limit it exceeds
+var ErrTooLarge = errors.New("file is larger than the size limit")
+
+// Status codes from later SFTP drafts, sent by servers that speak them.
+// OpenSSH reports these conditions as a generic failure.
+const (
+	fxFileAlreadyExists   = 11
+	fxNoSpaceOnFilesystem = 14
+	fxQuotaExceeded       = 15
+)
+
+// kindError tags an error with the kind it was classified as
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string { return e.err.Error() }
+
+func (e *kindError) Unwrap() []error { return []error{e.err, e.kind} }
+
+// classify tags err with its kind when the status code or underlying error
+// identifies one. Unknown errors are returned unchanged.
+func classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	if kind := errorKind(err); kind != nil && !errors.Is(err, kind) {
+		return &kindError{kind: kind, err: err}
+	}
+	return err
+}
+
+func errorKind(err error) error {
+	var status *sftp.StatusError
+	if errors.As(err, &status) {
+		switch status.Code {
+		case fxFileAlreadyExists:
+			return ErrExists
+		case fxNoSpaceOnFilesystem, fxQuotaExceeded:
+			return ErrNoSpace
+		}
+	}
+
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return ErrNotFound
+	case errors.Is(err, os.ErrPermission), errors.Is(err, ErrPermissionDenied):
+		return ErrPermission
+	case errors.Is(err, os.ErrExist), errors.Is(err, ErrLinkExists):
+		return ErrExists
+	case errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EDQUOT):
+		return ErrNoSpace
+	case strings.Contains(strings.ToLower(err.Error()), "no space left"):
+		return ErrNoSpace
+	}
+	return nil
+}
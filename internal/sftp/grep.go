@@ -0,0 +1,172 @@
+package sftp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"monitoring/internal/models"
+	sshclient "monitoring/internal/ssh"
+)
+
+const (
+	DefaultGrepResults = 100
+	MaxGrepResults     = 1000
+	grepMaxFileSize    = 1024 * 1024       // Larger files are not searched
+	grepMaxScanBytes   = 100 * 1024 * 1024 // Total read by the SFTP fallback
+	grepSnippetLen     = 200
+	grepTimeout        = 30 * time.Second
+)
+
+// GrepOptions controls SearchFileContents
+type GrepOptions struct {
+	CaseSensitive bool
+	MaxResults    int // DefaultGrepResults when zero
+}
+
+// SearchFileContents finds lines containing query in text files below
+// basePath. It runs grep on the server and only reads files over SFTP when
+// the remote shell is unusable. Binary files and files over 1MB are skipped.
+func (c *SFTPClient) SearchFileContents(basePath, query string, opts GrepOptions) (*models.GrepResult, error) {
+	if opts.MaxResults <= 0 {
+		opts.MaxResults = DefaultGrepResults
+	}
+	if opts.MaxResults > MaxGrepResults {
+		opts.MaxResults = MaxGrepResults
+	}
+
+	if _, err := c.sshClient.Execute("command -v grep && command -v find"); err == nil {
+		return c.grepRemote(basePath, query, opts)
+	}
+	return c.grepOverSFTP(basePath, query, opts)
+}
+
+// grepRemote lets find apply the size cap and grep -I skip binaries. Names
+// are NUL terminated (-Z) so paths containing ':' parse correctly.
+func (c *SFTPClient) grepRemote(basePath, query string, opts GrepOptions) (*models.GrepResult, error) {
+	flags := "-nIHZF"
+	if !opts.CaseSensitive {
+		flags += "i"
+	}
+
+	cmd := fmt.Sprintf("find %s -type f -size -%dk -print0 2>/dev/null | xargs -0 -r grep %s -e %s -- 2>/dev/null | head -n %d",
+		sshclient.ShellQuote(basePath), grepMaxFileSize/1024, flags, sshclient.ShellQuote(query), opts.MaxResults+1)
+
+	output, err := c.sshClient.ExecuteWithTimeout(cmd, grepTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("grep failed: %w", err)
+	}
+
+	result := &models.GrepResult{Matches: []models.GrepMatch{}}
+	for _, line := range strings.Split(output, "\n") {
+		path, rest, ok := strings.Cut(line, "\x00")
+		if !ok {
+			continue
+		}
+		lineNo, text, ok := strings.Cut(rest, ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(lineNo)
+		if err != nil {
+			continue
+		}
+
+		if len(result.Matches) >= opts.MaxResults {
+			result.Truncated = true
+			break
+		}
+		result.Matches = append(result.Matches, models.GrepMatch{Path: path, Line: n, Text: snippet(text)})
+	}
+
+	result.Total = len(result.Matches)
+	return result, nil
+}
+
+// grepOverSFTP reads candidate files through SFTP, bounded by grepMaxScanBytes
+func (c *SFTPClient) grepOverSFTP(basePath, query string, opts GrepOptions) (*models.GrepResult, error) {
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	needle := []byte(query)
+	if !opts.CaseSensitive {
+		needle = bytes.ToLower(needle)
+	}
+
+	result := &models.GrepResult{Matches: []models.GrepMatch{}}
+	var scanned int64
+
+	walker := client.Walk(basePath)
+	for walker.Step() {
+		if walker.Err() != nil {
+			continue
+		}
+		info := walker.Stat()
+		if info.IsDir() || !info.Mode().IsRegular() || info.Size() > grepMaxFileSize {
+			continue
+		}
+
+		if scanned+info.Size() > grepMaxScanBytes {
+			result.Truncated = true
+			break
+		}
+		scanned += info.Size()
+
+		file, err := client.Open(walker.Path())
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil || IsBinary(data) {
+			continue
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 64*1024), grepMaxFileSize)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			line := scanner.Bytes()
+			haystack := line
+			if !opts.CaseSensitive {
+				haystack = bytes.ToLower(line)
+			}
+			if !bytes.Contains(haystack, needle) {
+				continue
+			}
+
+			if len(result.Matches) >= opts.MaxResults {
+				result.Truncated = true
+				result.Total = len(result.Matches)
+				return result, nil
+			}
+			result.Matches = append(result.Matches, models.GrepMatch{
+				Path: walker.Path(),
+				Line: lineNo,
+				Text: snippet(string(line)),
+			})
+		}
+	}
+
+	result.Total = len(result.Matches)
+	return result, nil
+}
+
+func snippet(text string) string {
+	text = strings.TrimRight(text, "\r")
+	if len(text) <= grepSnippetLen {
+		return text
+	}
+	// Avoid cutting a multi-byte character in half
+	cut := grepSnippetLen
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "..."
+}
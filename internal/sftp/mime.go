@@ -0,0 +1,114 @@
+package sftp
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"monitoring/internal/models"
+)
+
+const (
+	mimeSniffLen     = 512
+	binarySniffLen   = 8000
+	mimeSniffMaxSize = 100 * 1024 * 1024 // Larger files are classified by extension only
+)
+
+// DetectMime sniffs the first bytes of a file to determine its MIME type
+func (c *SFTPClient) DetectMime(p string) (*models.PreviewInfo, error) {
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := client.Stat(p)
+	if err != nil {
+		return nil, classify(fmt.Errorf("failed to stat file: %w", err))
+	}
+
+	result := &models.PreviewInfo{
+		Path: p,
+		Size: info.Size(),
+	}
+
+	if info.IsDir() {
+		result.MimeType = "inode/directory"
+		return result, nil
+	}
+
+	result.MimeType = mimeFromExtension(p)
+
+	if info.Size() > 0 && info.Size() <= mimeSniffMaxSize {
+		file, err := client.Open(p)
+		if err != nil {
+			return nil, classify(fmt.Errorf("failed to open file: %w", err))
+		}
+		defer file.Close()
+
+		buf := make([]byte, mimeSniffLen)
+		n, err := io.ReadFull(file, buf)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, classify(fmt.Errorf("failed to read file: %w", err))
+		}
+
+		if sniffed := http.DetectContentType(buf[:n]); sniffed != "application/octet-stream" {
+			result.MimeType = sniffed
+		}
+	}
+
+	result.Previewable = isPreviewable(result.MimeType)
+	return result, nil
+}
+
+// IsBinary reports whether data looks like a binary file, judged from its
+// first bytes containing a NUL byte or invalid UTF-8
+func IsBinary(data []byte) bool {
+	if len(data) > binarySniffLen {
+		data = data[:binarySniffLen]
+		// Drop a multi-byte rune cut in half by the limit
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+
+	for _, b := range data {
+		if b == 0 {
+			return true
+		}
+	}
+	return !utf8.Valid(data)
+}
+
+// DetectContentMime returns the MIME type of already loaded content
+func DetectContentMime(p string, data []byte) string {
+	if len(data) > mimeSniffLen {
+		data = data[:mimeSniffLen]
+	}
+	if sniffed := http.DetectContentType(data); sniffed != "application/octet-stream" {
+		return sniffed
+	}
+	return mimeFromExtension(p)
+}
+
+func mimeFromExtension(p string) string {
+	if t := mime.TypeByExtension(path.Ext(p)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// isPreviewable reports whether the UI can render a MIME type inline
+func isPreviewable(mimeType string) bool {
+	base, _, _ := strings.Cut(mimeType, ";")
+	switch {
+	case strings.HasPrefix(base, "text/"), strings.HasPrefix(base, "image/"):
+		return true
+	case base == "application/json", base == "application/pdf", base == "application/xml":
+		return true
+	}
+	return false
+}
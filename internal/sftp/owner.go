@@ -0,0 +1,119 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/sftp"
+
+	sshclient "monitoring/internal/ssh"
+)
+
+// ErrPermissionDenied is returned when the SFTP user may not change ownership
+var ErrPermissionDenied = errors.New("permission denied: changing ownership usually requires connecting as root")
+
+// Chown changes the owner and group of a file
+func (c *SFTPClient) Chown(path string, uid, gid int) error {
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
+
+	return classify(ownerError(client.Chown(path, uid, gid)))
+}
+
+// Owner returns the numeric owner and group of a file
+func (c *SFTPClient) Owner(path string) (uid, gid int, err error) {
+	info, err := c.Stat(path)
+	if err != nil {
+		return -1, -1, err
+	}
+
+	stat, ok := info.Sys().(*sftp.FileStat)
+	if !ok {
+		return -1, -1, fmt.Errorf("ownership not reported by server")
+	}
+	return int(stat.UID), int(stat.GID), nil
+}
+
+// ChownRecursive changes the owner and group of path and everything below it.
+// Symlinks are skipped, since SETSTAT would follow them out of the tree.
+// It returns the number of entries changed.
+func (c *SFTPClient) ChownRecursive(path string, uid, gid int) (int, error) {
+	client, err := c.conn()
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	walker := client.Walk(path)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return changed, classify(ownerError(err))
+		}
+		if walker.Stat().Mode()&os.ModeSymlink != 0 {
+			continue
+		}
+		if err := client.Chown(walker.Path(), uid, gid); err != nil {
+			return changed, classify(fmt.Errorf("%s: %w", walker.Path(), ownerError(err)))
+		}
+		changed++
+	}
+
+	return changed, nil
+}
+
+// ResolveOwner looks up numeric IDs for a user and group name on the server.
+// Names that are already numeric are used as is.
+func (c *SFTPClient) ResolveOwner(user, group string) (uid, gid int, err error) {
+	uid, gid = -1, -1
+
+	if user != "" {
+		if uid, err = c.lookupID("id -u "+sshclient.ShellQuote(user), user); err != nil {
+			return -1, -1, fmt.Errorf("unknown user %q", user)
+		}
+	}
+	if group != "" {
+		if gid, err = c.lookupID("getent group "+sshclient.ShellQuote(group)+" | cut -d: -f3", group); err != nil {
+			return -1, -1, fmt.Errorf("unknown group %q", group)
+		}
+	}
+
+	return uid, gid, nil
+}
+
+// OwnerNames looks up the user and group names of numeric IDs in one
+// command. Names that cannot be resolved are left empty.
+func (c *SFTPClient) OwnerNames(uid, gid string) (user, group string) {
+	output, err := c.sshClient.Execute(fmt.Sprintf(
+		"getent passwd %s | cut -d: -f1; echo @@; getent group %s | cut -d: -f1",
+		sshclient.ShellQuote(uid), sshclient.ShellQuote(gid)))
+	if err != nil {
+		return "", ""
+	}
+
+	user, group, _ = strings.Cut(output, "@@")
+	return strings.TrimSpace(user), strings.TrimSpace(group)
+}
+
+func (c *SFTPClient) lookupID(command, name string) (int, error) {
+	if id, err := strconv.Atoi(name); err == nil && id >= 0 {
+		return id, nil
+	}
+
+	output, err := c.sshClient.Execute(command)
+	if err != nil {
+		return -1, err
+	}
+	return strconv.Atoi(strings.TrimSpace(output))
+}
+
+func ownerError(err error) error {
+	if errors.Is(err, os.ErrPermission) {
+		return ErrPermissionDenied
+	}
+	return err
+}
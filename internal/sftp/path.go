@@ -0,0 +1,137 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/sftp"
+)
+
+var ErrPathOutsideRoot = errors.New("path escapes the allowed root directory")
+
+// SanitizePath cleans a remote path and ensures it stays within root.
+// Relative paths are resolved against root and an empty path means root itself.
+// The check is lexical, SFTPClient.ResolvePath also follows symlinks.
+func SanitizePath(root, p string) (string, error) {
+	root = path.Clean("/" + root)
+	if p == "" {
+		return root, nil
+	}
+
+	if path.IsAbs(p) {
+		p = path.Clean(p)
+	} else {
+		p = path.Join(root, p)
+	}
+
+	if !IsWithin(root, p) {
+		return "", ErrPathOutsideRoot
+	}
+	return p, nil
+}
+
+// IsWithin reports whether p is root or a descendant of root
+func IsWithin(root, p string) bool {
+	root = path.Clean(root)
+	p = path.Clean(p)
+
+	if root == "/" {
+		return path.IsAbs(p)
+	}
+	return p == root || strings.HasPrefix(p, root+"/")
+}
+
+// maxLinkHops bounds the symlinks followed while resolving one path
+const maxLinkHops = 40
+
+// checkLinks fails with ErrPathOutsideRoot when following the symlinks in p,
+// a path already lexically within root, leads outside root. Components that
+// do not exist yet end the walk, as nothing below them can be a link.
+func (c *SFTPClient) checkLinks(root, p string) error {
+	client, err := c.conn()
+	if err != nil {
+		return err
+	}
+
+	realRoot, err := c.resolvedRoot(client, root)
+	if err != nil {
+		return err
+	}
+
+	rest := splitPath(strings.TrimPrefix(p, root))
+	resolved := realRoot
+	for hops := 0; len(rest) > 0; {
+		name := rest[0]
+		rest = rest[1:]
+		if name == ".." {
+			resolved = path.Dir(resolved)
+			continue
+		}
+
+		next := path.Join(resolved, name)
+		info, err := client.Lstat(next)
+		if err != nil {
+			resolved = path.Join(next, path.Join(rest...))
+			break
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		if hops++; hops > maxLinkHops {
+			return fmt.Errorf("%s: too many levels of symbolic links", p)
+		}
+		target, err := client.ReadLink(next)
+		if err != nil {
+			return classify(err)
+		}
+		if path.IsAbs(target) {
+			resolved = "/"
+		}
+		rest = append(splitPath(target), rest...)
+	}
+
+	if !IsWithin(realRoot, resolved) {
+		return ErrPathOutsideRoot
+	}
+	return nil
+}
+
+// resolvedRoot returns root with its symlinks resolved by the server,
+// caching it until the root changes
+func (c *SFTPClient) resolvedRoot(client *sftp.Client, root string) (string, error) {
+	c.mu.RLock()
+	realRoot := c.realRoot
+	c.mu.RUnlock()
+	if realRoot != "" {
+		return realRoot, nil
+	}
+
+	realRoot, err := client.RealPath(root)
+	if err != nil {
+		return "", classify(err)
+	}
+	realRoot = path.Clean(realRoot)
+
+	c.mu.Lock()
+	if path.Clean("/"+c.rootPath) == root {
+		c.realRoot = realRoot
+	}
+	c.mu.Unlock()
+	return realRoot, nil
+}
+
+// splitPath returns the non-empty components of p
+func splitPath(p string) []string {
+	var parts []string
+	for _, part := range strings.Split(p, "/") {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,160 @@
+package sftp
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/sftp"
+
+	"monitoring/internal/models"
+	sshclient "monitoring/internal/ssh"
+)
+
+func TestSanitizePath(t *testing.T) {
+	tests := []struct {
+		root, path string
+		want       string
+		err        error
+	}{
+		{"/srv/data", "", "/srv/data", nil},
+		{"/srv/data", "reports/q1.csv", "/srv/data/reports/q1.csv", nil},
+		{"/srv/data", "/srv/data/reports", "/srv/data/reports", nil},
+		{"/srv/data", "reports/../logs", "/srv/data/logs", nil},
+		{"/srv/data", "../../etc/passwd", "", ErrPathOutsideRoot},
+		{"/srv/data", "reports/../../../etc/passwd", "", ErrPathOutsideRoot},
+		{"/srv/data", "/etc/passwd", "", ErrPathOutsideRoot},
+		{"/srv/data", "/srv/data/../../etc/passwd", "", ErrPathOutsideRoot},
+		{"/srv/data", "/srv/database", "", ErrPathOutsideRoot},
+		{"/srv/data/", "./a//b/", "/srv/data/a/b", nil},
+		{"", "../../etc/passwd", "/etc/passwd", nil},
+		{"/", "/etc/passwd", "/etc/passwd", nil},
+	}
+
+	for _, tt := range tests {
+		got, err := SanitizePath(tt.root, tt.path)
+		if !errors.Is(err, tt.err) || got != tt.want {
+			t.Errorf("SanitizePath(%q, %q) = %q, %v; want %q, %v", tt.root, tt.path, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestIsWithin(t *testing.T) {
+	tests := []struct {
+		root, path string
+		want       bool
+	}{
+		{"/srv/data", "/srv/data", true},
+		{"/srv/data", "/srv/data/", true},
+		{"/srv/data", "/srv/data/a/b", true},
+		{"/srv/data", "/srv/database", false},
+		{"/srv/data", "/srv", false},
+		{"/srv/data", "/srv/data/../../etc/passwd", false},
+		{"/", "/etc/passwd", true},
+		{"/", "etc/passwd", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsWithin(tt.root, tt.path); got != tt.want {
+			t.Errorf("IsWithin(%q, %q) = %v, want %v", tt.root, tt.path, got, tt.want)
+		}
+	}
+}
+
+// newTestClient returns a client served in-process from the local file
+// system, jailed to root
+func newTestClient(t testing.TB, root string) *SFTPClient {
+	t.Helper()
+
+	clientConn, serverConn := newPipe()
+	server, err := sftp.NewServer(serverConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		clientConn.Close()
+		serverConn.Close()
+		client.Close()
+	})
+	return &SFTPClient{
+		sshClient:  &sshclient.SSHClient{Server: &models.Server{}},
+		sftpClient: client,
+		rootPath:   root,
+	}
+}
+
+// pipeConn joins the read end of one pipe with the write end of another
+type pipeConn struct {
+	*io.PipeReader
+	*io.PipeWriter
+}
+
+func (p *pipeConn) Close() error {
+	p.PipeReader.Close()
+	return p.PipeWriter.Close()
+}
+
+func newPipe() (*pipeConn, *pipeConn) {
+	clientRead, serverWrite := io.Pipe()
+	serverRead, clientWrite := io.Pipe()
+	return &pipeConn{clientRead, clientWrite}, &pipeConn{serverRead, serverWrite}
+}
+
+func TestResolvePathFollowsLinks(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(root, "data"), outside} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		"escape":   outside,
+		"relative": "../outside",
+		"inside":   "data",
+		"absolute": filepath.Join(root, "data"),
+		"dangling": filepath.Join(outside, "missing"),
+		"loop":     "loop",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client := newTestClient(t, root)
+	tests := []struct {
+		path string
+		ok   bool
+	}{
+		{"data/report.csv", true},
+		{"new/dir/file.txt", true},
+		{"inside/report.csv", true},
+		{"absolute/report.csv", true},
+		{"escape", false},
+		{"escape/secret.txt", false},
+		{"relative/secret.txt", false},
+		{"dangling", false},
+		{"inside/../escape/secret.txt", false},
+		{"loop/file", false},
+	}
+
+	for _, tt := range tests {
+		_, err := client.ResolvePath(tt.path)
+		if (err == nil) != tt.ok {
+			t.Errorf("ResolvePath(%q) error = %v, want ok %v", tt.path, err, tt.ok)
+		}
+		if !tt.ok && tt.path != "loop/file" && !errors.Is(err, ErrPathOutsideRoot) {
+			t.Errorf("ResolvePath(%q) error = %v, want ErrPathOutsideRoot", tt.path, err)
+		}
+	}
+}
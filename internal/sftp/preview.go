@@ -0,0 +1,55 @@
+package sftp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"monitoring/internal/models"
+)
+
+// PreviewRemove walks path and lists everything a recursive delete of it
+// would remove, without deleting anything. Symlinks are listed, not followed.
+func (c *SFTPClient) PreviewRemove(path string) (*models.DeletePreview, error) {
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.DeletePreview{Path: path, Entries: []models.DeleteEntry{}}
+	walker := client.Walk(path)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return nil, classify(err)
+		}
+
+		info := walker.Stat()
+		if info.IsDir() {
+			preview.Directories++
+		} else {
+			preview.Files++
+			preview.TotalSize += info.Size()
+		}
+
+		if len(preview.Entries) < models.MaxPreviewEntries {
+			preview.Entries = append(preview.Entries, models.DeleteEntry{
+				Path:  walker.Path(),
+				IsDir: info.IsDir(),
+				Size:  info.Size(),
+			})
+		} else {
+			preview.Truncated = true
+		}
+	}
+
+	preview.Confirm = previewToken(preview)
+	return preview, nil
+}
+
+// previewToken summarises a preview, so a delete confirmed with it fails
+// when the tree has changed since it was shown
+func previewToken(preview *models.DeletePreview) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d\x00%d\x00%d",
+		preview.Path, preview.Files, preview.Directories, preview.TotalSize)))
+	return hex.EncodeToString(sum[:8])
+}
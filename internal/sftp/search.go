@@ -0,0 +1,125 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"monitoring/internal/models"
+)
+
+const (
+	DefaultSearchLimit = 100
+	MaxSearchLimit     = 10000
+	searchTimeout      = 30 * time.Second
+	maxSearchErrors    = 50
+)
+
+// ErrInvalidPattern is returned for malformed regular expressions
+var ErrInvalidPattern = errors.New("invalid regular expression")
+
+// Search type filters
+const (
+	SearchTypeFile = "file"
+	SearchTypeDir  = "dir"
+)
+
+// SearchOptions controls how SearchFiles matches and limits results
+type SearchOptions struct {
+	Limit int    // Maximum number of matches, DefaultSearchLimit when zero
+	Type  string // SearchTypeFile, SearchTypeDir or empty for both
+	Regex bool   // Treat the pattern as a regular expression
+}
+
+// SearchFiles walks basePath for names matching pattern. Without Regex a
+// name matches the glob pattern or contains it case-insensitively. Walk
+// errors are collected instead of aborting, and the result reports whether
+// the limit or timeout cut the search short.
+func (c *SFTPClient) SearchFiles(basePath, pattern string, opts SearchOptions) (*models.SearchResult, error) {
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	match, err := searchMatcher(pattern, opts.Regex)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	result := &models.SearchResult{
+		Files:  []models.FileInfo{},
+		Errors: []string{},
+	}
+	deadline := time.Now().Add(searchTimeout)
+
+	walker := client.Walk(basePath)
+	for walker.Step() {
+		if time.Now().After(deadline) {
+			result.TimedOut = true
+			result.Truncated = true
+			break
+		}
+
+		if err := walker.Err(); err != nil {
+			if len(result.Errors) < maxSearchErrors {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", walker.Path(), err))
+			}
+			continue
+		}
+
+		info := walker.Stat()
+		if opts.Type == SearchTypeFile && info.IsDir() || opts.Type == SearchTypeDir && !info.IsDir() {
+			continue
+		}
+
+		if !match(info.Name()) {
+			continue
+		}
+
+		if len(result.Files) >= limit {
+			result.Truncated = true
+			break
+		}
+
+		result.Files = append(result.Files, models.FileInfo{
+			Name:        info.Name(),
+			Path:        walker.Path(),
+			Size:        info.Size(),
+			IsDir:       info.IsDir(),
+			Permissions: info.Mode(),
+			ModTime:     info.ModTime(),
+		})
+	}
+
+	result.Total = len(result.Files)
+	return result, nil
+}
+
+func searchMatcher(pattern string, regex bool) (func(name string) bool, error) {
+	if regex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPattern, err)
+		}
+		return re.MatchString, nil
+	}
+
+	lower := strings.ToLower(pattern)
+	return func(name string) bool {
+		if matched, err := filepath.Match(pattern, name); err == nil && matched {
+			return true
+		}
+		return strings.Contains(strings.ToLower(name), lower)
+	}, nil
+}
@@ -0,0 +1,107 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"monitoring/internal/models"
+	sshclient "monitoring/internal/ssh"
+	"monitoring/internal/utils"
+)
+
+// Server returns the server this client is connected to
+func (c *SFTPClient) Server() *models.Server {
+	return c.sshClient.Server
+}
+
+// StatVFS returns the size of the filesystem holding p. A p that does not
+// exist yet is measured at its closest existing parent. Servers without the
+// statvfs extension are asked through df.
+func (c *SFTPClient) StatVFS(p string) (*models.FilesystemStats, error) {
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := p
+	for {
+		_, err := client.Stat(dir)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) || dir == "/" || dir == "." {
+			return nil, err
+		}
+		dir = path.Dir(dir)
+	}
+
+	stats := &models.FilesystemStats{Path: dir}
+	if vfs, err := client.StatVFS(dir); err == nil {
+		stats.Total = vfs.Frsize * vfs.Blocks
+		stats.Free = vfs.Frsize * vfs.Bfree
+		stats.Available = vfs.Frsize * vfs.Bavail
+		stats.Used = stats.Total - stats.Free
+		stats.Source = "statvfs"
+	} else {
+		output, err := c.sshClient.Execute("df -Pk " + sshclient.ShellQuote(dir))
+		if err != nil {
+			return nil, err
+		}
+		if err := parseDfSizes(output, stats); err != nil {
+			return nil, err
+		}
+		stats.Source = "df"
+	}
+
+	// Like df, the percentage leaves out blocks reserved for root
+	if usable := stats.Used + stats.Available; usable > 0 {
+		stats.UsedPercent = math.Round(float64(stats.Used)/float64(usable)*10000) / 100
+	}
+	stats.TotalHuman = utils.FormatBytes(stats.Total)
+	stats.UsedHuman = utils.FormatBytes(stats.Used)
+	stats.FreeHuman = utils.FormatBytes(stats.Free)
+	stats.AvailableHuman = utils.FormatBytes(stats.Available)
+	return stats, nil
+}
+
+// AvailableBytes returns the space an unprivileged user can still write on
+// the filesystem holding p
+func (c *SFTPClient) AvailableBytes(p string) (uint64, error) {
+	stats, err := c.StatVFS(p)
+	if err != nil {
+		return 0, err
+	}
+	return stats.Available, nil
+}
+
+// parseDfSizes reads the size columns of POSIX df -Pk output. df does not
+// report reserved blocks, so Free is derived from Total and Used.
+func parseDfSizes(output string, stats *models.FilesystemStats) error {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) < 2 {
+		return fmt.Errorf("unexpected df output")
+	}
+
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 4 {
+		return fmt.Errorf("unexpected df output")
+	}
+
+	var kb [3]uint64
+	for i := range kb {
+		value, err := strconv.ParseUint(fields[i+1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("unexpected df output: %w", err)
+		}
+		kb[i] = value * 1024
+	}
+
+	stats.Total, stats.Used, stats.Available = kb[0], kb[1], kb[2]
+	stats.Free = stats.Total - stats.Used
+	return nil
+}
@@ -0,0 +1,60 @@
+package sftp
+
+import (
+	"errors"
+	"fmt"
+	"path"
+
+	"monitoring/internal/models"
+)
+
+// ErrLinkExists is returned when the link path is taken and force is not set
+var ErrLinkExists = errors.New("link path already exists")
+
+// CreateSymlink creates linkPath pointing at target. Relative targets are
+// kept relative, as deploy links like current -> releases/xyz expect, but
+// both the link and the resolved target must stay within the root. With
+// force an existing link or file at linkPath is replaced; directories never
+// are. The returned entry matches what ListDirectory reports for the link.
+func (c *SFTPClient) CreateSymlink(target, linkPath string, force bool) (*models.FileInfo, error) {
+	linkPath, err := c.ResolvePath(linkPath)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := target
+	if !path.IsAbs(resolved) {
+		resolved = path.Join(path.Dir(linkPath), resolved)
+	}
+	if _, err := c.ResolvePath(resolved); err != nil {
+		return nil, classify(fmt.Errorf("target: %w", err))
+	}
+
+	client, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	if existing, err := client.Lstat(linkPath); err == nil {
+		if !force {
+			return nil, ErrLinkExists
+		}
+		if existing.IsDir() {
+			return nil, fmt.Errorf("%w: refusing to replace a directory", ErrLinkExists)
+		}
+		if err := client.Remove(linkPath); err != nil {
+			return nil, classify(fmt.Errorf("failed to remove existing link: %w", err))
+		}
+	}
+
+	if err := client.Symlink(target, linkPath); err != nil {
+		return nil, classify(fmt.Errorf("failed to create symlink: %w", err))
+	}
+
+	info, err := client.Lstat(linkPath)
+	if err != nil {
+		return nil, err
+	}
+	fileInfo := newFileInfo(client, linkPath, info)
+	return &fileInfo, nil
+}
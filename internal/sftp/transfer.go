@@ -0,0 +1,104 @@
+package sftp
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+)
+
+// transfers counts uploads and downloads in flight so shutdown can wait for them
+var transfers sync.WaitGroup
+
+// trackTransfer marks a transfer as started and returns the func ending it
+func trackTransfer() func() {
+	transfers.Add(1)
+	return transfers.Done
+}
+
+// WaitForTransfers blocks until in-flight transfers finish or ctx is done
+func WaitForTransfers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		transfers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ErrSourceIsDir is returned when a transfer names a directory as its source
+var ErrSourceIsDir = errors.New("source is a directory")
+
+// TransferBetweenServers streams srcPath on src to dstPath on dst without
+// staging the file on the API host, returning the number of bytes copied.
+// The copy goes to a temporary file beside dstPath that replaces it only
+// once complete, so a failed transfer leaves the destination as it was.
+func TransferBetweenServers(src *SFTPClient, srcPath string, dst *SFTPClient, dstPath string, preserveMode bool) (int64, error) {
+	defer trackTransfer()()
+	defer dst.invalidateDirSize(dstPath)
+
+	srcClient, err := src.conn()
+	if err != nil {
+		return 0, err
+	}
+	dstClient, err := dst.conn()
+	if err != nil {
+		return 0, err
+	}
+
+	in, err := srcClient.Open(srcPath)
+	if err != nil {
+		return 0, classify(fmt.Errorf("failed to open source file: %w", err))
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return 0, classify(fmt.Errorf("failed to stat source file: %w", err))
+	}
+	if info.IsDir() {
+		return 0, ErrSourceIsDir
+	}
+
+	if err := dstClient.MkdirAll(path.Dir(dstPath)); err != nil {
+		return 0, classify(fmt.Errorf("failed to create directory: %w", err))
+	}
+
+	suffix := make([]byte, 8)
+	rand.Read(suffix)
+	tmpPath := path.Join(path.Dir(dstPath), fmt.Sprintf(".%s.%x.part", path.Base(dstPath), suffix))
+
+	out, err := dstClient.Create(tmpPath)
+	if err != nil {
+		return 0, classify(fmt.Errorf("failed to create destination file: %w", err))
+	}
+
+	written, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		dstClient.Remove(tmpPath)
+		return written, classify(fmt.Errorf("failed to transfer file: %w", err))
+	}
+
+	if preserveMode {
+		dstClient.Chmod(tmpPath, info.Mode().Perm())
+	}
+
+	if err := dstClient.PosixRename(tmpPath, dstPath); err != nil {
+		dstClient.Remove(tmpPath)
+		return written, classify(fmt.Errorf("failed to replace destination file: %w", err))
+	}
+
+	return written, nil
+}
@@ -0,0 +1,89 @@
+package sftp
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/pkg/sftp"
+
+	"monitoring/internal/utils"
+)
+
+// UploadJob is a single file of a batch upload
+type UploadJob struct {
+	RemotePath string
+	Open       func() (io.ReadCloser, error)
+}
+
+// UploadFiles uploads jobs in parallel and returns one error slot per job.
+// Each worker opens its own SFTP session on the shared SSH connection so
+// large transfers do not queue behind each other on a single channel.
+func (c *SFTPClient) UploadFiles(jobs []UploadJob, concurrency int) []error {
+	errs := make([]error, len(jobs))
+	if len(jobs) == 0 {
+		return errs
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if concurrency > len(jobs) {
+		concurrency = len(jobs)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			session, err := c.newSession()
+			if err != nil {
+				utils.AppLogger.Warning("Failed to open extra SFTP session, falling back to shared client: %v", err)
+				session = nil
+			} else {
+				defer session.Close()
+			}
+
+			for i := range indexes {
+				errs[i] = c.runUploadJob(session, jobs[i])
+			}
+		}()
+	}
+
+	for i := range jobs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
+
+// newSession opens an additional SFTP session on the underlying SSH connection
+func (c *SFTPClient) newSession() (*sftp.Client, error) {
+	conn := c.sshClient.GetUnderlyingClient()
+	if conn == nil {
+		return nil, fmt.Errorf("ssh connection closed")
+	}
+	return sftp.NewClient(conn)
+}
+
+// runUploadJob uploads through session, or through the shared client when session is nil
+func (c *SFTPClient) runUploadJob(session *sftp.Client, job UploadJob) error {
+	defer c.invalidateDirSize(job.RemotePath)
+
+	reader, err := job.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if session == nil {
+		return c.UploadFile(job.RemotePath, reader, 0)
+	}
+	return uploadFile(session, job.RemotePath, reader)
+}
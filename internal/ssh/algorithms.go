@@ -0,0 +1,87 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+)
+
+// Algorithm presets selectable per server (ssh_preset) or globally (SSH_PRESET)
+const (
+	PresetDefault = "default"
+	PresetLegacy  = "legacy"
+)
+
+// The legacy preset keeps the modern algorithms first and adds the CBC/RC4
+// ciphers, SHA-1 key exchanges and MACs that old network gear still needs
+var (
+	legacyCiphers = []string{
+		"aes128-gcm@openssh.com", "aes256-gcm@openssh.com", "chacha20-poly1305@openssh.com",
+		"aes128-ctr", "aes192-ctr", "aes256-ctr",
+		"aes128-cbc", "3des-cbc", "arcfour256", "arcfour128", "arcfour",
+	}
+	legacyKeyExchanges = []string{
+		"curve25519-sha256", "curve25519-sha256@libssh.org",
+		"ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+		"diffie-hellman-group14-sha256", "diffie-hellman-group16-sha512",
+		"diffie-hellman-group14-sha1", "diffie-hellman-group-exchange-sha256",
+		"diffie-hellman-group-exchange-sha1", "diffie-hellman-group1-sha1",
+	}
+	legacyMACs = []string{
+		"hmac-sha2-256-etm@openssh.com", "hmac-sha2-512-etm@openssh.com",
+		"hmac-sha2-256", "hmac-sha2-512", "hmac-sha1", "hmac-sha1-96",
+	}
+)
+
+// ErrNoCommonAlgorithm is returned when the server only offers algorithms
+// the current preset does not enable
+var ErrNoCommonAlgorithm = errors.New("no common SSH algorithm, set ssh_preset to \"legacy\" for older devices")
+
+// IsAlgorithmPreset reports whether name is a known preset, empty meaning
+// the global SSH_PRESET
+func IsAlgorithmPreset(name string) bool {
+	return name == "" || name == PresetDefault || name == PresetLegacy
+}
+
+// algorithmConfig returns the algorithms offered to a server. The server's
+// preset wins over SSH_PRESET, and SSH_CIPHERS, SSH_KEX and SSH_MACS replace
+// the matching list of either. Nil lists keep Go's secure defaults; names the
+// library does not implement are ignored.
+func algorithmConfig(server *models.Server) ssh.Config {
+	settings := config.Get()
+	preset := server.SSHPreset
+	if preset == "" {
+		preset = settings.SSHPreset
+	}
+
+	var cfg ssh.Config
+	if preset == PresetLegacy {
+		cfg.Ciphers = legacyCiphers
+		cfg.KeyExchanges = legacyKeyExchanges
+		cfg.MACs = legacyMACs
+	}
+
+	if len(settings.SSHCiphers) > 0 {
+		cfg.Ciphers = settings.SSHCiphers
+	}
+	if len(settings.SSHKeyExchanges) > 0 {
+		cfg.KeyExchanges = settings.SSHKeyExchanges
+	}
+	if len(settings.SSHMACs) > 0 {
+		cfg.MACs = settings.SSHMACs
+	}
+	return cfg
+}
+
+// algorithmError points negotiation failures at the legacy preset
+func algorithmError(err error) error {
+	if err != nil && strings.Contains(err.Error(), "no common algorithm") {
+		return fmt.Errorf("%w: %v", ErrNoCommonAlgorithm, err)
+	}
+	return err
+}
@@ -0,0 +1,186 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"monitoring/config"
+	"monitoring/internal/utils"
+)
+
+// Auth orders a server can be restricted to. The default, empty, offers a
+// private key when one is configured and then the password.
+const (
+	AuthPassword = "password"
+	AuthKey      = "key"
+)
+
+// maxKeySize keeps a mistyped key path from reading a large file
+const maxKeySize = 64 << 10
+
+// ErrPathNotAllowed is returned for key and ssh_config paths outside
+// SSH_KEY_DIR. It says nothing of whether the file exists.
+var ErrPathNotAllowed = errors.New("path must name a file in SSH_KEY_DIR")
+
+// IsAuthOrder reports whether order is a known auth order, empty meaning
+// key then password
+func IsAuthOrder(order string) bool {
+	return order == "" || order == AuthPassword || order == AuthKey
+}
+
+// ValidateKey checks that path holds a private key that can be used without
+// a passphrase
+func ValidateKey(path string) error {
+	_, err := loadKey(path)
+	return err
+}
+
+// keyDirPath resolves name, relative to SSH_KEY_DIR unless it is absolute,
+// and returns it only when it stays in SSH_KEY_DIR once symlinks are
+// followed. A leading ~ is the home directory of the user running the API.
+// A missing file reports os.ErrNotExist only when its path is in the
+// directory, so other paths cannot be probed.
+func keyDirPath(name string) (string, error) {
+	dir := config.Get().SSHKeyDir
+	if dir == "" {
+		return "", ErrPathNotAllowed
+	}
+	dir, err := expandHome(dir)
+	if err != nil {
+		return "", ErrPathNotAllowed
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", ErrPathNotAllowed
+	}
+
+	if name, err = expandHome(name); err != nil {
+		return "", ErrPathNotAllowed
+	}
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(dir, name)
+	}
+	name = filepath.Clean(name)
+	if !inDir(dir, name) {
+		return "", ErrPathNotAllowed
+	}
+
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", ErrPathNotAllowed
+	}
+	real, err := filepath.EvalSymlinks(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", os.ErrNotExist
+	}
+	if err != nil || !inDir(realDir, real) {
+		return "", ErrPathNotAllowed
+	}
+	return real, nil
+}
+
+// inDir reports whether name is below dir, both clean absolute paths
+func inDir(dir, name string) bool {
+	rel, err := filepath.Rel(dir, name)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// loadKey reads an unencrypted private key from SSH_KEY_DIR
+func loadKey(name string) (ssh.Signer, error) {
+	file, err := keyDirPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("private key %s: %w", name, err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("private key %s: %w", name, err)
+	}
+	if !info.Mode().IsRegular() || info.Size() > maxKeySize {
+		return nil, fmt.Errorf("private key %s is not a key file", name)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("private key %s: %w", name, err)
+	}
+	signer, err := ssh.ParsePrivateKey(data)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		return nil, fmt.Errorf("private key %s is protected by a passphrase, which is not supported", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("private key %s: %w", name, err)
+	}
+	return signer, nil
+}
+
+// serverKey returns the server's private key: KeyPath, or else the
+// IdentityFile of its ssh_config entry. Like ssh, a missing IdentityFile is
+// skipped. The signer is nil when the server has no key.
+func (c *SSHClient) serverKey() (ssh.Signer, error) {
+	if c.Server.KeyPath != "" {
+		return loadKey(c.Server.KeyPath)
+	}
+	if c.Server.SSHConfigPath == "" {
+		return nil, nil
+	}
+
+	hosts, err := loadSSHConfig(c.Server.SSHConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	identity := hosts.lookup(c.Server.IPAddress).IdentityFile
+	if identity == "" {
+		return nil, nil
+	}
+	signer, err := loadKey(identity)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if err != nil {
+		utils.AppLogger.Debug("Skipping IdentityFile of server %d: %v", c.Server.ID, err)
+	}
+	return signer, nil
+}
+
+// authMethods lists the methods the server's AuthOrder allows, the key
+// first so ssh falls back to the password when the key is refused. ssh asks
+// each method for its credentials just before trying it, so the last one
+// written to *used is the method that logged in.
+func (c *SSHClient) authMethods(used *string) ([]ssh.AuthMethod, error) {
+	order := c.Server.AuthOrder
+	var methods []ssh.AuthMethod
+
+	if order != AuthPassword {
+		signer, err := c.serverKey()
+		if err != nil {
+			return nil, err
+		}
+		if signer == nil && order == AuthKey {
+			return nil, fmt.Errorf("auth_order is key but the server has no private key")
+		}
+		if signer != nil {
+			methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+				*used = "publickey"
+				return []ssh.Signer{signer}, nil
+			}))
+		}
+	}
+
+	if order != AuthKey && c.password != "" {
+		methods = append(methods, ssh.PasswordCallback(func() (string, error) {
+			*used = "password"
+			return c.password, nil
+		}))
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("server has no password or private key to log in with")
+	}
+	return methods, nil
+}
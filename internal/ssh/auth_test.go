@@ -0,0 +1,114 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+)
+
+// writeKey stores a new unencrypted private key at path
+func writeKey(t *testing.T, path string) {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKeyPathsStayInKeyDir(t *testing.T) {
+	keyDir := t.TempDir()
+	outside := t.TempDir()
+	t.Setenv("SSH_KEY_DIR", keyDir)
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeKey(t, filepath.Join(keyDir, "web.key"))
+	writeKey(t, filepath.Join(outside, "id_ed25519"))
+	if err := os.Symlink(filepath.Join(outside, "id_ed25519"), filepath.Join(keyDir, "escape.key")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		err  error
+	}{
+		{"web.key", nil},
+		{filepath.Join(keyDir, "web.key"), nil},
+		{"missing.key", os.ErrNotExist},
+		{filepath.Join(outside, "id_ed25519"), ErrPathNotAllowed},
+		{filepath.Join(outside, "missing"), ErrPathNotAllowed},
+		{"../" + filepath.Base(outside) + "/id_ed25519", ErrPathNotAllowed},
+		{"escape.key", ErrPathNotAllowed},
+		{"/etc/passwd", ErrPathNotAllowed},
+		{".", ErrPathNotAllowed},
+	}
+
+	for _, tt := range tests {
+		err := ValidateKey(tt.path)
+		if tt.err == nil && err != nil || tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("ValidateKey(%q) error = %v, want %v", tt.path, err, tt.err)
+		}
+	}
+
+	t.Setenv("SSH_KEY_DIR", "")
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateKey(filepath.Join(keyDir, "web.key")); !errors.Is(err, ErrPathNotAllowed) {
+		t.Errorf("without SSH_KEY_DIR error = %v, want ErrPathNotAllowed", err)
+	}
+}
+
+func TestSSHConfigStaysInKeyDir(t *testing.T) {
+	keyDir := t.TempDir()
+	outside := t.TempDir()
+	t.Setenv("SSH_KEY_DIR", keyDir)
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeKey(t, filepath.Join(keyDir, "web.key"))
+	writeKey(t, filepath.Join(outside, "id_ed25519"))
+	hosts := "Host web\n\tIdentityFile web.key\nHost db\n\tIdentityFile " + filepath.Join(outside, "id_ed25519") + "\n"
+	for _, dir := range []string{keyDir, outside} {
+		if err := os.WriteFile(filepath.Join(dir, "config"), []byte(hosts), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ValidateSSHConfig(filepath.Join(outside, "config")); !errors.Is(err, ErrPathNotAllowed) {
+		t.Errorf("ssh config outside SSH_KEY_DIR error = %v, want ErrPathNotAllowed", err)
+	}
+
+	tests := []struct {
+		host string
+		err  error
+	}{
+		{"web", nil},
+		{"db", ErrPathNotAllowed},
+	}
+	for _, tt := range tests {
+		client := &SSHClient{Server: &models.Server{IPAddress: tt.host, SSHConfigPath: "config"}}
+		signer, err := client.serverKey()
+		if tt.err == nil && (err != nil || signer == nil) || tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("IdentityFile of %s: signer %v, error %v; want error %v", tt.host, signer != nil, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package ssh
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// batchScript gathers the base metrics in a single session. Each source is
+// preceded by a marker line, so a command that fails on a host only leaves
+// its own section empty and the rest of the snapshot intact.
+const batchScript = `exec 2>/dev/null
+echo @@stat1; grep '^cpu ' /proc/stat; cat /proc/diskstats; sleep 0.5
+echo @@stat2; grep '^cpu ' /proc/stat; cat /proc/diskstats
+echo @@inodes; df -iP
+echo @@mem; free -m | awk '/^Mem/ {print $2, $3, $4}'
+echo @@disk; df -BG / | tail -1 | awk '{gsub("G",""); print $2, $3, $4}'
+echo @@route; ip route get 1.1.1.1 | head -1
+echo @@net; cat /proc/net/dev
+echo @@uptime; cat /proc/uptime
+echo @@sensors; command -v sensors >/dev/null && sensors -u
+echo @@thermal; for z in /sys/class/thermal/thermal_zone*; do [ -r "$z/temp" ] && echo "$(basename $z):$(cat $z/type) $(cat $z/temp)"; done
+echo @@gpu; command -v nvidia-smi >/dev/null && echo present && ` + gpuQuery + `
+true`
+
+// batchTimeout bounds the batch session; it normally takes about 0.5s
+const batchTimeout = 20 * time.Second
+
+// batchSampleInterval is the sleep between the stat1 and stat2 samples
+const batchSampleInterval = 500 * time.Millisecond
+
+// batchSections splits batch output by section name. A missing section
+// means the batch itself failed and the individual collector should run.
+func batchSections(output string) map[string]string {
+	sections := make(map[string]string)
+	name := ""
+	var body strings.Builder
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "@@") {
+			if name != "" {
+				sections[name] = body.String()
+			}
+			name = strings.TrimPrefix(line, "@@")
+			body.Reset()
+			continue
+		}
+		if name != "" {
+			body.WriteString(line)
+			body.WriteByte('\n')
+		}
+	}
+	if name != "" {
+		sections[name] = body.String()
+	}
+
+	return sections
+}
+
+// parseProcStatCPU computes CPU usage from two "cpu " lines of /proc/stat
+func parseProcStatCPU(output string) (float64, bool) {
+	var samples [][2]float64
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] != "cpu" {
+			continue
+		}
+		user, _ := strconv.ParseFloat(fields[1], 64)
+		nice, _ := strconv.ParseFloat(fields[2], 64)
+		system, _ := strconv.ParseFloat(fields[3], 64)
+		idle, _ := strconv.ParseFloat(fields[4], 64)
+		samples = append(samples, [2]float64{user + nice + system, idle})
+	}
+	if len(samples) < 2 {
+		return 0, false
+	}
+
+	activeDiff := samples[1][0] - samples[0][0]
+	idleDiff := samples[1][1] - samples[0][1]
+	if activeDiff+idleDiff <= 0 {
+		return 0, true
+	}
+	return activeDiff / (activeDiff + idleDiff) * 100, true
+}
+
+// parseTriple reads three unsigned integers, as printed for memory and disk
+func parseTriple(output string) (a, b, c uint64, ok bool) {
+	parts := strings.Fields(strings.TrimSpace(output))
+	if len(parts) < 3 {
+		return 0, 0, 0, false
+	}
+
+	var err error
+	if a, err = strconv.ParseUint(parts[0], 10, 64); err != nil {
+		return 0, 0, 0, false
+	}
+	b, _ = strconv.ParseUint(parts[1], 10, 64)
+	c, _ = strconv.ParseUint(parts[2], 10, 64)
+	return a, b, c, true
+}
+
+// parseRouteDevice returns the dev field of `ip route get` output
+func parseRouteDevice(output string) string {
+	fields := strings.Fields(output)
+	for i := 0; i < len(fields)-1; i++ {
+		if fields[i] == "dev" {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+// parseNetDev returns the byte counters of iface from /proc/net/dev, or of
+// the first ethernet-like interface when iface is empty or not listed
+func parseNetDev(output, iface string) (string, uint64, uint64, bool) {
+	var fallback []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(strings.Replace(line, ":", " ", 1))
+		if len(fields) < 10 {
+			continue
+		}
+		name := fields[0]
+		if iface != "" && name == iface {
+			fallback = fields
+			break
+		}
+		if fallback == nil && (name == "eth0" || strings.HasPrefix(name, "ens") || strings.HasPrefix(name, "enp")) {
+			fallback = fields
+		}
+	}
+	if fallback == nil {
+		return "", 0, 0, false
+	}
+
+	rx, err := strconv.ParseUint(fallback[1], 10, 64)
+	if err != nil {
+		return "", 0, 0, false
+	}
+	tx, _ := strconv.ParseUint(fallback[9], 10, 64)
+	return fallback[0], rx, tx, true
+}
+
+// parseThermalZones reads "zone:type millidegrees" lines
+func parseThermalZones(output string) map[string]float64 {
+	temps := make(map[string]float64)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) != 2 {
+			continue
+		}
+		// Thermal zones report millidegrees
+		milli, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			continue
+		}
+		temps[parts[0]] = milli / 1000
+	}
+	return temps
+}
@@ -2,8 +2,13 @@ package ssh
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -15,19 +20,26 @@ import (
 
 // SSHClient manages SSH connections to a server
 type SSHClient struct {
-	Server     *models.Server
-	client     *ssh.Client
-	mu         sync.Mutex
-	connected  bool
-	lastUsed   time.Time
-	password   string // Decrypted password
-	CurrentDir string // Current working directory
+	Server    *models.Server
+	client    *ssh.Client
+	bastion   *ssh.Client // Jump host connection carrying client, if any
+	mu        sync.Mutex
+	connected bool
+	lastUsed  atomic.Int64 // UnixNano, read by the pool without holding mu
+	sessions  atomic.Int32 // Long-lived sessions open on client, see Acquire
+	password  string       // Decrypted password
+
+	dirs   map[string]string // Working directory per command session
+	dirsMu sync.Mutex
 }
 
-// SSHPool manages a pool of SSH connections
+// SSHPool manages a pool of SSH connections. Past MAX_SSH_CONNECTIONS the
+// least recently used idle clients are closed.
 type SSHPool struct {
-	clients map[uint]*SSHClient
-	mu      sync.RWMutex
+	clients   map[uint]*SSHClient
+	pinned    map[uint]int // Pin count per server, pinned clients are never evicted
+	evictions uint64
+	mu        sync.RWMutex
 }
 
 var Pool *SSHPool
@@ -35,15 +47,22 @@ var Pool *SSHPool
 func InitPool() {
 	Pool = &SSHPool{
 		clients: make(map[uint]*SSHClient),
+		pinned:  make(map[uint]int),
 	}
 }
 
 func (p *SSHPool) GetClient(server *models.Server, password string) (*SSHClient, error) {
+	return p.GetClientContext(context.Background(), server, password)
+}
+
+// GetClientContext is GetClient with a connection attempt that gives up
+// when ctx is done
+func (p *SSHPool) GetClientContext(ctx context.Context, server *models.Server, password string) (*SSHClient, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	if client, exists := p.clients[server.ID]; exists && client.connected {
-		client.lastUsed = time.Now()
+		client.Touch()
 		return client, nil
 	}
 
@@ -52,14 +71,86 @@ func (p *SSHPool) GetClient(server *models.Server, password string) (*SSHClient,
 		password: password,
 	}
 
-	if err := client.Connect(); err != nil {
+	if err := client.ConnectContext(ctx); err != nil {
 		return nil, err
 	}
 
 	p.clients[server.ID] = client
+	p.evict(server.ID)
 	return client, nil
 }
 
+// evict closes least recently used clients until the pool is back within
+// MAX_SSH_CONNECTIONS. keep, pinned clients, clients running a command and
+// clients with an open session are skipped, so the pool stays over the cap
+// while all of them are in use.
+// Callers hold p.mu.
+func (p *SSHPool) evict(keep uint) {
+	max := config.Get().MaxSSHConnections
+	for max > 0 && len(p.clients) > max {
+		id, client := p.leastRecentlyUsed(keep)
+		if client == nil {
+			utils.AppLogger.Warning("SSH pool holds %d connections, over the limit of %d, but none is idle", len(p.clients), max)
+			return
+		}
+
+		idle := time.Since(time.Unix(0, client.lastUsed.Load())).Round(time.Second)
+		client.closeClient()
+		client.mu.Unlock()
+		delete(p.clients, id)
+		p.evictions++
+		utils.AppLogger.Info("Evicted SSH connection to server %d, idle for %v", id, idle)
+	}
+}
+
+// leastRecentlyUsed returns the idle client that was used longest ago, with
+// its lock held so it cannot start a command before it is closed. A client
+// whose lock is taken is busy and skipped, and so is one holding a session.
+// Sessions are acquired under the lock, so checking them after taking it
+// cannot miss one that is opening.
+func (p *SSHPool) leastRecentlyUsed(keep uint) (uint, *SSHClient) {
+	var oldestID uint
+	var oldest *SSHClient
+	for id, client := range p.clients {
+		if id == keep || p.pinned[id] > 0 || !client.mu.TryLock() {
+			continue
+		}
+		if client.sessions.Load() > 0 {
+			client.mu.Unlock()
+			continue
+		}
+		if oldest != nil && client.lastUsed.Load() >= oldest.lastUsed.Load() {
+			client.mu.Unlock()
+			continue
+		}
+		if oldest != nil {
+			oldest.mu.Unlock()
+		}
+		oldestID, oldest = id, client
+	}
+	return oldestID, oldest
+}
+
+// Pin keeps the server's client from being evicted, e.g. while a monitor
+// worker polls it. Each Pin must be matched by an Unpin.
+func (p *SSHPool) Pin(serverID uint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pinned[serverID]++
+}
+
+// Unpin undoes a Pin
+func (p *SSHPool) Unpin(serverID uint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pinned[serverID] <= 1 {
+		delete(p.pinned, serverID)
+		return
+	}
+	p.pinned[serverID]--
+}
+
 // RemoveClient removes a client from the pool
 func (p *SSHPool) RemoveClient(serverID uint) {
 	p.mu.Lock()
@@ -69,6 +160,22 @@ func (p *SSHPool) RemoveClient(serverID uint) {
 		client.Close()
 		delete(p.clients, serverID)
 	}
+	forgetSystemInfo(serverID)
+}
+
+// Count returns the number of pooled connections
+func (p *SSHPool) Count() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return len(p.clients)
+}
+
+// Evictions returns the number of connections closed to stay within
+// MAX_SSH_CONNECTIONS
+func (p *SSHPool) Evictions() uint64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.evictions
 }
 
 // CloseAll closes all connections in the pool
@@ -84,6 +191,12 @@ func (p *SSHPool) CloseAll() {
 
 // Connect establishes SSH connection
 func (c *SSHClient) Connect() error {
+	return c.ConnectContext(context.Background())
+}
+
+// ConnectContext is Connect that gives up when ctx is done, also in the
+// middle of dialing or the handshake
+func (c *SSHClient) ConnectContext(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -91,42 +204,211 @@ func (c *SSHClient) Connect() error {
 		return nil
 	}
 
+	var method string
+	auth, err := c.authMethods(&method)
+	if err != nil {
+		utils.AppLogger.Error("SSH connection failed to %s: %v", c.Server.IPAddress, err)
+		return err
+	}
+
 	sshConfig := &ssh.ClientConfig{
-		User: c.Server.Username,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(c.password),
-		},
+		User:            c.Server.Username,
+		Auth:            auth,
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // TODO: Implement proper host key verification
-		Timeout:         config.AppConfig.SSHTimeout,
+		Timeout:         config.Get().SSHTimeout,
+		Config:          algorithmConfig(c.Server),
 	}
 
-	addr := fmt.Sprintf("%s:%s", c.Server.IPAddress, c.Server.Port)
-	client, err := ssh.Dial("tcp", addr, sshConfig)
+	addr, jump, err := c.route()
 	if err != nil {
+		utils.AppLogger.Error("SSH connection failed to %s: %v", c.Server.IPAddress, err)
+		return err
+	}
+
+	client, bastion, err := c.dial(ctx, addr, jump, sshConfig)
+	if err != nil {
+		err = algorithmError(err)
 		utils.AppLogger.Error("SSH connection failed to %s: %v", addr, err)
 		return fmt.Errorf("ssh dial failed: %w", err)
 	}
 
 	c.client = client
+	c.bastion = bastion
 	c.connected = true
-	c.lastUsed = time.Now()
+	c.Touch()
 
-	utils.AppLogger.Info("SSH connected to %s", addr)
+	utils.AppLogger.Info("SSH connected to %s using %s", addr, method)
 	return nil
 }
 
+// jumpTarget is the bastion a connection goes through
+type jumpTarget struct {
+	host string
+	port string // Empty is 22
+	user string // Empty is the server's Username
+}
+
+// route resolves the address to dial and the jump host, if any. ProxyJump
+// takes precedence over the JumpHost fields, and the server's ssh_config
+// file, when it has one, maps host aliases to real names. Without either
+// the server is dialed directly.
+func (c *SSHClient) route() (string, *jumpTarget, error) {
+	host := c.Server.IPAddress
+	proxyJump := c.Server.ProxyJump
+
+	var hosts *sshConfigFile
+	if c.Server.SSHConfigPath != "" {
+		var err error
+		if hosts, err = loadSSHConfig(c.Server.SSHConfigPath); err != nil {
+			return "", nil, err
+		}
+		options := hosts.lookup(host)
+		if options.HostName != "" {
+			host = options.HostName
+		}
+		if proxyJump == "" && c.Server.JumpHost == "" {
+			proxyJump = options.ProxyJump
+		}
+	}
+	addr := net.JoinHostPort(host, c.Server.Port)
+
+	if proxyJump == "" {
+		if c.Server.JumpHost == "" {
+			return addr, nil, nil
+		}
+		return addr, &jumpTarget{host: c.Server.JumpHost, port: c.Server.JumpPort, user: c.Server.JumpUser}, nil
+	}
+
+	spec, err := models.ParseProxyJump(proxyJump)
+	if err != nil || spec == nil {
+		return addr, nil, err
+	}
+	jump := &jumpTarget{host: spec.Host, port: spec.Port, user: spec.User}
+	if hosts != nil {
+		options := hosts.lookup(spec.Host)
+		if options.HostName != "" {
+			jump.host = options.HostName
+		}
+		if jump.port == "" {
+			jump.port = options.Port
+		}
+		if jump.user == "" {
+			jump.user = options.User
+		}
+	}
+	return addr, jump, nil
+}
+
+// dial connects to addr directly, or through jump when it is set. The
+// bastion client is returned so it can be closed with the target connection.
+func (c *SSHClient) dial(ctx context.Context, addr string, jump *jumpTarget, sshConfig *ssh.ClientConfig) (*ssh.Client, *ssh.Client, error) {
+	if jump == nil {
+		client, err := dialContext(ctx, addr, sshConfig)
+		return client, nil, err
+	}
+
+	jumpConfig := &ssh.ClientConfig{
+		User:            jump.user,
+		Auth:            sshConfig.Auth,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // TODO: Implement proper host key verification
+		Timeout:         sshConfig.Timeout,
+		Config:          sshConfig.Config,
+	}
+	if jumpConfig.User == "" {
+		jumpConfig.User = sshConfig.User
+	}
+	if c.Server.JumpPassword != "" {
+		jumpPassword, err := utils.Decrypt(c.Server.JumpPassword)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decrypt jump host credentials")
+		}
+		jumpConfig.Auth = []ssh.AuthMethod{ssh.Password(jumpPassword)}
+	}
+
+	jumpPort := jump.port
+	if jumpPort == "" {
+		jumpPort = "22"
+	}
+	jumpAddr := net.JoinHostPort(jump.host, jumpPort)
+
+	bastion, err := dialContext(ctx, jumpAddr, jumpConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("jump host %s: %w", jumpAddr, err)
+	}
+
+	conn, err := bastion.Dial("tcp", addr)
+	if err != nil {
+		bastion.Close()
+		return nil, nil, fmt.Errorf("jump host %s could not reach %s: %w", jumpAddr, addr, err)
+	}
+
+	// Channel connections ignore deadlines, so bound the handshake by closing
+	// the tunnel if it takes longer than the dial timeout
+	ctx, cancel := context.WithTimeoutCause(ctx, sshConfig.Timeout, errors.New("handshake timed out"))
+	defer cancel()
+	client, err := handshake(ctx, conn, addr, sshConfig)
+	if err != nil {
+		bastion.Close()
+		return nil, nil, err
+	}
+
+	return client, bastion, nil
+}
+
+// dialContext is ssh.Dial that gives up when ctx is done
+func dialContext(ctx context.Context, addr string, sshConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	dialer := net.Dialer{Timeout: sshConfig.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return handshake(ctx, conn, addr, sshConfig)
+}
+
+// handshake sets up an SSH client on conn, closing conn to abort the
+// handshake once ctx is done
+func handshake(ctx context.Context, conn net.Conn, addr string, sshConfig *ssh.ClientConfig) (*ssh.Client, error) {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, sshConfig)
+	if !stop() {
+		if err == nil {
+			clientConn.Close()
+		}
+		return nil, context.Cause(ctx)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
+
 // Close closes the SSH connection
 func (c *SSHClient) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	return c.closeClient()
+}
+
+// closeClient closes the connection and its bastion. Callers hold c.mu.
+func (c *SSHClient) closeClient() error {
+	var err error
 	if c.client != nil {
-		err := c.client.Close()
+		err = c.client.Close()
 		c.client = nil
-		c.connected = false
-		return err
 	}
-	return nil
+	if c.bastion != nil {
+		c.bastion.Close()
+		c.bastion = nil
+	}
+	c.connected = false
+	return err
+}
+
+// Touch records that the client was just used
+func (c *SSHClient) Touch() {
+	c.lastUsed.Store(time.Now().UnixNano())
 }
 
 // IsConnected checks if the client is connected
@@ -136,59 +418,153 @@ func (c *SSHClient) IsConnected() bool {
 	return c.connected && c.client != nil
 }
 
+// sessionAbortGrace bounds how long a timed out session may take to unwind
+const sessionAbortGrace = 5 * time.Second
+
+// ErrCommandTimeout is returned when a command does not finish within its timeout
+var ErrCommandTimeout = errors.New("command timed out")
+
 // Execute runs a command on the remote server
 func (c *SSHClient) Execute(command string) (string, error) {
+	return c.execute(command, "", 0)
+}
+
+// ExecuteWithTimeout runs a command with a specific timeout. On expiry the
+// session is closed so the command stops holding the client.
+func (c *SSHClient) ExecuteWithTimeout(command string, timeout time.Duration) (string, error) {
+	return c.execute(command, "", timeout)
+}
+
+// ExecuteWithInput runs a command with stdin fed from input
+func (c *SSHClient) ExecuteWithInput(command, input string, timeout time.Duration) (string, error) {
+	return c.execute(command, input, timeout)
+}
+
+// CommandResult is the outcome of a command that ran to completion. When
+// Truncated is set the command was killed for writing more than
+// MAX_COMMAND_OUTPUT, the output is what was kept and ExitCode is -1.
+type CommandResult struct {
+	Stdout    string
+	Stderr    string
+	ExitCode  int
+	Truncated bool
+}
+
+// ExecuteDetailed runs a command like ExecuteWithInput but returns stdout,
+// stderr and the exit code separately. A non-zero exit is not an error; the
+// error is reserved for commands that could not run or timed out. env is
+// added to the server's environment for this command only.
+func (c *SSHClient) ExecuteDetailed(command, input string, env map[string]string, timeout time.Duration) (*CommandResult, error) {
+	return c.run(command, input, env, timeout)
+}
+
+// execute runs command and turns a non-zero exit into an error carrying
+// stderr when there is any. Truncated output is returned along with
+// ErrOutputTruncated.
+func (c *SSHClient) execute(command, input string, timeout time.Duration) (string, error) {
+	result, err := c.run(command, input, nil, timeout)
+	if err != nil {
+		return "", err
+	}
+	if result.Truncated {
+		return result.Stdout, ErrOutputTruncated
+	}
+
+	if result.ExitCode != 0 {
+		if result.Stderr != "" {
+			return "", fmt.Errorf("command failed: %s", result.Stderr)
+		}
+		return "", fmt.Errorf("command failed: Process exited with status %d", result.ExitCode)
+	}
+	return result.Stdout, nil
+}
+
+// run runs command in a new session, aborting it after timeout if timeout > 0.
+// The server's Shell and Env, plus env, are applied to the command.
+func (c *SSHClient) run(command, input string, env map[string]string, timeout time.Duration) (*CommandResult, error) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if !c.connected || c.client == nil {
-		return "", fmt.Errorf("not connected")
+		return nil, fmt.Errorf("not connected")
 	}
 
 	session, err := c.client.NewSession()
 	if err != nil {
 		c.connected = false
-		return "", fmt.Errorf("failed to create session: %w", err)
+		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 	defer session.Close()
 
 	var stdout, stderr bytes.Buffer
-	session.Stdout = &stdout
-	session.Stderr = &stderr
+	limit := newOutputLimit(config.Get().MaxCommandOutput)
+	session.Stdout = limit.writer(&stdout)
+	session.Stderr = limit.writer(&stderr)
+	if input != "" {
+		session.Stdin = strings.NewReader(input)
+	}
 
-	err = session.Run(command)
-	if err != nil {
+	if err := session.Start(wrapCommand(c.Server, command, env)); err != nil {
+		return nil, fmt.Errorf("command failed: %w", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Wait()
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case err = <-done:
+	case <-expired:
+		c.abortSession(session, done)
+		return nil, fmt.Errorf("%w after %v", ErrCommandTimeout, timeout)
+	case <-limit.exceeded:
+		c.abortSession(session, done)
+	}
+
+	result := &CommandResult{Stdout: stdout.String(), Stderr: stderr.String()}
+	if limit.Truncated() {
+		utils.AppLogger.Warning("Command output on server %d exceeded %d bytes, command killed", c.Server.ID, limit.max)
+		result.Truncated = true
+		result.ExitCode = -1
+		c.Touch()
+		return result, nil
+	}
+
+	var exitErr *ssh.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitStatus()
+	case err != nil:
 		if stderr.Len() > 0 {
-			return "", fmt.Errorf("command failed: %s", stderr.String())
+			return nil, fmt.Errorf("command failed: %s", stderr.String())
 		}
-		return "", fmt.Errorf("command failed: %w", err)
+		return nil, fmt.Errorf("command failed: %w", err)
 	}
 
-	c.lastUsed = time.Now()
-	return stdout.String(), nil
+	c.Touch()
+	return result, nil
 }
 
-// ExecuteWithTimeout runs a command with a specific timeout
-func (c *SSHClient) ExecuteWithTimeout(command string, timeout time.Duration) (string, error) {
-	resultCh := make(chan string, 1)
-	errCh := make(chan error, 1)
-
-	go func() {
-		result, err := c.Execute(command)
-		if err != nil {
-			errCh <- err
-			return
-		}
-		resultCh <- result
-	}()
+// abortSession kills the remote command and closes its session so run can
+// release the client lock. If the server never acknowledges the close within
+// sessionAbortGrace the connection is marked as lost instead of blocking.
+func (c *SSHClient) abortSession(session *ssh.Session, done <-chan error) {
+	session.Signal(ssh.SIGKILL)
+	session.Close()
 
 	select {
-	case result := <-resultCh:
-		return result, nil
-	case err := <-errCh:
-		return "", err
-	case <-time.After(timeout):
-		return "", fmt.Errorf("command timeout after %v", timeout)
+	case <-done:
+	case <-time.After(sessionAbortGrace):
+		utils.AppLogger.Warning("SSH session on server %d did not close after timeout, dropping connection", c.Server.ID)
+		c.closeClient()
 	}
 }
 
@@ -198,6 +574,46 @@ func (c *SSHClient) Reconnect() error {
 	return c.Connect()
 }
 
+// Acquire returns the raw SSH client for a session that outlives a single
+// call, such as SFTP, and keeps the pool from evicting it. Each Acquire
+// must be matched by a Release once the session is closed.
+func (c *SSHClient) Acquire() (*ssh.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.connected || c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	c.sessions.Add(1)
+	c.Touch()
+	return c.client, nil
+}
+
+// Release undoes an Acquire
+func (c *SSHClient) Release() {
+	c.Touch()
+	c.sessions.Add(-1)
+}
+
+// newSession opens a session the caller must close and Release. The client
+// lock is only held while the session opens.
+func (c *SSHClient) newSession() (*ssh.Session, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.connected || c.client == nil {
+		return nil, fmt.Errorf("not connected")
+	}
+	session, err := c.client.NewSession()
+	if err != nil {
+		c.connected = false
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	c.sessions.Add(1)
+	c.Touch()
+	return session, nil
+}
+
 // GetUnderlyingClient returns the raw SSH client for advanced operations
 func (c *SSHClient) GetUnderlyingClient() *ssh.Client {
 	c.mu.Lock()
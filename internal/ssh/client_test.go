@@ -0,0 +1,276 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+	"monitoring/internal/ssh/sshtest"
+	"monitoring/internal/utils"
+)
+
+// connectTestClient connects a client to an sshtest server listening on addr
+func connectTestClient(t *testing.T, addr string) *SSHClient {
+	t.Helper()
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	utils.InitLogger(utils.LogError + 1)
+
+	server := sshtest.NewServer(t, addr)
+	client := &SSHClient{
+		Server: &models.Server{
+			IPAddress: server.Host,
+			Port:      server.Port,
+			Username:  sshtest.User,
+		},
+		password: sshtest.Password,
+	}
+	if err := client.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func newTestPool(t *testing.T, max string, idle map[uint]time.Duration) *SSHPool {
+	t.Helper()
+	t.Setenv("MAX_SSH_CONNECTIONS", max)
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	utils.InitLogger(utils.LogError + 1)
+
+	pool := &SSHPool{clients: make(map[uint]*SSHClient), pinned: make(map[uint]int)}
+	for id, d := range idle {
+		client := &SSHClient{connected: true}
+		client.lastUsed.Store(time.Now().Add(-d).UnixNano())
+		pool.clients[id] = client
+	}
+	return pool
+}
+
+func TestEvictLeastRecentlyUsed(t *testing.T) {
+	pool := newTestPool(t, "2", map[uint]time.Duration{
+		1: time.Minute,
+		2: time.Hour,
+		3: time.Second,
+		4: 2 * time.Hour,
+	})
+	pool.pinned[4] = 1
+
+	pool.evict(3)
+
+	if _, ok := pool.clients[2]; ok {
+		t.Error("client 2, the least recently used unpinned one, was kept")
+	}
+	for _, id := range []uint{3, 4} {
+		if _, ok := pool.clients[id]; !ok {
+			t.Errorf("client %d was evicted", id)
+		}
+	}
+	if len(pool.clients) != 2 || pool.Evictions() != 2 {
+		t.Errorf("pool holds %d clients after %d evictions, want 2 and 2", len(pool.clients), pool.Evictions())
+	}
+}
+
+func TestEvictWhileClientsAreUsed(t *testing.T) {
+	idle := make(map[uint]time.Duration)
+	for id := uint(1); id <= 50; id++ {
+		idle[id] = time.Duration(id) * time.Second
+	}
+	pool := newTestPool(t, "10", idle)
+
+	clients := make([]*SSHClient, 0, len(pool.clients))
+	for _, client := range pool.clients {
+		clients = append(clients, client)
+	}
+
+	// Run updates lastUsed under the client lock while evict reads it
+	// under the pool lock; go test -race reports any unsynchronized access
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func(client *SSHClient) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					client.Touch()
+				}
+			}
+		}(client)
+	}
+
+	pool.mu.Lock()
+	pool.evict(0)
+	pool.mu.Unlock()
+	close(stop)
+	wg.Wait()
+
+	if len(pool.clients) != 10 {
+		t.Errorf("pool holds %d clients, want 10", len(pool.clients))
+	}
+}
+
+func TestTimedOutCommandReleasesClient(t *testing.T) {
+	client := connectTestClient(t, "127.0.0.1:0")
+
+	start := time.Now()
+	_, err := client.ExecuteWithTimeout("sleep 60", time.Second)
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("sleep 60 error = %v, want ErrCommandTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("timed out command returned after %v", elapsed)
+	}
+
+	start = time.Now()
+	output, err := client.ExecuteWithTimeout("echo ok", 5*time.Second)
+	if err != nil || strings.TrimSpace(output) != "ok" {
+		t.Fatalf("follow-up command = %q, %v", output, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("follow-up command took %v", elapsed)
+	}
+}
+
+func TestConnectIPv6Loopback(t *testing.T) {
+	client := connectTestClient(t, "[::1]:0")
+
+	if client.Server.IPAddress != "::1" {
+		t.Fatalf("server address = %q, want ::1", client.Server.IPAddress)
+	}
+	output, err := client.Execute("echo ok")
+	if err != nil || strings.TrimSpace(output) != "ok" {
+		t.Fatalf("command over IPv6 = %q, %v", output, err)
+	}
+}
+
+func TestRouteBracketsIPv6(t *testing.T) {
+	tests := []struct {
+		host, port string
+		want       string
+	}{
+		{"2001:db8::1", "2222", "[2001:db8::1]:2222"},
+		{"::1", "22", "[::1]:22"},
+		{"10.0.0.1", "22", "10.0.0.1:22"},
+		{"web.example.com", "2200", "web.example.com:2200"},
+	}
+
+	for _, tt := range tests {
+		client := &SSHClient{Server: &models.Server{IPAddress: tt.host, Port: tt.port}}
+		addr, jump, err := client.route()
+		if err != nil || jump != nil || addr != tt.want {
+			t.Errorf("route(%s, %s) = %q, %v, %v; want %q", tt.host, tt.port, addr, jump, err, tt.want)
+		}
+	}
+}
+
+func TestCommandOutputLimit(t *testing.T) {
+	t.Setenv("MAX_COMMAND_OUTPUT", "4096")
+	client := connectTestClient(t, "127.0.0.1:0")
+
+	tests := []struct {
+		name      string
+		command   string
+		truncated bool
+	}{
+		{"endless stdout", "yes", true},
+		{"endless stderr", "yes >&2", true},
+		{"one byte over", "head -c 4097 /dev/zero", true},
+		{"exactly at limit", "head -c 4096 /dev/zero", false},
+		{"split at limit", "head -c 2048 /dev/zero; head -c 2048 /dev/zero >&2", false},
+	}
+
+	for _, tt := range tests {
+		start := time.Now()
+		result, err := client.ExecuteDetailed(tt.command, "", nil, 10*time.Second)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: took %v, the command was not killed", tt.name, elapsed)
+		}
+
+		kept := len(result.Stdout) + len(result.Stderr)
+		if result.Truncated != tt.truncated || kept > 4096 {
+			t.Errorf("%s: truncated = %v with %d bytes kept, want truncated %v and at most 4096", tt.name, result.Truncated, kept, tt.truncated)
+		}
+		if tt.truncated && result.ExitCode != -1 {
+			t.Errorf("%s: exit code %d, want -1", tt.name, result.ExitCode)
+		}
+		if !tt.truncated && kept != 4096 {
+			t.Errorf("%s: kept %d bytes, want 4096", tt.name, kept)
+		}
+	}
+
+	if _, err := client.Execute("yes"); !errors.Is(err, ErrOutputTruncated) {
+		t.Errorf("Execute(yes) error = %v, want ErrOutputTruncated", err)
+	}
+}
+
+func TestEvictSkipsOpenSessions(t *testing.T) {
+	tests := []struct {
+		name string
+		open func(t *testing.T, client *SSHClient) (close func())
+	}{
+		{"shell", func(t *testing.T, client *SSHClient) func() {
+			shell, err := client.OpenShell("", 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return func() { shell.Close() }
+		}},
+		{"stream", func(t *testing.T, client *SSHClient) func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			started := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				client.ExecuteStream(ctx, "echo started; sleep 60", func(string) { close(started) })
+			}()
+			<-started
+			return func() {
+				cancel()
+				<-done
+			}
+		}},
+		{"acquired", func(t *testing.T, client *SSHClient) func() {
+			if _, err := client.Acquire(); err != nil {
+				t.Fatal(err)
+			}
+			return client.Release
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newTestPool(t, "1", map[uint]time.Duration{2: time.Second})
+			client := connectTestClient(t, "127.0.0.1:0")
+			pool.clients[1] = client
+
+			closeSession := tt.open(t, client)
+			client.lastUsed.Store(time.Now().Add(-time.Hour).UnixNano())
+			pool.evict(2)
+			if _, ok := pool.clients[1]; !ok || !client.IsConnected() {
+				t.Fatal("client with an open session was evicted")
+			}
+
+			closeSession()
+			pool.evict(2)
+			if _, ok := pool.clients[1]; ok {
+				t.Error("client was kept after its session closed")
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package ssh
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+)
+
+// CollectCustom runs the server's custom metric commands and returns their
+// parsed values by name. Each command is cut off after its timeout so a hung
+// one cannot stall the tick; failed metrics are left out.
+func (m *MetricCollector) CollectCustom(metrics []models.CustomMetric) map[string]interface{} {
+	values := make(map[string]interface{}, len(metrics))
+
+	for _, metric := range metrics {
+		timeout := config.Get().CustomMetricTimeout
+		if metric.Timeout > 0 && time.Duration(metric.Timeout)*time.Second < timeout {
+			timeout = time.Duration(metric.Timeout) * time.Second
+		}
+
+		output, err := m.client.ExecuteWithTimeout(metric.Command, timeout)
+		if err != nil {
+			m.logger.Warning("Custom metric %s failed: %v", metric.Name, err)
+			continue
+		}
+
+		value, err := parseCustomValue(output, metric.ParseType)
+		if err != nil {
+			m.logger.Warning("Custom metric %s returned a non-numeric value: %q", metric.Name, strings.TrimSpace(output))
+			continue
+		}
+		values[metric.Name] = value
+	}
+
+	return values
+}
+
+// parseCustomValue reads a number from the first field of the output, or
+// returns the trimmed output for string metrics
+func parseCustomValue(output string, parseType models.CustomParseType) (interface{}, error) {
+	output = strings.TrimSpace(output)
+	if parseType == models.CustomParseString {
+		return output, nil
+	}
+
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return nil, strconv.ErrSyntax
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
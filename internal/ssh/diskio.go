@@ -0,0 +1,173 @@
+package ssh
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"monitoring/internal/models"
+)
+
+// diskDevicePattern matches whole block devices, leaving out partitions,
+// loop and ram devices
+var diskDevicePattern = regexp.MustCompile(`^(sd[a-z]+|vd[a-z]+|xvd[a-z]+|hd[a-z]+|nvme\d+n\d+|mmcblk\d+|dm-\d+|md\d+)$`)
+
+// pseudoFilesystems are skipped in inode usage
+var pseudoFilesystems = map[string]bool{
+	"tmpfs":    true,
+	"devtmpfs": true,
+	"udev":     true,
+	"overlay":  true,
+	"shm":      true,
+	"none":     true,
+}
+
+// CollectDiskIO returns per-device throughput and utilization from iostat,
+// or from two /proc/diskstats samples a second apart when iostat is missing
+func (m *MetricCollector) CollectDiskIO() ([]models.DiskIOStat, error) {
+	if _, err := m.client.Execute("command -v iostat"); err == nil {
+		output, err := m.client.Execute("iostat -dxk 1 2")
+		if err == nil {
+			if stats := parseIostat(output); len(stats) > 0 {
+				return stats, nil
+			}
+		}
+	}
+
+	output, err := m.client.Execute("cat /proc/diskstats; echo @@; sleep 1; cat /proc/diskstats")
+	if err != nil {
+		return []models.DiskIOStat{}, err
+	}
+	before, after, _ := strings.Cut(output, "@@\n")
+	return diskIOFromDiskstats(before, after, time.Second), nil
+}
+
+// CollectInodes returns inode usage per mounted filesystem
+func (m *MetricCollector) CollectInodes() ([]models.InodeStat, error) {
+	output, err := m.client.Execute("df -iP")
+	if err != nil {
+		return []models.InodeStat{}, err
+	}
+	return parseDfInodes(output), nil
+}
+
+// diskCounters holds the /proc/diskstats fields used for rates
+type diskCounters struct {
+	sectorsRead    uint64
+	sectorsWritten uint64
+	ioMillis       uint64
+}
+
+func parseDiskstats(output string) map[string]diskCounters {
+	counters := make(map[string]diskCounters)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 13 || !diskDevicePattern.MatchString(fields[2]) {
+			continue
+		}
+		read, _ := strconv.ParseUint(fields[5], 10, 64)
+		written, _ := strconv.ParseUint(fields[9], 10, 64)
+		ioMillis, _ := strconv.ParseUint(fields[12], 10, 64)
+		counters[fields[2]] = diskCounters{read, written, ioMillis}
+	}
+	return counters
+}
+
+// diskIOFromDiskstats derives rates from two samples taken interval apart.
+// Sectors are always 512 bytes in /proc/diskstats.
+func diskIOFromDiskstats(before, after string, interval time.Duration) []models.DiskIOStat {
+	first := parseDiskstats(before)
+	stats := []models.DiskIOStat{}
+
+	seconds := interval.Seconds()
+	for device, end := range parseDiskstats(after) {
+		start, ok := first[device]
+		if !ok || end.sectorsRead < start.sectorsRead || end.sectorsWritten < start.sectorsWritten || end.ioMillis < start.ioMillis {
+			continue
+		}
+
+		util := float64(end.ioMillis-start.ioMillis) / (seconds * 1000) * 100
+		if util > 100 {
+			util = 100
+		}
+		stats = append(stats, models.DiskIOStat{
+			Device:      device,
+			ReadRate:    float64(end.sectorsRead-start.sectorsRead) * 512 / seconds,
+			WriteRate:   float64(end.sectorsWritten-start.sectorsWritten) * 512 / seconds,
+			Utilization: util,
+		})
+	}
+	return stats
+}
+
+// parseIostat reads the last report of `iostat -dxk`, locating columns by
+// header name since their order differs between sysstat versions
+func parseIostat(output string) []models.DiskIOStat {
+	var stats []models.DiskIOStat
+	columns := map[string]int{}
+
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if strings.TrimSuffix(fields[0], ":") == "Device" {
+			// A new report starts, only the last one covers the interval
+			stats = []models.DiskIOStat{}
+			columns = map[string]int{}
+			for i, name := range fields {
+				columns[name] = i
+			}
+			continue
+		}
+
+		readCol, okRead := columns["rkB/s"]
+		writeCol, okWrite := columns["wkB/s"]
+		utilCol, okUtil := columns["%util"]
+		if !okRead || !okWrite || !okUtil || len(fields) != len(columns) || !diskDevicePattern.MatchString(fields[0]) {
+			continue
+		}
+
+		read, _ := strconv.ParseFloat(fields[readCol], 64)
+		write, _ := strconv.ParseFloat(fields[writeCol], 64)
+		util, _ := strconv.ParseFloat(fields[utilCol], 64)
+		stats = append(stats, models.DiskIOStat{
+			Device:      fields[0],
+			ReadRate:    read * 1024,
+			WriteRate:   write * 1024,
+			Utilization: util,
+		})
+	}
+	return stats
+}
+
+// parseDfInodes parses `df -iP`. Filesystems without inode accounting
+// (Inodes 0, e.g. btrfs) and pseudo filesystems are skipped.
+func parseDfInodes(output string) []models.InodeStat {
+	stats := []models.InodeStat{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] == "Filesystem" || pseudoFilesystems[fields[0]] {
+			continue
+		}
+
+		total, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil || total == 0 {
+			continue
+		}
+		used, _ := strconv.ParseUint(fields[2], 10, 64)
+		free, _ := strconv.ParseUint(fields[3], 10, 64)
+
+		stats = append(stats, models.InodeStat{
+			Filesystem: fields[0],
+			Mount:      strings.Join(fields[5:], " "),
+			Total:      total,
+			Used:       used,
+			Free:       free,
+			Percent:    float64(used) / float64(total) * 100,
+		})
+	}
+	return stats
+}
@@ -0,0 +1,106 @@
+package ssh
+
+import (
+	"strconv"
+	"strings"
+
+	"monitoring/internal/models"
+)
+
+// sizeUnits maps the suffixes printed by docker stats to bytes
+var sizeUnits = []struct {
+	suffix string
+	factor float64
+}{
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"TiB", 1 << 40},
+	{"kB", 1e3},
+	{"KB", 1e3},
+	{"MB", 1e6},
+	{"GB", 1e9},
+	{"TB", 1e12},
+	{"B", 1},
+}
+
+// CollectDockerContainers lists containers with their CPU and memory usage.
+// The second return value is false when Docker is not installed.
+func (m *MetricCollector) CollectDockerContainers() ([]models.ContainerStat, bool, error) {
+	if _, err := m.client.Execute("command -v docker"); err != nil {
+		return []models.ContainerStat{}, false, nil
+	}
+
+	output, err := m.client.Execute(`docker ps -a --format '{{.ID}}|{{.Names}}|{{.Image}}|{{.State}}|{{.Status}}'`)
+	if err != nil {
+		return nil, true, err
+	}
+
+	containers := []models.ContainerStat{}
+	index := make(map[string]int)
+
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "|")
+		if len(parts) < 5 {
+			continue
+		}
+		index[parts[0]] = len(containers)
+		containers = append(containers, models.ContainerStat{
+			ID:      parts[0],
+			Name:    parts[1],
+			Image:   parts[2],
+			Running: parts[3] == "running",
+			Status:  parts[4],
+		})
+	}
+
+	output, err = m.client.Execute(`docker stats --no-stream --format '{{.ID}}|{{.CPUPerc}}|{{.MemUsage}}|{{.MemPerc}}'`)
+	if err != nil {
+		m.logger.Warning("Failed to collect docker stats: %v", err)
+		return containers, true, nil
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		parts := strings.Split(line, "|")
+		if len(parts) < 4 {
+			continue
+		}
+		i, ok := index[parts[0]]
+		if !ok {
+			continue
+		}
+
+		containers[i].CPUPercent = parsePercent(parts[1])
+		containers[i].MemPercent = parsePercent(parts[3])
+
+		// MemUsage looks like "12.5MiB / 1.944GiB"
+		if usage := strings.Split(parts[2], "/"); len(usage) == 2 {
+			containers[i].MemUsage = parseSize(usage[0])
+			containers[i].MemLimit = parseSize(usage[1])
+		}
+	}
+
+	return containers, true, nil
+}
+
+// parsePercent converts "12.34%" to 12.34
+func parsePercent(s string) float64 {
+	v, _ := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	return v
+}
+
+// parseSize converts a human readable size such as "1.5GiB" to bytes
+func parseSize(s string) uint64 {
+	s = strings.TrimSpace(s)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			v, err := strconv.ParseFloat(strings.TrimSuffix(s, unit.suffix), 64)
+			if err != nil {
+				return 0
+			}
+			return uint64(v * unit.factor)
+		}
+	}
+	v, _ := strconv.ParseFloat(s, 64)
+	return uint64(v)
+}
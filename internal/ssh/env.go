@@ -0,0 +1,43 @@
+package ssh
+
+import (
+	"sort"
+	"strings"
+
+	"monitoring/internal/models"
+)
+
+// wrapCommand applies the server's Shell and the merged environment to a
+// command. Variables in env override the server's Env. Values are quoted,
+// names must pass models.ValidateEnv. With nothing configured the command
+// is returned unchanged.
+func wrapCommand(server *models.Server, command string, env map[string]string) string {
+	merged := make(map[string]string, len(server.Env)+len(env))
+	for name, value := range server.Env {
+		merged[name] = value
+	}
+	for name, value := range env {
+		merged[name] = value
+	}
+
+	if server.Shell != "" {
+		command = server.Shell + " " + ShellQuote(command)
+	}
+	if len(merged) == 0 {
+		return command
+	}
+
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("env")
+	for _, name := range names {
+		b.WriteString(" " + name + "=" + ShellQuote(merged[name]))
+	}
+	b.WriteString(" " + command)
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package ssh
+
+import (
+	"strconv"
+	"strings"
+
+	"monitoring/internal/models"
+)
+
+const gpuQuery = "nvidia-smi --query-gpu=index,name,utilization.gpu,memory.used,memory.total,temperature.gpu --format=csv,noheader,nounits"
+
+// CollectGPU returns per-GPU utilization from nvidia-smi. Hosts without
+// nvidia-smi get an empty slice instead of an error.
+func (m *MetricCollector) CollectGPU() ([]models.GPUStat, error) {
+	if _, err := m.client.Execute("command -v nvidia-smi"); err != nil {
+		return []models.GPUStat{}, nil
+	}
+
+	output, err := m.client.Execute(gpuQuery)
+	if err != nil {
+		return []models.GPUStat{}, err
+	}
+
+	return parseGPUOutput(output), nil
+}
+
+// parseGPUOutput parses nvidia-smi CSV rows. Fields the driver does not
+// support are reported as "[N/A]" and left at zero.
+func parseGPUOutput(output string) []models.GPUStat {
+	gpus := []models.GPUStat{}
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) != 6 {
+			continue
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+
+		gpu := models.GPUStat{
+			Index: index,
+			Name:  fields[1],
+		}
+		gpu.Utilization, _ = strconv.ParseFloat(fields[2], 64)
+		gpu.MemUsed, _ = strconv.ParseUint(fields[3], 10, 64)
+		gpu.MemTotal, _ = strconv.ParseUint(fields[4], 10, 64)
+		gpu.Temperature, _ = strconv.ParseFloat(fields[5], 64)
+
+		gpus = append(gpus, gpu)
+	}
+	return gpus
+}
@@ -1,11 +1,13 @@
 package ssh
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"monitoring/config"
 	"monitoring/internal/models"
 	"monitoring/internal/utils"
 )
@@ -24,7 +26,10 @@ func NewMetricCollector(client *SSHClient) *MetricCollector {
 	}
 }
 
-// CollectAll collects all metrics from the server
+// CollectAll collects all metrics from the server. The base metrics come
+// from one batched session; any section the batch could not produce is
+// collected on its own so one bad source does not void the snapshot.
+// Collectors with an overridden command ignore their batch section.
 func (m *MetricCollector) CollectAll() (*models.MetricSnapshot, error) {
 	snapshot := &models.MetricSnapshot{
 		ServerID:   m.client.Server.ID,
@@ -32,19 +37,30 @@ func (m *MetricCollector) CollectAll() (*models.MetricSnapshot, error) {
 		Timestamp:  time.Now().Unix(),
 	}
 
-	// Collect CPU usage
-	cpu, err := m.CollectCPU()
+	output, err := m.client.ExecuteWithTimeout(batchScript, batchTimeout)
 	if err != nil {
+		m.logger.Warning("Batch collection failed, collecting individually: %v", err)
+	}
+	sections := batchSections(output)
+
+	// Collect CPU usage
+	if cpu, ok := parseProcStatCPU(sections["stat1"] + sections["stat2"]); ok && m.override(models.MetricCommandCPU) == "" {
+		snapshot.CPUUsage = cpu
+	} else if cpu, err := m.CollectCPU(); err != nil {
 		m.logger.Warning("Failed to collect CPU: %v", err)
 	} else {
 		snapshot.CPUUsage = cpu
 	}
 
 	// Collect memory
-	memTotal, memUsed, memFree, err := m.CollectMemory()
-	if err != nil {
-		m.logger.Warning("Failed to collect memory: %v", err)
-	} else {
+	memTotal, memUsed, memFree, ok := parseTriple(sections["mem"])
+	if !ok || m.override(models.MetricCommandMemory) != "" {
+		memTotal, memUsed, memFree, err = m.CollectMemory()
+		if ok = err == nil; !ok {
+			m.logger.Warning("Failed to collect memory: %v", err)
+		}
+	}
+	if ok {
 		snapshot.MemTotal = memTotal
 		snapshot.MemUsed = memUsed
 		snapshot.MemFree = memFree
@@ -54,10 +70,14 @@ func (m *MetricCollector) CollectAll() (*models.MetricSnapshot, error) {
 	}
 
 	// Collect disk
-	diskTotal, diskUsed, diskFree, err := m.CollectDisk()
-	if err != nil {
-		m.logger.Warning("Failed to collect disk: %v", err)
-	} else {
+	diskTotal, diskUsed, diskFree, ok := parseTriple(sections["disk"])
+	if !ok || m.override(models.MetricCommandDisk) != "" {
+		diskTotal, diskUsed, diskFree, err = m.CollectDisk()
+		if ok = err == nil; !ok {
+			m.logger.Warning("Failed to collect disk: %v", err)
+		}
+	}
+	if ok {
 		snapshot.DiskTotal = diskTotal
 		snapshot.DiskUsed = diskUsed
 		snapshot.DiskFree = diskFree
@@ -67,26 +87,115 @@ func (m *MetricCollector) CollectAll() (*models.MetricSnapshot, error) {
 	}
 
 	// Collect network
-	rx, tx, err := m.CollectNetwork()
-	if err != nil {
-		m.logger.Warning("Failed to collect network: %v", err)
-	} else {
-		snapshot.NetRX = rx
-		snapshot.NetTX = tx
+	iface, rxBytes, txBytes, ok := m.networkFromBatch(sections)
+	if !ok {
+		iface, rxBytes, txBytes, err = m.CollectNetworkBytes()
+		if ok = err == nil; !ok {
+			m.logger.Warning("Failed to collect network: %v", err)
+		}
+	}
+	if ok {
+		snapshot.NetInterface = iface
+		snapshot.NetRXBytes = rxBytes
+		snapshot.NetTXBytes = txBytes
+		snapshot.NetRX = rxBytes / (1024 * 1024)
+		snapshot.NetTX = txBytes / (1024 * 1024)
 	}
 
 	// Collect uptime
-	uptime, err := m.CollectUptime()
-	if err != nil {
+	if uptime, ok := parseUptime(sections["uptime"]); ok && m.override(models.MetricCommandUptime) == "" {
+		snapshot.Uptime = uptime
+	} else if uptime, err := m.CollectUptime(); err != nil {
 		m.logger.Warning("Failed to collect uptime: %v", err)
 	} else {
 		snapshot.Uptime = uptime
 	}
 
+	// Collect temperatures
+	if _, batched := sections["thermal"]; batched {
+		snapshot.Temperatures = parseSensorsOutput(sections["sensors"])
+		if len(snapshot.Temperatures) == 0 {
+			snapshot.Temperatures = parseThermalZones(sections["thermal"])
+		}
+	} else if temps, err := m.CollectTemperatures(); err != nil {
+		m.logger.Warning("Failed to collect temperatures: %v", err)
+	} else {
+		snapshot.Temperatures = temps
+	}
+
+	// Collect disk I/O
+	if _, batched := sections["stat2"]; batched {
+		snapshot.DiskIO = diskIOFromDiskstats(sections["stat1"], sections["stat2"], batchSampleInterval)
+	} else if diskIO, err := m.CollectDiskIO(); err != nil {
+		m.logger.Warning("Failed to collect disk I/O: %v", err)
+	} else {
+		snapshot.DiskIO = diskIO
+	}
+
+	// Collect inodes
+	if _, batched := sections["inodes"]; batched {
+		snapshot.Inodes = parseDfInodes(sections["inodes"])
+	} else if inodes, err := m.CollectInodes(); err != nil {
+		m.logger.Warning("Failed to collect inodes: %v", err)
+	} else {
+		snapshot.Inodes = inodes
+	}
+
+	// Collect GPUs
+	if gpuOutput, batched := sections["gpu"]; batched {
+		snapshot.GPUs = []models.GPUStat{}
+		if rows, present := strings.CutPrefix(gpuOutput, "present\n"); present {
+			snapshot.GPUs = parseGPUOutput(rows)
+		}
+	} else if gpus, err := m.CollectGPU(); err != nil {
+		m.logger.Warning("Failed to collect GPUs: %v", err)
+	} else {
+		snapshot.GPUs = gpus
+	}
+
 	return snapshot, nil
 }
 
+// networkFromBatch picks the configured or default route interface from the
+// batch's /proc/net/dev section
+func (m *MetricCollector) networkFromBatch(sections map[string]string) (string, uint64, uint64, bool) {
+	iface := m.client.Server.NetInterface
+	if iface == "" {
+		iface = parseRouteDevice(sections["route"])
+	}
+	return parseNetDev(sections["net"], iface)
+}
+
+// override returns the command configured for a collector, from the server
+// or else from METRIC_CMD_*, or "" to use the built-in Linux command
+func (m *MetricCollector) override(name string) string {
+	if command := m.client.Server.MetricCommands[name]; command != "" {
+		return command
+	}
+	return config.Get().MetricCommands[name]
+}
+
+// runOverride runs a collector's overridden command. Its output is parsed
+// loosely: the leading numbers are used and anything after them ignored.
+func (m *MetricCollector) runOverride(name, command string) ([]string, error) {
+	output, err := m.client.ExecuteWithTimeout(command, batchTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%s command: %w", name, err)
+	}
+	return strings.Fields(output), nil
+}
+
 func (m *MetricCollector) CollectCPU() (float64, error) {
+	if command := m.override(models.MetricCommandCPU); command != "" {
+		fields, err := m.runOverride(models.MetricCommandCPU, command)
+		if err != nil {
+			return 0, err
+		}
+		if len(fields) == 0 {
+			return 0, fmt.Errorf("cpu command printed no usage")
+		}
+		return strconv.ParseFloat(strings.TrimSuffix(fields[0], "%"), 64)
+	}
 
 	cmd := `top -bn2 -d0.5 | grep "Cpu(s)" | tail -1 | awk '{print $2}' | cut -d'%' -f1`
 	output, err := m.client.Execute(cmd)
@@ -146,6 +255,10 @@ func (m *MetricCollector) collectCPUFromProc() (float64, error) {
 
 // CollectMemory collects memory usage in MB
 func (m *MetricCollector) CollectMemory() (total, used, free uint64, err error) {
+	if command := m.override(models.MetricCommandMemory); command != "" {
+		return m.collectTriple(models.MetricCommandMemory, command)
+	}
+
 	cmd := `free -m | grep Mem | awk '{print $2, $3, $4}'`
 	output, err := m.client.Execute(cmd)
 	if err != nil {
@@ -166,6 +279,10 @@ func (m *MetricCollector) CollectMemory() (total, used, free uint64, err error)
 
 // CollectDisk collects disk usage in GB (root partition)
 func (m *MetricCollector) CollectDisk() (total, used, free uint64, err error) {
+	if command := m.override(models.MetricCommandDisk); command != "" {
+		return m.collectTriple(models.MetricCommandDisk, command)
+	}
+
 	cmd := `df -BG / | tail -1 | awk '{gsub("G",""); print $2, $3, $4}'`
 	output, err := m.client.Execute(cmd)
 	if err != nil {
@@ -184,41 +301,111 @@ func (m *MetricCollector) CollectDisk() (total, used, free uint64, err error) {
 	return total, used, free, nil
 }
 
+// collectTriple runs an overridden command that prints total, used and free
+func (m *MetricCollector) collectTriple(name, command string) (total, used, free uint64, err error) {
+	fields, err := m.runOverride(name, command)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	total, used, free, ok := parseTriple(strings.Join(fields, " "))
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("%s command must print total, used and free", name)
+	}
+	return total, used, free, nil
+}
+
 // CollectNetwork collects network traffic in MB
 func (m *MetricCollector) CollectNetwork() (rx, tx uint64, err error) {
-	// Get the primary interface and its traffic
-	cmd := `cat /proc/net/dev | grep -E '(eth0|ens|enp)' | head -1 | awk '{print $2, $10}'`
-	output, err := m.client.Execute(cmd)
+	_, rxBytes, txBytes, err := m.CollectNetworkBytes()
 	if err != nil {
 		return 0, 0, err
 	}
 
+	// Convert bytes to MB
+	rx = rxBytes / (1024 * 1024)
+	tx = txBytes / (1024 * 1024)
+
+	return rx, tx, nil
+}
+
+var interfaceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.:@-]+$`)
+
+// CollectNetworkBytes collects the cumulative byte counters of the server's
+// configured interface, or of the default route's interface when unset
+func (m *MetricCollector) CollectNetworkBytes() (iface string, rx, tx uint64, err error) {
+	iface = m.client.Server.NetInterface
+	if iface == "" {
+		iface = m.detectDefaultInterface()
+	}
+
+	if iface != "" && !interfaceNamePattern.MatchString(iface) {
+		m.logger.Warning("Ignoring invalid network interface %q", iface)
+		iface = ""
+	}
+
+	var output string
+	if iface != "" {
+		cmd := `awk '{gsub(":", " ")} $1 == "` + iface + `" {print $1, $2, $10}' /proc/net/dev`
+		output, err = m.client.Execute(cmd)
+		if err != nil {
+			return "", 0, 0, err
+		}
+	}
+
 	parts := strings.Fields(strings.TrimSpace(output))
-	if len(parts) < 2 {
-		// Try alternative approach
-		cmd = `ip -s link show | grep -A1 'RX:' | tail -1 | awk '{print $1}' && ip -s link show | grep -A1 'TX:' | tail -1 | awk '{print $1}'`
+	if len(parts) < 3 {
+		// Fall back to the first ethernet-like interface
+		cmd := `cat /proc/net/dev | grep -E '(eth0|ens|enp)' | head -1 | awk '{gsub(":", " "); print $1, $2, $10}'`
 		output, err = m.client.Execute(cmd)
 		if err != nil {
-			return 0, 0, err
+			return "", 0, 0, err
 		}
 		parts = strings.Fields(strings.TrimSpace(output))
-		if len(parts) < 2 {
-			return 0, 0, nil
+	}
+
+	if len(parts) < 3 {
+		// Try alternative approach
+		cmd := `ip -s link show | grep -A1 'RX:' | tail -1 | awk '{print $1}' && ip -s link show | grep -A1 'TX:' | tail -1 | awk '{print $1}'`
+		output, err = m.client.Execute(cmd)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		parts = append([]string{""}, strings.Fields(strings.TrimSpace(output))...)
+		if len(parts) < 3 {
+			return "", 0, 0, nil
 		}
 	}
 
-	rxBytes, _ := strconv.ParseUint(parts[0], 10, 64)
-	txBytes, _ := strconv.ParseUint(parts[1], 10, 64)
+	iface = parts[0]
+	rx, _ = strconv.ParseUint(parts[1], 10, 64)
+	tx, _ = strconv.ParseUint(parts[2], 10, 64)
 
-	// Convert bytes to MB
-	rx = rxBytes / (1024 * 1024)
-	tx = txBytes / (1024 * 1024)
+	return iface, rx, tx, nil
+}
 
-	return rx, tx, nil
+// detectDefaultInterface returns the interface used by the default route
+func (m *MetricCollector) detectDefaultInterface() string {
+	output, err := m.client.Execute("ip route get 1.1.1.1")
+	if err != nil {
+		return ""
+	}
+	return parseRouteDevice(output)
 }
 
 // CollectUptime collects system uptime in seconds
 func (m *MetricCollector) CollectUptime() (uint64, error) {
+	if command := m.override(models.MetricCommandUptime); command != "" {
+		fields, err := m.runOverride(models.MetricCommandUptime, command)
+		if err != nil {
+			return 0, err
+		}
+		uptime, ok := parseUptime(strings.Join(fields, " "))
+		if !ok {
+			return 0, fmt.Errorf("uptime command must print seconds")
+		}
+		return uptime, nil
+	}
+
 	cmd := `cat /proc/uptime | awk '{print int($1)}'`
 	output, err := m.client.Execute(cmd)
 	if err != nil {
@@ -233,6 +420,19 @@ func (m *MetricCollector) CollectUptime() (uint64, error) {
 	return uptime, nil
 }
 
+// parseUptime reads the whole seconds of /proc/uptime
+func parseUptime(output string) (uint64, bool) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint64(seconds), true
+}
+
 // CollectProcesses collects running processes count
 func (m *MetricCollector) CollectProcesses() (int, error) {
 	cmd := `ps aux | wc -l`
@@ -0,0 +1,69 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrOutputTruncated is returned with the partial output of a command that
+// wrote more than MAX_COMMAND_OUTPUT and was killed
+var ErrOutputTruncated = errors.New("command output exceeded the size limit and was truncated")
+
+// outputLimit caps the bytes a command's stdout and stderr keep between them.
+// Once the cap is hit exceeded is closed and everything after it is dropped,
+// so the buffers no longer change and can be read while the session unwinds.
+type outputLimit struct {
+	mu        sync.Mutex
+	max       int64 // 0 is unlimited
+	written   int64
+	truncated bool
+	exceeded  chan struct{}
+}
+
+func newOutputLimit(max int64) *outputLimit {
+	return &outputLimit{max: max, exceeded: make(chan struct{})}
+}
+
+// writer returns a writer that fills buf within the limit
+func (l *outputLimit) writer(buf *bytes.Buffer) io.Writer {
+	return &limitedWriter{limit: l, buf: buf}
+}
+
+// Truncated reports whether output was dropped
+func (l *outputLimit) Truncated() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.truncated
+}
+
+type limitedWriter struct {
+	limit *outputLimit
+	buf   *bytes.Buffer
+}
+
+// Write never fails so the session keeps draining its channels until it is
+// killed; bytes past the limit are discarded
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	l := w.limit
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.truncated {
+		return len(p), nil
+	}
+	if l.max <= 0 {
+		return w.buf.Write(p)
+	}
+
+	keep := p
+	if room := l.max - l.written; int64(len(p)) > room {
+		keep = p[:room]
+		l.truncated = true
+		close(l.exceeded)
+	}
+	w.buf.Write(keep)
+	l.written += int64(len(keep))
+	return len(p), nil
+}
@@ -0,0 +1,71 @@
+package ssh
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+)
+
+// PingResult reports how a server answered a keepalive request. DNS and Dial
+// are only measured when no pooled connection existed (Cold).
+type PingResult struct {
+	Reachable bool
+	Cold      bool
+	Latency   time.Duration
+	DNS       time.Duration
+	Dial      time.Duration
+	Error     string
+}
+
+// Ping times a keepalive round trip to the server, connecting first when the
+// pool holds no live connection. Nothing is cached between calls.
+func (p *SSHPool) Ping(server *models.Server, password string) *PingResult {
+	result := &PingResult{Cold: !p.hasConnection(server.ID)}
+
+	// With a jump host the bastion resolves the target name, not us, and an
+	// ssh_config alias may not resolve at all
+	direct := server.JumpHost == "" && server.ProxyJump == "" && server.SSHConfigPath == ""
+	if result.Cold && direct && net.ParseIP(server.IPAddress) == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), config.Get().SSHTimeout)
+		start := time.Now()
+		_, err := net.DefaultResolver.LookupHost(ctx, server.IPAddress)
+		result.DNS = time.Since(start)
+		cancel()
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+	}
+
+	start := time.Now()
+	client, err := p.GetClient(server, password)
+	if result.Cold {
+		result.Dial = time.Since(start)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	start = time.Now()
+	err = client.TestConnection()
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Reachable = true
+	return result
+}
+
+// hasConnection reports whether the pool holds a live connection for the server
+func (p *SSHPool) hasConnection(serverID uint) bool {
+	p.mu.RLock()
+	client, exists := p.clients[serverID]
+	p.mu.RUnlock()
+	return exists && client.IsConnected()
+}
@@ -0,0 +1,82 @@
+package ssh
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"monitoring/config"
+)
+
+// CommandPolicy decides which commands may be executed through the API.
+// An empty policy allows every command.
+type CommandPolicy struct {
+	allow []*regexp.Regexp
+	deny  []*regexp.Regexp
+}
+
+var Policy *CommandPolicy
+
+// InitPolicy compiles the allow and deny patterns from config
+func InitPolicy() error {
+	allow, err := compilePatterns(config.Get().CommandAllowPatterns)
+	if err != nil {
+		return fmt.Errorf("invalid allow pattern: %w", err)
+	}
+
+	deny, err := compilePatterns(config.Get().CommandDenyPatterns)
+	if err != nil {
+		return fmt.Errorf("invalid deny pattern: %w", err)
+	}
+
+	Policy = &CommandPolicy{
+		allow: allow,
+		deny:  deny,
+	}
+	return nil
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Restricted reports whether any allow or deny rule is configured
+func (p *CommandPolicy) Restricted() bool {
+	return p != nil && (len(p.allow) > 0 || len(p.deny) > 0)
+}
+
+// Check reports whether a command is allowed, and the rule that blocked it otherwise.
+// Deny rules take precedence over allow rules.
+func (p *CommandPolicy) Check(command string) (bool, string) {
+	if p == nil {
+		return true, ""
+	}
+
+	command = strings.TrimSpace(command)
+
+	for _, re := range p.deny {
+		if re.MatchString(command) {
+			return false, "deny: " + re.String()
+		}
+	}
+
+	if len(p.allow) == 0 {
+		return true, ""
+	}
+
+	for _, re := range p.allow {
+		if re.MatchString(command) {
+			return true, ""
+		}
+	}
+
+	return false, "not in allow-list"
+}
@@ -0,0 +1,143 @@
+package ssh
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"monitoring/internal/models"
+)
+
+// portsScript lists listening sockets with ss, or netstat on older systems.
+// Both often live in sbin, which is not on a regular user's PATH.
+const portsScript = `PATH=$PATH:/usr/sbin:/sbin
+if command -v ss >/dev/null 2>&1; then echo @@ss; ss -tulpn
+elif command -v netstat >/dev/null 2>&1; then echo @@netstat; netstat -tulpn 2>/dev/null
+else exit 127; fi`
+
+// ssProcess matches the first owner in ss output: users:(("sshd",pid=812,fd=3))
+var ssProcess = regexp.MustCompile(`\("([^"]*)",pid=(\d+)`)
+
+// CollectOpenPorts lists the listening sockets of the server, sorted by
+// port. Servers with use_sudo are queried as root so every owner shows;
+// otherwise, or if sudo fails, owners of other users' sockets are left out.
+func (m *MetricCollector) CollectOpenPorts() ([]models.OpenPort, error) {
+	var output string
+	var err error
+	if m.client.Server.UseSudo {
+		output, err = m.client.ExecuteSudo("sudo sh -c "+ShellQuote(portsScript), batchTimeout)
+		if err != nil {
+			m.logger.Debug("Listing ports with sudo failed, retrying without: %v", err)
+		}
+	}
+	if output == "" {
+		if output, err = m.client.ExecuteWithTimeout(portsScript, batchTimeout); err != nil {
+			return nil, fmt.Errorf("neither ss nor netstat could list ports: %w", err)
+		}
+	}
+
+	sections := batchSections(output)
+	var ports []models.OpenPort
+	if body, ok := sections["ss"]; ok {
+		ports = parseSS(body)
+	} else {
+		ports = parseNetstat(sections["netstat"])
+	}
+
+	sort.SliceStable(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Protocol < ports[j].Protocol
+	})
+	return ports, nil
+}
+
+// parseSS reads ss -tulpn:
+// Netid State Recv-Q Send-Q Local-Address:Port Peer-Address:Port Process
+func parseSS(output string) []models.OpenPort {
+	var ports []models.OpenPort
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] == "Netid" {
+			continue
+		}
+
+		port, ok := parseSocket(fields[0], fields[4])
+		if !ok {
+			continue
+		}
+		port.State = fields[1]
+		if len(fields) > 6 {
+			if match := ssProcess.FindStringSubmatch(strings.Join(fields[6:], " ")); match != nil {
+				port.Process = match[1]
+				port.PID, _ = strconv.Atoi(match[2])
+			}
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// parseNetstat reads netstat -tulpn. UDP lines have no state column:
+// Proto Recv-Q Send-Q Local-Address Foreign-Address [State] PID/Program
+func parseNetstat(output string) []models.OpenPort {
+	var ports []models.OpenPort
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.HasPrefix(fields[0], "tcp") && !strings.HasPrefix(fields[0], "udp") {
+			continue
+		}
+
+		port, ok := parseSocket(fields[0], fields[3])
+		if !ok {
+			continue
+		}
+		owner := 5
+		if port.Protocol == "tcp" && len(fields) > 5 {
+			port.State = fields[5]
+			owner = 6
+		}
+		if len(fields) > owner {
+			if pid, name, found := strings.Cut(fields[owner], "/"); found {
+				port.PID, _ = strconv.Atoi(pid)
+				port.Process = name
+			}
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+// parseSocket splits a local address such as 0.0.0.0:22, [::]:22, :::22,
+// *:53 or 127.0.0.53%lo:53. proto is ss's netid or netstat's proto, where
+// tcp6 and udp6 mark IPv6.
+func parseSocket(proto, local string) (models.OpenPort, bool) {
+	i := strings.LastIndex(local, ":")
+	if i < 0 {
+		return models.OpenPort{}, false
+	}
+	port, err := strconv.Atoi(local[i+1:])
+	if err != nil {
+		return models.OpenPort{}, false
+	}
+
+	host := strings.TrimSuffix(strings.TrimPrefix(local[:i], "["), "]")
+	if zone := strings.Index(host, "%"); zone >= 0 {
+		host = host[:zone]
+	}
+
+	result := models.OpenPort{
+		Protocol: strings.TrimSuffix(proto, "6"),
+		IPv6:     strings.HasSuffix(proto, "6") || strings.Contains(host, ":"),
+		Address:  host,
+		Port:     port,
+	}
+	switch host {
+	case "", "0.0.0.0", "::":
+		result.Address = "*"
+	}
+	return result, true
+}
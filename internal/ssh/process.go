@@ -0,0 +1,52 @@
+package ssh
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KillSignals are the signals accepted by KillProcess
+var KillSignals = map[string]bool{
+	"TERM": true,
+	"KILL": true,
+	"HUP":  true,
+	"INT":  true,
+	"QUIT": true,
+	"USR1": true,
+	"USR2": true,
+	"STOP": true,
+	"CONT": true,
+}
+
+// NormalizeSignal converts "sigterm" or "SIGTERM" to "TERM", defaulting to TERM
+func NormalizeSignal(signal string) (string, error) {
+	signal = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(signal)), "SIG")
+	if signal == "" {
+		return "TERM", nil
+	}
+	if !KillSignals[signal] {
+		return "", fmt.Errorf("signal not allowed: %s", signal)
+	}
+	return signal, nil
+}
+
+// KillCommand builds the kill command for a process
+func KillCommand(pid int, signal string) string {
+	return "kill -" + signal + " " + strconv.Itoa(pid)
+}
+
+// KillProcess sends a signal to a process on the remote server
+func (c *SSHClient) KillProcess(pid int, signal string) error {
+	if pid <= 0 {
+		return fmt.Errorf("invalid pid: %d", pid)
+	}
+
+	signal, err := NormalizeSignal(signal)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Execute(KillCommand(pid, signal))
+	return err
+}
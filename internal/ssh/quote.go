@@ -0,0 +1,8 @@
+package ssh
+
+import "strings"
+
+// ShellQuote wraps s in single quotes so it is passed to a POSIX shell verbatim
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"strconv"
+	"strings"
+)
+
+// CollectTemperatures returns temperature readings in Celsius keyed by sensor label.
+// It parses lm-sensors output when available and falls back to the kernel
+// thermal zones. An empty map is returned when neither source exists.
+func (m *MetricCollector) CollectTemperatures() (map[string]float64, error) {
+	if _, err := m.client.Execute("command -v sensors"); err == nil {
+		output, err := m.client.Execute("sensors -u 2>/dev/null")
+		if err == nil {
+			if temps := parseSensorsOutput(output); len(temps) > 0 {
+				return temps, nil
+			}
+		}
+	}
+
+	cmd := `for z in /sys/class/thermal/thermal_zone*; do [ -r "$z/temp" ] && echo "$(basename $z):$(cat $z/type 2>/dev/null) $(cat $z/temp)"; done; true`
+	output, err := m.client.Execute(cmd)
+	if err != nil {
+		return map[string]float64{}, nil
+	}
+
+	return parseThermalZones(output), nil
+}
+
+// parseSensorsOutput reads the tempN_input values of `sensors -u`, labelling
+// each as "chip/feature"
+func parseSensorsOutput(output string) map[string]float64 {
+	temps := make(map[string]float64)
+	chip, feature := "", ""
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			chip, feature = "", ""
+		case chip == "":
+			chip = trimmed
+		case strings.HasPrefix(trimmed, "Adapter:"):
+		case line[0] != ' ' && strings.HasSuffix(trimmed, ":"):
+			feature = strings.TrimSuffix(trimmed, ":")
+		default:
+			key, value, ok := strings.Cut(trimmed, ":")
+			if !ok || !strings.HasPrefix(key, "temp") || !strings.HasSuffix(key, "_input") {
+				continue
+			}
+			temp, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				continue
+			}
+			temps[chip+"/"+feature] = temp
+		}
+	}
+
+	return temps
+}
@@ -0,0 +1,64 @@
+package ssh
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ServiceActions are the systemctl verbs accepted by ControlService
+var ServiceActions = map[string]bool{
+	"start":   true,
+	"stop":    true,
+	"restart": true,
+}
+
+// unitNamePattern rejects a leading "-" so a name is never read as an option
+var unitNamePattern = regexp.MustCompile(`^[a-zA-Z0-9@._:][a-zA-Z0-9@._:\-]*$`)
+
+// ValidateServiceUnit checks that a unit name is safe to pass to systemctl
+func ValidateServiceUnit(unit string) error {
+	if !unitNamePattern.MatchString(unit) {
+		return fmt.Errorf("invalid unit name: %q", unit)
+	}
+	return nil
+}
+
+// ServiceCommand builds the systemctl command for an action on a unit
+func ServiceCommand(unit, action string, sudo bool) string {
+	cmd := "systemctl " + action + " " + unit
+	if sudo {
+		cmd = "sudo " + cmd
+	}
+	return cmd
+}
+
+// ControlService runs a systemctl action and returns the resulting unit state
+func (c *SSHClient) ControlService(unit, action string, sudo bool) (string, error) {
+	if !ServiceActions[action] {
+		return "", fmt.Errorf("invalid action: %q", action)
+	}
+	if err := ValidateServiceUnit(unit); err != nil {
+		return "", err
+	}
+
+	if _, err := c.ExecuteSudo(ServiceCommand(unit, action, sudo), 0); err != nil {
+		return "", err
+	}
+
+	return c.ServiceState(unit)
+}
+
+// ServiceState returns the output of systemctl is-active for a unit
+func (c *SSHClient) ServiceState(unit string) (string, error) {
+	if err := ValidateServiceUnit(unit); err != nil {
+		return "", err
+	}
+
+	// is-active exits non-zero for inactive units, the state is still printed
+	output, err := c.Execute("systemctl is-active " + unit + " || true")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(output), nil
+}
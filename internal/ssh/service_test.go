@@ -0,0 +1,30 @@
+package ssh
+
+import "testing"
+
+func TestValidateServiceUnit(t *testing.T) {
+	tests := []struct {
+		unit  string
+		valid bool
+	}{
+		{"nginx", true},
+		{"nginx.service", true},
+		{"getty@tty1.service", true},
+		{"dev-disk-by\\x2duuid.device", false},
+		{"my-app.service", true},
+		{"", false},
+		{"--force", false},
+		{"-Hroot@host", false},
+		{"-", false},
+		{"nginx; reboot", false},
+		{"nginx service", false},
+		{"$(reboot)", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateServiceUnit(tt.unit)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateServiceUnit(%q) error = %v, want valid %v", tt.unit, err, tt.valid)
+		}
+	}
+}
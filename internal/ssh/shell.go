@@ -0,0 +1,119 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	defaultTerm = "xterm-256color"
+	defaultCols = 80
+	defaultRows = 24
+)
+
+// Shell is an interactive login shell on a remote pseudo-terminal. Output
+// and errors arrive merged on Read, as a terminal would show them.
+type Shell struct {
+	client  *SSHClient
+	session *ssh.Session
+	stdin   io.WriteCloser
+	stdout  io.Reader
+	release sync.Once // Releases client on the first Close
+}
+
+// OpenShell starts a login shell on a pseudo-terminal of the given size.
+// Like ExecuteStream, the client lock is only held while the session opens
+// and the client is kept from eviction until the shell is closed.
+func (c *SSHClient) OpenShell(term string, cols, rows int) (*Shell, error) {
+	if term == "" {
+		term = defaultTerm
+	}
+	if cols <= 0 || rows <= 0 {
+		cols, rows = defaultCols, defaultRows
+	}
+
+	session, err := c.newSession()
+	if err != nil {
+		return nil, err
+	}
+
+	shell, err := startShell(session, term, cols, rows)
+	if err != nil {
+		session.Close()
+		c.Release()
+		return nil, err
+	}
+	shell.client = c
+	return shell, nil
+}
+
+func startShell(session *ssh.Session, term string, cols, rows int) (*Shell, error) {
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          1,
+		ssh.TTY_OP_ISPEED: 14400,
+		ssh.TTY_OP_OSPEED: 14400,
+	}
+	if err := session.RequestPty(term, rows, cols, modes); err != nil {
+		return nil, fmt.Errorf("failed to request pty: %w", err)
+	}
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdin: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stdout: %w", err)
+	}
+
+	if err := session.Shell(); err != nil {
+		return nil, fmt.Errorf("failed to start shell: %w", err)
+	}
+
+	return &Shell{session: session, stdin: stdin, stdout: stdout}, nil
+}
+
+// Read returns terminal output
+func (s *Shell) Read(p []byte) (int, error) {
+	s.client.Touch()
+	return s.stdout.Read(p)
+}
+
+// Write sends keystrokes to the terminal
+func (s *Shell) Write(p []byte) (int, error) {
+	s.client.Touch()
+	return s.stdin.Write(p)
+}
+
+// Resize tells the remote terminal its new size
+func (s *Shell) Resize(cols, rows int) error {
+	if cols <= 0 || rows <= 0 {
+		return fmt.Errorf("invalid terminal size %dx%d", cols, rows)
+	}
+	return s.session.WindowChange(rows, cols)
+}
+
+// Wait blocks until the shell exits and returns its exit code. An exit
+// without a status, such as a closed session, reports -1.
+func (s *Shell) Wait() int {
+	err := s.session.Wait()
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := err.(*ssh.ExitError); ok {
+		return exitErr.ExitStatus()
+	}
+	return -1
+}
+
+// Close hangs up the terminal and ends the session
+func (s *Shell) Close() error {
+	s.stdin.Close()
+	s.session.Signal(ssh.SIGHUP)
+	err := s.session.Close()
+	s.release.Do(s.client.Release)
+	return err
+}
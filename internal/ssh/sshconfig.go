@@ -0,0 +1,169 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxSSHConfigSize keeps a mistyped path such as /dev/zero from being read
+const maxSSHConfigSize = 1 << 20
+
+// hostOptions are the ssh_config settings used when dialing
+type hostOptions struct {
+	HostName     string
+	User         string
+	Port         string
+	ProxyJump    string
+	IdentityFile string
+}
+
+// configBlock is a Host section. Match sections are kept with no patterns
+// so their options never apply.
+type configBlock struct {
+	patterns []string
+	options  map[string]string
+}
+
+// sshConfigFile holds the sections of an ssh_config file in order
+type sshConfigFile struct {
+	blocks []configBlock
+}
+
+// ValidateSSHConfig checks that path names a readable ssh_config file
+func ValidateSSHConfig(path string) error {
+	_, err := loadSSHConfig(path)
+	return err
+}
+
+// expandHome replaces a leading ~ with the home directory of the user
+// running the API
+func expandHome(name string) (string, error) {
+	rest, ok := strings.CutPrefix(name, "~/")
+	if !ok {
+		return name, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rest), nil
+}
+
+// loadSSHConfig reads an ssh_config file from SSH_KEY_DIR. Include
+// directives are not followed, and IdentityFile paths are held to
+// SSH_KEY_DIR as well when the key is loaded.
+func loadSSHConfig(name string) (*sshConfigFile, error) {
+	file, err := keyDirPath(name)
+	if err != nil {
+		return nil, fmt.Errorf("ssh config %s: %w", name, err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("ssh config %s: %w", name, err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("ssh config %s is not a regular file", name)
+	}
+	if info.Size() > maxSSHConfigSize {
+		return nil, fmt.Errorf("ssh config %s is larger than %d bytes", name, maxSSHConfigSize)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("ssh config %s: %w", name, err)
+	}
+	return parseSSHConfig(data)
+}
+
+// parseSSHConfig reads "Keyword value" and "Keyword=value" lines. Options
+// before the first Host apply to every host.
+func parseSSHConfig(data []byte) (*sshConfigFile, error) {
+	file := &sshConfigFile{}
+	current := configBlock{patterns: []string{"*"}, options: make(map[string]string)}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		keyword, value := text, ""
+		if i := strings.IndexAny(text, " \t="); i >= 0 {
+			keyword = text[:i]
+			value = strings.TrimSpace(text[i:])
+			value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+		}
+		value = strings.Trim(value, `"`)
+		keyword = strings.ToLower(keyword)
+		if value == "" {
+			return nil, fmt.Errorf("ssh config line %d: %s has no value", line, keyword)
+		}
+
+		switch keyword {
+		case "host", "match":
+			file.blocks = append(file.blocks, current)
+			current = configBlock{options: make(map[string]string)}
+			if keyword == "host" {
+				current.patterns = strings.Fields(strings.ToLower(value))
+			}
+		default:
+			// ssh uses the first value it finds for each option
+			if _, set := current.options[keyword]; !set {
+				current.options[keyword] = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	file.blocks = append(file.blocks, current)
+	return file, nil
+}
+
+// lookup returns the options for alias, first match winning as in ssh.
+// %h in HostName is replaced by the alias.
+func (f *sshConfigFile) lookup(alias string) hostOptions {
+	values := make(map[string]string)
+	for _, block := range f.blocks {
+		if !matchesHost(block.patterns, alias) {
+			continue
+		}
+		for keyword, value := range block.options {
+			if _, set := values[keyword]; !set {
+				values[keyword] = value
+			}
+		}
+	}
+
+	return hostOptions{
+		HostName:     strings.ReplaceAll(values["hostname"], "%h", alias),
+		User:         values["user"],
+		Port:         values["port"],
+		ProxyJump:    values["proxyjump"],
+		IdentityFile: values["identityfile"],
+	}
+}
+
+// matchesHost applies Host patterns: * and ? wildcards, and a !pattern that
+// excludes the host even when another pattern matches
+func matchesHost(patterns []string, host string) bool {
+	host = strings.ToLower(host)
+	matched := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		ok, _ := path.Match(strings.TrimPrefix(pattern, "!"), host)
+		if ok && negated {
+			return false
+		}
+		matched = matched || ok && !negated
+	}
+	return matched
+}
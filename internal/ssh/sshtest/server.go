@@ -0,0 +1,191 @@
+// Package sshtest runs an in-process SSH server for tests. Commands sent in
+// exec requests run on the local machine with sh -c, and the sftp subsystem
+// serves the local file system.
+package sshtest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Credentials the server accepts
+const (
+	User     = "test"
+	Password = "secret"
+)
+
+// Server is a running test server
+type Server struct {
+	Host string
+	Port string
+
+	listener net.Listener
+	config   *ssh.ServerConfig
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server listening on addr, e.g. "127.0.0.1:0" or
+// "[::1]:0", and closes it when the test ends. The test is skipped when the
+// address cannot be bound, e.g. on hosts without IPv6.
+func NewServer(t testing.TB, addr string) *Server {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == User && string(password) == Password {
+				return nil, nil
+			}
+			return nil, errors.New("wrong credentials")
+		},
+	}
+	config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", addr, err)
+	}
+
+	s := &Server{listener: listener, config: config}
+	s.Host, s.Port, _ = net.SplitHostPort(listener.Addr().String())
+
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Close stops accepting connections. Open connections end with the test.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go handleSession(channel, requests)
+	}
+}
+
+// handleSession runs the session's exec or shell request. A signal or the
+// client closing the session kills the command.
+func handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var env []string
+	for req := range requests {
+		switch req.Type {
+		case "env":
+			var payload struct{ Name, Value string }
+			ssh.Unmarshal(req.Payload, &payload)
+			env = append(env, payload.Name+"="+payload.Value)
+			req.Reply(true, nil)
+		case "exec":
+			var payload struct{ Command string }
+			ssh.Unmarshal(req.Payload, &payload)
+			req.Reply(true, nil)
+			go run(ctx, channel, payload.Command, env)
+		case "shell":
+			req.Reply(true, nil)
+			go run(ctx, channel, "sh", env)
+		case "pty-req", "window-change":
+			// No terminal is emulated, shells read plain stdin
+			req.Reply(true, nil)
+		case "subsystem":
+			var payload struct{ Name string }
+			ssh.Unmarshal(req.Payload, &payload)
+			if payload.Name != "sftp" {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			go serveSFTP(channel)
+		case "signal":
+			cancel()
+			req.Reply(true, nil)
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// serveSFTP runs an SFTP server on channel until the client closes it
+func serveSFTP(channel ssh.Channel) {
+	server, err := sftp.NewServer(channel)
+	if err != nil {
+		channel.Close()
+		return
+	}
+	server.Serve()
+	server.Close()
+}
+
+// run executes command and reports its exit status on channel
+func run(ctx context.Context, channel ssh.Channel, command string, env []string) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = channel
+	cmd.Stdout = channel
+	cmd.Stderr = channel.Stderr()
+	// A killed shell may leave children holding its output open
+	cmd.WaitDelay = 100 * time.Millisecond
+
+	status := 0
+	var exitErr *exec.ExitError
+	if err := cmd.Run(); errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		status = exitErr.ExitCode()
+	} else if err != nil {
+		status = 255
+	}
+
+	channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+	channel.Close()
+}
@@ -0,0 +1,122 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ExecuteStream runs a long-lived command and calls onLine for every line it
+// prints until the command exits or ctx is cancelled. The client lock is only
+// held while the session opens so other commands are not blocked, and the
+// pool does not evict the client while the command runs. On cancellation
+// stdin is closed and the session killed.
+func (c *SSHClient) ExecuteStream(ctx context.Context, command string, onLine func(line string)) error {
+	session, err := c.newSession()
+	if err != nil {
+		return err
+	}
+	defer c.Release()
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stdin: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open stdout: %w", err)
+	}
+
+	if err := session.Start(command); err != nil {
+		return fmt.Errorf("command failed: %w", err)
+	}
+
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			stdin.Close()
+			session.Signal(ssh.SIGKILL)
+			session.Close()
+		case <-finished:
+		}
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		c.Touch()
+		onLine(scanner.Text())
+	}
+
+	err = session.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("command failed: %w", err)
+	}
+	return nil
+}
+
+// ExecuteTo runs a command and copies its raw stdout to w until it exits or
+// ctx is cancelled, returning the number of bytes written. Like
+// ExecuteStream, the client lock is only held while the session opens.
+func (c *SSHClient) ExecuteTo(ctx context.Context, command string, w io.Writer) (int64, error) {
+	session, err := c.newSession()
+	if err != nil {
+		return 0, err
+	}
+	defer c.Release()
+	defer session.Close()
+
+	counter := &countingWriter{w: w}
+	var stderr bytes.Buffer
+	session.Stdout = counter
+	session.Stderr = &stderr
+
+	if err := session.Start(wrapCommand(c.Server, command, nil)); err != nil {
+		return 0, fmt.Errorf("command failed: %w", err)
+	}
+
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			session.Signal(ssh.SIGKILL)
+			session.Close()
+		case <-finished:
+		}
+	}()
+
+	err = session.Wait()
+	if ctx.Err() != nil {
+		return counter.n, ctx.Err()
+	}
+	if err != nil {
+		if stderr.Len() > 0 {
+			return counter.n, fmt.Errorf("command failed: %s", stderr.String())
+		}
+		return counter.n, fmt.Errorf("command failed: %w", err)
+	}
+	return counter.n, nil
+}
+
+// countingWriter counts the bytes passed to w
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,94 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"monitoring/internal/utils"
+)
+
+var (
+	// ErrSudoPasswordRequired is returned when sudo asks for a password the
+	// server is not configured to provide
+	ErrSudoPasswordRequired = errors.New("sudo requires a password: enable use_sudo for this server or configure passwordless sudo")
+	// ErrSudoPasswordRejected is returned when the configured sudo password is wrong
+	ErrSudoPasswordRejected = errors.New("sudo rejected the configured password")
+)
+
+// sudoScript runs sudo on its arguments, reading the password from stdin
+// only when sudo needs one. Under NOPASSWD or with cached credentials the
+// command gets /dev/null instead, so the password is never its input.
+const sudoScript = `if sudo -n true 2>/dev/null; then exec sudo -n "$@" </dev/null; fi; exec sudo -S -p '' "$@"`
+
+// PrepareSudo rewrites a command that starts with sudo so it can never wait
+// on a password prompt. With UseSudo the password is passed on stdin (-S)
+// with an empty prompt when sudo asks for one; otherwise -n makes sudo fail
+// at once. It returns the command and the stdin to run it with. The password
+// is never part of the command, so it does not reach logs or audit entries.
+func (c *SSHClient) PrepareSudo(command string) (string, string, error) {
+	trimmed := strings.TrimSpace(command)
+	if trimmed != "sudo" && !strings.HasPrefix(trimmed, "sudo ") {
+		return command, "", nil
+	}
+	args := strings.TrimPrefix(trimmed, "sudo")
+
+	if !c.Server.UseSudo {
+		return "sudo -n" + args, "", nil
+	}
+
+	password := c.password
+	if c.Server.SudoPassword != "" {
+		var err error
+		if password, err = utils.Decrypt(c.Server.SudoPassword); err != nil {
+			return "", "", fmt.Errorf("failed to decrypt sudo credentials")
+		}
+	}
+	return "sh -c " + ShellQuote(sudoScript) + " sudo" + args, password + "\n", nil
+}
+
+// ExecuteSudo runs a command through PrepareSudo, reporting password
+// problems as ErrSudoPasswordRequired or ErrSudoPasswordRejected
+func (c *SSHClient) ExecuteSudo(command string, timeout time.Duration) (string, error) {
+	command, input, err := c.PrepareSudo(command)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := c.ExecuteWithInput(command, input, timeout)
+	return output, SudoError(err)
+}
+
+// SudoError maps sudo's password failures to the package errors
+func SudoError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "a password is required"),
+		strings.Contains(msg, "a terminal is required"),
+		strings.Contains(msg, "no tty present"):
+		return ErrSudoPasswordRequired
+	case strings.Contains(msg, "incorrect password"),
+		strings.Contains(msg, "Sorry, try again"):
+		return ErrSudoPasswordRejected
+	}
+	return err
+}
+
+// SudoResultError returns ErrSudoPasswordRequired or ErrSudoPasswordRejected
+// when a command failed because of sudo's password handling, nil otherwise
+func SudoResultError(result *CommandResult) error {
+	if result == nil || result.ExitCode == 0 {
+		return nil
+	}
+
+	err := SudoError(errors.New(result.Stderr))
+	if errors.Is(err, ErrSudoPasswordRequired) || errors.Is(err, ErrSudoPasswordRejected) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package ssh
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"monitoring/internal/ssh/sshtest"
+	"monitoring/internal/utils"
+)
+
+// fakeSudo stands in for sudo on the test server. It prompts for
+// sshtest.Password on stdin unless FAKE_SUDO_NOPASSWD is set.
+const fakeSudo = `#!/bin/sh
+while [ $# -gt 0 ]; do
+	case "$1" in
+	-n) nonInteractive=1; shift ;;
+	-S) shift ;;
+	-p | -u) shift 2 ;;
+	*) break ;;
+	esac
+done
+if [ -z "$FAKE_SUDO_NOPASSWD" ]; then
+	if [ -n "$nonInteractive" ]; then
+		echo "sudo: a password is required" >&2
+		exit 1
+	fi
+	IFS= read -r password
+	if [ "$password" != "` + sshtest.Password + `" ]; then
+		echo "sudo: 1 incorrect password attempt" >&2
+		exit 1
+	fi
+fi
+exec "$@"
+`
+
+func TestSudoPasswordNeverReachesCommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sudo"), []byte(fakeSudo), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	client := connectTestClient(t, "127.0.0.1:0")
+	client.Server.UseSudo = true
+
+	for _, nopasswd := range []string{"", "1"} {
+		t.Setenv("FAKE_SUDO_NOPASSWD", nopasswd)
+
+		output, err := client.ExecuteSudo("sudo cat", 5*time.Second)
+		if err != nil || strings.Contains(output, sshtest.Password) {
+			t.Errorf("NOPASSWD %q: sudo cat = %q, %v; want no password", nopasswd, output, err)
+		}
+		output, err = client.ExecuteSudo("sudo -u root echo 'two  words'", 5*time.Second)
+		if err != nil || strings.TrimSpace(output) != "two  words" {
+			t.Errorf("NOPASSWD %q: sudo echo = %q, %v", nopasswd, output, err)
+		}
+	}
+
+	t.Setenv("FAKE_SUDO_NOPASSWD", "")
+	wrong, err := utils.Encrypt("wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Server.SudoPassword = wrong
+	if _, err := client.ExecuteSudo("sudo true", 5*time.Second); !errors.Is(err, ErrSudoPasswordRejected) {
+		t.Errorf("wrong password error = %v, want ErrSudoPasswordRejected", err)
+	}
+}
@@ -0,0 +1,65 @@
+package ssh
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"monitoring/internal/models"
+)
+
+// sysInfoScript reads the host details in one session, using the batch
+// section markers
+const sysInfoScript = `exec 2>/dev/null
+echo @@hostname; hostname
+echo @@os; [ -r /etc/os-release ] && (. /etc/os-release; echo "$PRETTY_NAME") || uname -sr
+echo @@kernel; uname -r
+echo @@arch; uname -m
+echo @@cpu; cpu=$(grep -m1 'model name' /proc/cpuinfo | cut -d: -f2-)
+[ -n "$cpu" ] || cpu=$(lscpu | grep -m1 'Model name' | cut -d: -f2-)
+echo "$cpu"
+true`
+
+// systemInfo caches the collected details per server
+var systemInfo = struct {
+	byServer map[uint]models.SystemInfo
+	mu       sync.Mutex
+}{byServer: make(map[uint]models.SystemInfo)}
+
+// SystemInfo returns the host details of the server, collecting them when
+// they are not cached yet or refresh is set
+func (c *SSHClient) SystemInfo(refresh bool) (models.SystemInfo, error) {
+	systemInfo.mu.Lock()
+	info, cached := systemInfo.byServer[c.Server.ID]
+	systemInfo.mu.Unlock()
+	if cached && !refresh {
+		return info, nil
+	}
+
+	output, err := c.ExecuteWithTimeout(sysInfoScript, batchTimeout)
+	if err != nil {
+		return models.SystemInfo{}, err
+	}
+
+	sections := batchSections(output)
+	info = models.SystemInfo{
+		Hostname:    strings.TrimSpace(sections["hostname"]),
+		OS:          strings.TrimSpace(sections["os"]),
+		Kernel:      strings.TrimSpace(sections["kernel"]),
+		Arch:        strings.TrimSpace(sections["arch"]),
+		CPUModel:    strings.TrimSpace(sections["cpu"]),
+		CollectedAt: time.Now(),
+	}
+
+	systemInfo.mu.Lock()
+	systemInfo.byServer[c.Server.ID] = info
+	systemInfo.mu.Unlock()
+	return info, nil
+}
+
+// forgetSystemInfo drops the cached details, e.g. when the server changes
+func forgetSystemInfo(serverID uint) {
+	systemInfo.mu.Lock()
+	defer systemInfo.mu.Unlock()
+	delete(systemInfo.byServer, serverID)
+}
@@ -0,0 +1,11 @@
+package ssh
+
+import "fmt"
+
+// TailCommand follows a file across rotations, printing the last lines first.
+// tail runs in the background and is killed once stdin reaches EOF, so closing
+// the session stdin also stops the remote process on servers that ignore
+// signal requests.
+func TailCommand(path string, lines int) string {
+	return fmt.Sprintf("tail -n %d -F -- %s & TAIL_PID=$!; cat >/dev/null; kill $TAIL_PID", lines, ShellQuote(path))
+}
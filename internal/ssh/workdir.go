@@ -0,0 +1,31 @@
+package ssh
+
+// maxWorkDirSessions bounds how many working directories a client remembers
+const maxWorkDirSessions = 1000
+
+// WorkDir returns the working directory of a session on this server, empty
+// when the session has not changed directory. Sessions are chosen by the
+// caller; the empty session is shared by callers that do not name one.
+func (c *SSHClient) WorkDir(session string) string {
+	c.dirsMu.Lock()
+	defer c.dirsMu.Unlock()
+	return c.dirs[session]
+}
+
+// SetWorkDir records the working directory of a session
+func (c *SSHClient) SetWorkDir(session, dir string) {
+	c.dirsMu.Lock()
+	defer c.dirsMu.Unlock()
+
+	if c.dirs == nil {
+		c.dirs = make(map[string]string)
+	}
+	if _, exists := c.dirs[session]; !exists && len(c.dirs) >= maxWorkDirSessions {
+		// Forget an arbitrary session rather than grow without bound
+		for old := range c.dirs {
+			delete(c.dirs, old)
+			break
+		}
+	}
+	c.dirs[session] = dir
+}
@@ -4,23 +4,49 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"monitoring/config"
 )
 
-// Encrypt encrypts plaintext using AES-256-GCM
+// keyIDSeparator splits the key ID from the ciphertext in encrypted values
+const keyIDSeparator = "$"
+
+// KeyID returns the identifier prefixed to values encrypted with key
+func KeyID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:4])
+}
+
+// GenerateEncryptionKey returns a random key suitable for ENCRYPTION_KEY.
+// The key is base64 text so it can be pasted into .env as is.
+func GenerateEncryptionKey() (string, error) {
+	raw := make([]byte, base64.StdEncoding.DecodedLen(config.EncryptionKeySize))
+	if _, err := io.ReadFull(rand.Reader, raw); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(raw), nil
+}
+
+// Encrypt encrypts plaintext using AES-256-GCM with the current key
 func Encrypt(plaintext string) (string, error) {
-	key := []byte(config.AppConfig.EncryptionKey)
+	return EncryptWithKey(plaintext, config.Get().EncryptionKey)
+}
+
+// EncryptWithKey encrypts plaintext with key, producing "<key id>$<base64>"
+func EncryptWithKey(plaintext, key string) (string, error) {
 	if len(key) != 32 {
 		fmt.Println("[error no long]")
 		return "", errors.New("encryption key must be 32 bytes")
 	}
 
-	block, err := aes.NewCipher(key)
+	block, err := aes.NewCipher([]byte(key))
 	if err != nil {
 		return "", err
 	}
@@ -36,12 +62,40 @@ func Encrypt(plaintext string) (string, error) {
 	}
 
 	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return KeyID(key) + keyIDSeparator + base64.StdEncoding.EncodeToString(ciphertext), nil
 }
 
-// Decrypt decrypts ciphertext using AES-256-GCM
+// Decrypt decrypts ciphertext using the current key or one of the previous keys
 func Decrypt(ciphertext string) (string, error) {
-	key := []byte(config.AppConfig.EncryptionKey)
+	cfg := config.Get()
+	keys := append([]string{cfg.EncryptionKey}, cfg.EncryptionOldKeys...)
+	return DecryptWithKeys(ciphertext, keys)
+}
+
+// DecryptWithKeys decrypts a value with whichever of keys matches its key ID.
+// Values written before key IDs existed are tried against every key.
+func DecryptWithKeys(ciphertext string, keys []string) (string, error) {
+	if id, data, ok := strings.Cut(ciphertext, keyIDSeparator); ok {
+		for _, key := range keys {
+			if KeyID(key) == id {
+				return decryptWithKey(data, key)
+			}
+		}
+		return "", fmt.Errorf("no encryption key available for key ID %s", id)
+	}
+
+	lastErr := errors.New("no encryption keys configured")
+	for _, key := range keys {
+		plaintext, err := decryptWithKey(ciphertext, key)
+		if err == nil {
+			return plaintext, nil
+		}
+		lastErr = err
+	}
+	return "", lastErr
+}
+
+func decryptWithKey(ciphertext, key string) (string, error) {
 	if len(key) != 32 {
 		return "", errors.New("encryption key must be 32 bytes")
 	}
@@ -51,7 +105,7 @@ func Decrypt(ciphertext string) (string, error) {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(key)
+	block, err := aes.NewCipher([]byte(key))
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	oldTestKey = "0123456789abcdef0123456789abcdef"
+	newTestKey = "fedcba9876543210fedcba9876543210"
+)
+
+func encryptForTest(t *testing.T, plaintext, key string) string {
+	t.Helper()
+	ciphertext, err := EncryptWithKey(plaintext, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ciphertext
+}
+
+// legacyValue strips the key ID, as values were stored before key IDs existed
+func legacyValue(ciphertext string) string {
+	_, data, _ := strings.Cut(ciphertext, keyIDSeparator)
+	return data
+}
+
+func TestDecryptWithKeys(t *testing.T) {
+	rotated := []string{newTestKey, oldTestKey}
+
+	tests := []struct {
+		name       string
+		ciphertext string
+		keys       []string
+		ok         bool
+	}{
+		{"current key", encryptForTest(t, "hunter2", newTestKey), rotated, true},
+		{"previous key", encryptForTest(t, "hunter2", oldTestKey), rotated, true},
+		{"previous key dropped", encryptForTest(t, "hunter2", oldTestKey), []string{newTestKey}, false},
+		{"legacy under current key", legacyValue(encryptForTest(t, "hunter2", newTestKey)), rotated, true},
+		{"legacy under previous key", legacyValue(encryptForTest(t, "hunter2", oldTestKey)), rotated, true},
+		{"legacy under unknown key", legacyValue(encryptForTest(t, "hunter2", oldTestKey)), []string{newTestKey}, false},
+		{"no keys", legacyValue(encryptForTest(t, "hunter2", oldTestKey)), nil, false},
+		{"tampered", encryptForTest(t, "hunter2", newTestKey) + "AA", rotated, false},
+	}
+
+	for _, tt := range tests {
+		plaintext, err := DecryptWithKeys(tt.ciphertext, tt.keys)
+		if tt.ok && (err != nil || plaintext != "hunter2") {
+			t.Errorf("%s: got %q, %v; want hunter2", tt.name, plaintext, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: got %q, want an error", tt.name, plaintext)
+		}
+	}
+}
+
+func TestEncryptWithKeyPrefixesKeyID(t *testing.T) {
+	ciphertext := encryptForTest(t, "hunter2", newTestKey)
+	if !strings.HasPrefix(ciphertext, KeyID(newTestKey)+keyIDSeparator) {
+		t.Errorf("ciphertext %q lacks the key ID of the key", ciphertext)
+	}
+	if KeyID(newTestKey) == KeyID(oldTestKey) {
+		t.Error("different keys share a key ID")
+	}
+}
@@ -1,10 +1,20 @@
 package utils
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"monitoring/config"
 )
 
 type LogLevel int
@@ -16,48 +26,190 @@ const (
 	LogError
 )
 
+func (l LogLevel) String() string {
+	switch l {
+	case LogDebug:
+		return "debug"
+	case LogInfo:
+		return "info"
+	case LogWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
+// ParseLogLevel converts a LOG_LEVEL value to a LogLevel
+func ParseLogLevel(value string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return LogDebug, nil
+	case "info", "":
+		return LogInfo, nil
+	case "warning", "warn":
+		return LogWarning, nil
+	case "error":
+		return LogError, nil
+	}
+	return LogInfo, fmt.Errorf("unknown log level %q", value)
+}
+
 type Logger struct {
 	debugLogger   *log.Logger
 	infoLogger    *log.Logger
 	warningLogger *log.Logger
 	errorLogger   *log.Logger
-	minLevel      LogLevel
+	minLevel      int32 // LogLevel, changed at runtime through SetLevel
+	jsonFormat    bool
+	redactor      *Redactor // Masks secrets before a message is written
+	stdout        io.Writer
+	stderr        io.Writer
 }
 
 var AppLogger *Logger
 
 func InitLogger(minLevel LogLevel) {
+	cfg := config.Get()
+	stdout, stderr := logOutputs()
+
+	var extra []string
+	if cfg != nil {
+		extra = cfg.LogRedactPatterns
+	}
+	redactor, invalid := NewRedactor(extra)
+
 	AppLogger = &Logger{
-		debugLogger:   log.New(os.Stdout, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile),
-		infoLogger:    log.New(os.Stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile),
-		warningLogger: log.New(os.Stdout, "WARNING: ", log.Ldate|log.Ltime|log.Lshortfile),
-		errorLogger:   log.New(os.Stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile),
-		minLevel:      minLevel,
+		debugLogger:   log.New(stdout, "DEBUG: ", log.Ldate|log.Ltime|log.Lshortfile),
+		infoLogger:    log.New(stdout, "INFO: ", log.Ldate|log.Ltime|log.Lshortfile),
+		warningLogger: log.New(stdout, "WARNING: ", log.Ldate|log.Ltime|log.Lshortfile),
+		errorLogger:   log.New(stderr, "ERROR: ", log.Ldate|log.Ltime|log.Lshortfile),
+		minLevel:      int32(minLevel),
+		jsonFormat:    cfg != nil && cfg.LogFormat == "json",
+		redactor:      redactor,
+		stdout:        stdout,
+		stderr:        stderr,
+	}
+
+	for _, pattern := range invalid {
+		AppLogger.Warning("Ignoring invalid LOG_REDACT_PATTERNS entry %q", pattern)
 	}
 }
 
-func (l *Logger) Debug(format string, v ...interface{}) {
-	if l.minLevel <= LogDebug {
-		l.debugLogger.Output(2, fmt.Sprintf(format, v...))
+// logOutputs returns the writers for regular and error logs, adding a
+// size-rotated file when LOG_FILE is configured
+func logOutputs() (io.Writer, io.Writer) {
+	cfg := config.Get()
+	if cfg == nil || cfg.LogFile == "" {
+		return os.Stdout, os.Stderr
 	}
+
+	file := &lumberjack.Logger{
+		Filename:   cfg.LogFile,
+		MaxSize:    cfg.LogMaxSizeMB,
+		MaxBackups: cfg.LogMaxBackups,
+	}
+
+	if !cfg.LogStdout {
+		return file, file
+	}
+	return io.MultiWriter(os.Stdout, file), io.MultiWriter(os.Stderr, file)
 }
 
-func (l *Logger) Info(format string, v ...interface{}) {
-	if l.minLevel <= LogInfo {
-		l.infoLogger.Output(2, fmt.Sprintf(format, v...))
+// Level returns the lowest level that is written
+func (l *Logger) Level() LogLevel {
+	return LogLevel(atomic.LoadInt32(&l.minLevel))
+}
+
+// SetLevel changes the lowest level that is written
+func (l *Logger) SetLevel(level LogLevel) {
+	atomic.StoreInt32(&l.minLevel, int32(level))
+}
+
+// jsonEntry is a single line in LOG_FORMAT=json mode
+type jsonEntry struct {
+	Level      string `json:"level"`
+	Timestamp  string `json:"timestamp"`
+	Message    string `json:"message"`
+	File       string `json:"file,omitempty"`
+	ServerID   uint   `json:"server_id,omitempty"`
+	ServerName string `json:"server_name,omitempty"`
+}
+
+// output writes a message at the given level. depth is the number of stack
+// frames between the original caller and output, ctx is nil for plain logs
+func (l *Logger) output(depth int, level LogLevel, ctx *ContextLogger, format string, v ...interface{}) {
+	if l.Level() > level {
+		return
 	}
+
+	msg := l.redactor.Redact(fmt.Sprintf(format, v...))
+
+	if l.jsonFormat {
+		l.writeJSON(depth+1, level, ctx, msg)
+		return
+	}
+
+	if ctx != nil {
+		msg = ctx.prefix() + msg
+	}
+	l.textLogger(level).Output(depth+1, msg)
 }
 
-func (l *Logger) Warning(format string, v ...interface{}) {
-	if l.minLevel <= LogWarning {
-		l.warningLogger.Output(2, fmt.Sprintf(format, v...))
+func (l *Logger) textLogger(level LogLevel) *log.Logger {
+	switch level {
+	case LogDebug:
+		return l.debugLogger
+	case LogInfo:
+		return l.infoLogger
+	case LogWarning:
+		return l.warningLogger
+	default:
+		return l.errorLogger
 	}
 }
 
-func (l *Logger) Error(format string, v ...interface{}) {
-	if l.minLevel <= LogError {
-		l.errorLogger.Output(2, fmt.Sprintf(format, v...))
+func (l *Logger) writeJSON(depth int, level LogLevel, ctx *ContextLogger, msg string) {
+	entry := jsonEntry{
+		Level:     level.String(),
+		Timestamp: time.Now().Format(time.RFC3339Nano),
+		Message:   msg,
+	}
+
+	if _, file, line, ok := runtime.Caller(depth); ok {
+		entry.File = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+
+	if ctx != nil {
+		entry.ServerID = ctx.serverID
+		entry.ServerName = ctx.serverName
 	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	out := l.stdout
+	if level == LogError {
+		out = l.stderr
+	}
+	out.Write(append(data, '\n'))
+}
+
+func (l *Logger) Debug(format string, v ...interface{}) {
+	l.output(2, LogDebug, nil, format, v...)
+}
+
+func (l *Logger) Info(format string, v ...interface{}) {
+	l.output(2, LogInfo, nil, format, v...)
+}
+
+func (l *Logger) Warning(format string, v ...interface{}) {
+	l.output(2, LogWarning, nil, format, v...)
+}
+
+func (l *Logger) Error(format string, v ...interface{}) {
+	l.output(2, LogError, nil, format, v...)
 }
 
 // Structured logging with context
@@ -80,19 +232,19 @@ func (c *ContextLogger) prefix() string {
 }
 
 func (c *ContextLogger) Debug(format string, v ...interface{}) {
-	c.logger.Debug(c.prefix()+format, v...)
+	c.logger.output(2, LogDebug, c, format, v...)
 }
 
 func (c *ContextLogger) Info(format string, v ...interface{}) {
-	c.logger.Info(c.prefix()+format, v...)
+	c.logger.output(2, LogInfo, c, format, v...)
 }
 
 func (c *ContextLogger) Warning(format string, v ...interface{}) {
-	c.logger.Warning(c.prefix()+format, v...)
+	c.logger.output(2, LogWarning, c, format, v...)
 }
 
 func (c *ContextLogger) Error(format string, v ...interface{}) {
-	c.logger.Error(c.prefix()+format, v...)
+	c.logger.output(2, LogError, c, format, v...)
 }
 
 // FormatUptime converts seconds to human readable format
@@ -121,6 +273,23 @@ func ParseDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
 
+// FormatBytes formats a byte count with a 1024-based unit, e.g. "1.50 GB"
+func FormatBytes(bytes uint64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	value := float64(bytes)
+	units := []string{"KB", "MB", "GB", "TB", "PB", "EB"}
+	i := -1
+	for value >= unit && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.2f %s", value, units[i])
+}
+
 // FormatPercent formats a float as percentage string
 func FormatPercent(v float64) string {
 	return fmt.Sprintf("%.2f%%", v)
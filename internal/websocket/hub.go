@@ -3,6 +3,7 @@ package websocket
 import (
 	"encoding/json"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -14,15 +15,54 @@ import (
 
 type MessageType string
 
+// In delta mode (WS_DELTA_MODE=true) workers send server_metrics_delta
+// messages instead of full server_metrics snapshots:
+//
+//	{"type": "server_metrics_delta", "payload": {
+//	    "server_id": 1, "server_name": "web", "timestamp": 1700000000,
+//	    "changes": {"cpu_usage": 12.5, "mem_used": 2048}}}
+//
+// changes holds the MetricSnapshot JSON fields that moved since they were
+// last sent. A full server_metrics snapshot is sent on subscribe and in
+// reply to request_snapshot so clients have a base to apply deltas to.
+//
+// In either mode, subscribing replays the last known snapshot right away,
+// or a server_status message with status "pending" if none exists yet.
 const (
-	MessageTypeMetrics   MessageType = "server_metrics"
-	MessageTypeStatus    MessageType = "server_status"
-	MessageTypePing      MessageType = "ping"
-	MessageTypePong      MessageType = "pong"
-	MessageTypeSubscribe MessageType = "subscribe"
-	MessageTypeError     MessageType = "error"
+	MessageTypeMetrics         MessageType = "server_metrics"
+	MessageTypeMetricsDelta    MessageType = "server_metrics_delta"
+	MessageTypeStatus          MessageType = "server_status"
+	MessageTypePing            MessageType = "ping"
+	MessageTypePong            MessageType = "pong"
+	MessageTypeSubscribe       MessageType = "subscribe"
+	MessageTypeSnapshotRequest MessageType = "request_snapshot"
+	MessageTypeError           MessageType = "error"
+
+	// Log tailing, see handlers.TailLogWebSocket
+	MessageTypeTailStart MessageType = "tail_start"
+	MessageTypeTailStop  MessageType = "tail_stop"
+	MessageTypeLogLine   MessageType = "log_line"
+
+	// Interactive shell, see handlers.ShellWebSocket
+	MessageTypeShellInput  MessageType = "shell_input"
+	MessageTypeShellResize MessageType = "shell_resize"
+	MessageTypeShellOutput MessageType = "shell_output"
+	MessageTypeShellExit   MessageType = "shell_exit"
+
+	// Server list changes, the payload is the ServerDTO
+	MessageTypeServerAdded   MessageType = "server_added"
+	MessageTypeServerUpdated MessageType = "server_updated"
+	MessageTypeServerRemoved MessageType = "server_removed"
 )
 
+// MetricsDelta is the payload of a server_metrics_delta message
+type MetricsDelta struct {
+	ServerID   uint                   `json:"server_id"`
+	ServerName string                 `json:"server_name"`
+	Timestamp  int64                  `json:"timestamp"`
+	Changes    map[string]interface{} `json:"changes"`
+}
+
 type Message struct {
 	Type    MessageType `json:"type"`
 	Payload interface{} `json:"payload"`
@@ -30,11 +70,24 @@ type Message struct {
 
 type Client struct {
 	ID            string
+	remoteIP      string // Counted against WS_MAX_PER_IP until unregistered
 	conn          *websocket.Conn
 	hub           *WebSocketHub
 	send          chan []byte
 	subscriptions map[uint]bool
+	closed        bool // send has been closed by the hub
 	mu            sync.Mutex
+
+	dropped          uint64 // Total messages dropped because send was full
+	consecutiveDrops int32
+	evicted          int32 // Set once the client is disconnected for slowness
+}
+
+// ClientStats describes a connected client for observability
+type ClientStats struct {
+	ID              string `json:"id"`
+	DroppedMessages uint64 `json:"dropped_messages"`
+	Subscriptions   int    `json:"subscriptions"`
 }
 
 type WebSocketHub struct {
@@ -44,6 +97,16 @@ type WebSocketHub struct {
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.RWMutex
+
+	// Latest full snapshot per server, replayed to new subscribers
+	lastSnapshots map[uint]*models.MetricSnapshot
+	snapshotMu    sync.RWMutex
+
+	// Open connections per client IP, including shells and log tails
+	connections  map[string]int
+	connectionMu sync.Mutex
+
+	running int32 // Set while Run is processing events
 }
 
 var Hub *WebSocketHub
@@ -55,10 +118,16 @@ func InitHub() {
 		broadcast:  make(chan []byte, 256),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+
+		lastSnapshots: make(map[uint]*models.MetricSnapshot),
+		connections:   make(map[string]int),
 	}
 }
 
 func (h *WebSocketHub) Run() {
+	atomic.StoreInt32(&h.running, 1)
+	defer atomic.StoreInt32(&h.running, 0)
+
 	for {
 		select {
 		case client := <-h.register:
@@ -71,12 +140,14 @@ func (h *WebSocketHub) Run() {
 			h.mu.Lock()
 			if _, ok := h.clients[client]; ok {
 				delete(h.clients, client)
+				client.mu.Lock()
+				client.closed = true
 				close(client.send)
 				for serverID := range client.subscriptions {
-					if room, exists := h.rooms[serverID]; exists {
-						delete(room, client)
-					}
+					h.leaveRoom(client, serverID)
 				}
+				client.mu.Unlock()
+				h.ReleaseConnection(client.remoteIP)
 			}
 			h.mu.Unlock()
 			utils.AppLogger.Info("WebSocket client disconnected: %s", client.ID)
@@ -84,10 +155,7 @@ func (h *WebSocketHub) Run() {
 		case message := <-h.broadcast:
 			h.mu.RLock()
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-				}
+				h.queue(client, message)
 			}
 			h.mu.RUnlock()
 		}
@@ -107,10 +175,72 @@ func (h *WebSocketHub) BroadcastMetrics(metrics *models.MetricSnapshot) {
 		return
 	}
 
+	h.storeSnapshot(metrics)
 	h.broadcast <- data
 	h.broadcastToRoom(metrics.ServerID, data)
 }
 
+// BroadcastMetricsDelta sends only the changed fields of a snapshot
+func (h *WebSocketHub) BroadcastMetricsDelta(metrics *models.MetricSnapshot, changes map[string]interface{}) {
+	msg := Message{
+		Type: MessageTypeMetricsDelta,
+		Payload: MetricsDelta{
+			ServerID:   metrics.ServerID,
+			ServerName: metrics.ServerName,
+			Timestamp:  metrics.Timestamp,
+			Changes:    changes,
+		},
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		utils.AppLogger.Error("Failed to marshal metrics delta: %v", err)
+		return
+	}
+
+	h.storeSnapshot(metrics)
+	h.broadcast <- data
+	h.broadcastToRoom(metrics.ServerID, data)
+}
+
+func (h *WebSocketHub) storeSnapshot(metrics *models.MetricSnapshot) {
+	h.snapshotMu.Lock()
+	defer h.snapshotMu.Unlock()
+	h.lastSnapshots[metrics.ServerID] = metrics
+}
+
+// SendLastSnapshot sends the latest full snapshot of a server to one client,
+// or a pending status when the server has not reported since startup
+func (h *WebSocketHub) SendLastSnapshot(client *Client, serverID uint) {
+	h.snapshotMu.RLock()
+	snapshot, exists := h.lastSnapshots[serverID]
+	h.snapshotMu.RUnlock()
+
+	msg := Message{Type: MessageTypeMetrics, Payload: snapshot}
+	if !exists {
+		msg = Message{
+			Type: MessageTypeStatus,
+			Payload: map[string]interface{}{
+				"server_id": serverID,
+				"status":    models.StatusPending,
+			},
+		}
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	client.enqueue(data)
+}
+
+// ForgetServer drops the cached snapshot of a deleted server
+func (h *WebSocketHub) ForgetServer(serverID uint) {
+	h.snapshotMu.Lock()
+	defer h.snapshotMu.Unlock()
+	delete(h.lastSnapshots, serverID)
+}
+
 // BroadcastServerStatus broadcasts a server status change
 func (h *WebSocketHub) BroadcastServerStatus(serverID uint, status models.ServerStatus) {
 	msg := Message{
@@ -129,16 +259,45 @@ func (h *WebSocketHub) BroadcastServerStatus(serverID uint, status models.Server
 	h.broadcast <- data
 }
 
+// BroadcastServerEvent tells every client that a server was added, updated
+// or removed. Only the DTO is sent, so credentials never leave the server.
+func (h *WebSocketHub) BroadcastServerEvent(eventType MessageType, server models.ServerDTO) {
+	msg := Message{
+		Type:    eventType,
+		Payload: server,
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		utils.AppLogger.Error("Failed to marshal server event: %v", err)
+		return
+	}
+
+	h.broadcast <- data
+}
+
 func (h *WebSocketHub) broadcastToRoom(serverID uint, data []byte) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
-	if room, exists := h.rooms[serverID]; exists {
-		for client := range room {
-			select {
-			case client.send <- data:
-			default:
-			}
+	for client := range h.rooms[serverID] {
+		h.queue(client, data)
+	}
+}
+
+// queue sends a message without blocking. A client that drops too many
+// consecutive messages is disconnected instead of silently starved.
+func (h *WebSocketHub) queue(client *Client, message []byte) {
+	select {
+	case client.send <- message:
+		atomic.StoreInt32(&client.consecutiveDrops, 0)
+	default:
+		atomic.AddUint64(&client.dropped, 1)
+		drops := atomic.AddInt32(&client.consecutiveDrops, 1)
+		if int(drops) >= config.Get().WSMaxDroppedMessages && atomic.CompareAndSwapInt32(&client.evicted, 0, 1) {
+			utils.AppLogger.Warning("Disconnecting slow WebSocket client %s after %d dropped messages", client.ID, drops)
+			// Unregister asynchronously, the hub may be holding its own lock here
+			go func() { h.unregister <- client }()
 		}
 	}
 }
@@ -161,21 +320,51 @@ func (h *WebSocketHub) Unsubscribe(client *Client, serverID uint) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if room, exists := h.rooms[serverID]; exists {
-		delete(room, client)
-	}
+	h.leaveRoom(client, serverID)
 
 	client.mu.Lock()
 	delete(client.subscriptions, serverID)
 	client.mu.Unlock()
 }
 
-func NewClient(id string, conn *websocket.Conn, hub *WebSocketHub) *Client {
+// leaveRoom removes a client from a room, dropping the room once it is empty
+// so rooms only exist for servers someone is watching. Callers hold h.mu.
+func (h *WebSocketHub) leaveRoom(client *Client, serverID uint) {
+	room, exists := h.rooms[serverID]
+	if !exists {
+		return
+	}
+	delete(room, client)
+	if len(room) == 0 {
+		delete(h.rooms, serverID)
+	}
+}
+
+// CloseAll sends a going-away close frame to every client and closes its
+// connection. The read pumps then unregister the clients.
+func (h *WebSocketHub) CloseAll() {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	frame := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	deadline := time.Now().Add(time.Second)
+	for client := range h.clients {
+		client.conn.WriteControl(websocket.CloseMessage, frame, deadline)
+		client.conn.Close()
+	}
+
+	utils.AppLogger.Info("Closed %d WebSocket clients", len(h.clients))
+}
+
+// NewClient wraps an upgraded connection. remoteIP must have been counted
+// with AcquireConnection; it is released when the client unregisters.
+func NewClient(id, remoteIP string, conn *websocket.Conn, hub *WebSocketHub) *Client {
 	return &Client{
 		ID:            id,
+		remoteIP:      remoteIP,
 		conn:          conn,
 		hub:           hub,
-		send:          make(chan []byte, 256),
+		send:          make(chan []byte, config.Get().WSSendBuffer),
 		subscriptions: make(map[uint]bool),
 	}
 }
@@ -186,9 +375,9 @@ func (c *Client) ReadPump() {
 		c.conn.Close()
 	}()
 
-	c.conn.SetReadDeadline(time.Now().Add(config.AppConfig.WSPongWait))
+	c.conn.SetReadDeadline(time.Now().Add(config.Get().WSPongWait))
 	c.conn.SetPongHandler(func(string) error {
-		c.conn.SetReadDeadline(time.Now().Add(config.AppConfig.WSPongWait))
+		c.conn.SetReadDeadline(time.Now().Add(config.Get().WSPongWait))
 		return nil
 	})
 
@@ -205,7 +394,7 @@ func (c *Client) ReadPump() {
 }
 
 func (c *Client) WritePump() {
-	ticker := time.NewTicker(config.AppConfig.WSPingInterval)
+	ticker := time.NewTicker(config.Get().WSPingInterval)
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
@@ -214,7 +403,7 @@ func (c *Client) WritePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.conn.SetWriteDeadline(time.Now().Add(config.Get().WSWriteTimeout))
 			if !ok {
 				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
@@ -224,7 +413,7 @@ func (c *Client) WritePump() {
 			}
 
 		case <-ticker.C:
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.conn.SetWriteDeadline(time.Now().Add(config.Get().WSWriteTimeout))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
@@ -248,6 +437,11 @@ func (c *Client) handleMessage(data []byte) {
 		if msg.ServerID > 0 {
 			c.hub.Subscribe(c, msg.ServerID)
 			c.sendAck("subscribed", msg.ServerID)
+			c.hub.SendLastSnapshot(c, msg.ServerID)
+		}
+	case MessageTypeSnapshotRequest:
+		if msg.ServerID > 0 {
+			c.hub.SendLastSnapshot(c, msg.ServerID)
 		}
 	case MessageTypePing:
 		c.sendPong()
@@ -260,7 +454,7 @@ func (c *Client) sendError(message string) {
 		Payload: map[string]string{"error": message},
 	}
 	data, _ := json.Marshal(msg)
-	c.send <- data
+	c.enqueue(data)
 }
 
 func (c *Client) sendAck(action string, serverID uint) {
@@ -272,13 +466,59 @@ func (c *Client) sendAck(action string, serverID uint) {
 		},
 	}
 	data, _ := json.Marshal(msg)
-	c.send <- data
+	c.enqueue(data)
 }
 
 func (c *Client) sendPong() {
 	msg := Message{Type: MessageTypePong}
 	data, _ := json.Marshal(msg)
-	c.send <- data
+	c.enqueue(data)
+}
+
+// enqueue sends a reply to this client unless the hub already closed it
+func (c *Client) enqueue(data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	select {
+	case c.send <- data:
+	default:
+		atomic.AddUint64(&c.dropped, 1)
+	}
+}
+
+// DroppedMessages returns how many messages were dropped for this client
+func (c *Client) DroppedMessages() uint64 {
+	return atomic.LoadUint64(&c.dropped)
+}
+
+// GetClientStats returns per-client delivery statistics
+func (h *WebSocketHub) GetClientStats() []ClientStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := make([]ClientStats, 0, len(h.clients))
+	for client := range h.clients {
+		client.mu.Lock()
+		subscriptions := len(client.subscriptions)
+		client.mu.Unlock()
+
+		stats = append(stats, ClientStats{
+			ID:              client.ID,
+			DroppedMessages: client.DroppedMessages(),
+			Subscriptions:   subscriptions,
+		})
+	}
+	return stats
+}
+
+// IsRunning reports whether the hub event loop has been started
+func (h *WebSocketHub) IsRunning() bool {
+	return atomic.LoadInt32(&h.running) == 1
 }
 
 func (h *WebSocketHub) GetClientCount() int {
@@ -287,6 +527,62 @@ func (h *WebSocketHub) GetClientCount() int {
 	return len(h.clients)
 }
 
+// AcquireConnection counts a new connection from ip, refusing it when ip
+// already has WS_MAX_PER_IP open. Every accepted connection must be released.
+func (h *WebSocketHub) AcquireConnection(ip string) bool {
+	h.connectionMu.Lock()
+	defer h.connectionMu.Unlock()
+
+	if max := config.Get().WSMaxPerIP; max > 0 && h.connections[ip] >= max {
+		return false
+	}
+	h.connections[ip]++
+	return true
+}
+
+// ReleaseConnection undoes AcquireConnection
+func (h *WebSocketHub) ReleaseConnection(ip string) {
+	h.connectionMu.Lock()
+	defer h.connectionMu.Unlock()
+
+	if h.connections[ip] <= 1 {
+		delete(h.connections, ip)
+		return
+	}
+	h.connections[ip]--
+}
+
+// GetConnectionCounts returns the open connections per client IP
+func (h *WebSocketHub) GetConnectionCounts() map[string]int {
+	h.connectionMu.Lock()
+	defer h.connectionMu.Unlock()
+
+	counts := make(map[string]int, len(h.connections))
+	for ip, n := range h.connections {
+		counts[ip] = n
+	}
+	return counts
+}
+
+// GetRoomCount returns the number of servers with at least one subscriber
+func (h *WebSocketHub) GetRoomCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms)
+}
+
+// GetRoomMembers returns the IDs of the clients subscribed to a server
+func (h *WebSocketHub) GetRoomMembers(serverID uint) []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	members := make([]string, 0, len(h.rooms[serverID]))
+	for client := range h.rooms[serverID] {
+		members = append(members, client.ID)
+	}
+	return members
+}
+
 func (h *WebSocketHub) Register(client *Client) {
 	h.register <- client
 }
@@ -0,0 +1,224 @@
+package winrm
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+	"monitoring/internal/utils"
+)
+
+const (
+	defaultHTTPPort  = "5985"
+	defaultHTTPSPort = "5986"
+)
+
+// ErrCommandTimeout is returned when a command does not finish within its timeout
+var ErrCommandTimeout = errors.New("command timed out")
+
+// ErrPlainHTTP refuses to send Basic credentials over an unencrypted endpoint
+var ErrPlainHTTP = errors.New("winrm over HTTP would send the password in cleartext, use HTTPS on port 5986")
+
+// WinRMClient runs commands on a Windows server over WS-Management
+type WinRMClient struct {
+	Server    *models.Server
+	endpoint  string
+	http      *http.Client
+	password  string // Decrypted password
+	connected bool
+	lastUsed  time.Time
+	mu        sync.Mutex
+}
+
+// WinRMPool manages a pool of WinRM clients
+type WinRMPool struct {
+	clients map[uint]*WinRMClient
+	mu      sync.RWMutex
+}
+
+var Pool *WinRMPool
+
+func InitPool() {
+	Pool = &WinRMPool{
+		clients: make(map[uint]*WinRMClient),
+	}
+}
+
+func (p *WinRMPool) GetClient(server *models.Server, password string) (*WinRMClient, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, exists := p.clients[server.ID]; exists && client.IsConnected() {
+		return client, nil
+	}
+
+	client := NewClient(server, password)
+	if err := client.Connect(); err != nil {
+		return nil, err
+	}
+
+	p.clients[server.ID] = client
+	return client, nil
+}
+
+// RemoveClient removes a client from the pool
+func (p *WinRMPool) RemoveClient(serverID uint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, exists := p.clients[serverID]; exists {
+		client.Close()
+		delete(p.clients, serverID)
+	}
+}
+
+// CloseAll closes all clients in the pool
+func (p *WinRMPool) CloseAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for id, client := range p.clients {
+		client.Close()
+		delete(p.clients, id)
+	}
+}
+
+// NewClient creates a client for server. Every port but 5985 uses HTTPS;
+// the SSH default of 22 is replaced by the WinRM HTTPS port. Certificates
+// are verified unless the server sets TLSSkipVerify.
+func NewClient(server *models.Server, password string) *WinRMClient {
+	port := server.Port
+	if port == "" || port == "22" {
+		port = defaultHTTPSPort
+	}
+
+	scheme := "https"
+	if port == defaultHTTPPort {
+		scheme = "http"
+	}
+
+	cfg := config.Get()
+	return &WinRMClient{
+		Server:   server,
+		endpoint: fmt.Sprintf("%s://%s/wsman", scheme, net.JoinHostPort(server.IPAddress, port)),
+		password: password,
+		http: &http.Client{
+			Timeout: cfg.SSHCommandTimeout + cfg.SSHTimeout,
+			Transport: &http.Transport{
+				DialContext:     (&net.Dialer{Timeout: cfg.SSHTimeout}).DialContext,
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: server.TLSSkipVerify},
+			},
+		},
+	}
+}
+
+// Connect verifies the endpoint and credentials by opening a shell
+func (c *WinRMClient) Connect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.Get().SSHTimeout)
+	defer cancel()
+
+	shellID, err := c.createShell(ctx)
+	if err != nil {
+		utils.AppLogger.Error("WinRM connection failed to %s: %v", c.endpoint, err)
+		return fmt.Errorf("winrm connect failed: %w", err)
+	}
+	c.deleteShell(shellID)
+
+	c.mu.Lock()
+	c.connected = true
+	c.lastUsed = time.Now()
+	c.mu.Unlock()
+
+	utils.AppLogger.Info("WinRM connected to %s", c.endpoint)
+	return nil
+}
+
+// Close marks the client as disconnected. WinRM is stateless over HTTP so
+// there is no connection to tear down besides idle keep-alives.
+func (c *WinRMClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.connected = false
+	c.http.CloseIdleConnections()
+	return nil
+}
+
+// IsConnected checks if the last request succeeded
+func (c *WinRMClient) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
+}
+
+// Execute runs a cmd.exe command line on the remote server
+func (c *WinRMClient) Execute(command string) (string, error) {
+	return c.ExecuteWithTimeout(command, config.Get().SSHCommandTimeout)
+}
+
+// ExecutePowerShell runs a PowerShell script on the remote server
+func (c *WinRMClient) ExecutePowerShell(script string) (string, error) {
+	return c.Execute(encodePowerShell(script))
+}
+
+// ExecuteWithTimeout runs a command, terminating it after timeout
+func (c *WinRMClient) ExecuteWithTimeout(command string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	shellID, err := c.createShell(ctx)
+	if err != nil {
+		c.markFailed(err)
+		return "", fmt.Errorf("failed to create shell: %w", err)
+	}
+	defer c.deleteShell(shellID)
+
+	commandID, err := c.startCommand(ctx, shellID, command)
+	if err != nil {
+		c.markFailed(err)
+		return "", fmt.Errorf("command failed: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	exitCode, err := c.receive(ctx, shellID, commandID, &stdout, &stderr)
+	if err != nil {
+		c.terminate(shellID, commandID)
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%w after %v", ErrCommandTimeout, timeout)
+		}
+		c.markFailed(err)
+		return "", fmt.Errorf("command failed: %w", err)
+	}
+
+	if exitCode != 0 {
+		if stderr.Len() > 0 {
+			return "", fmt.Errorf("command failed: %s", stderr.String())
+		}
+		return "", fmt.Errorf("command failed: exit code %d", exitCode)
+	}
+
+	c.mu.Lock()
+	c.lastUsed = time.Now()
+	c.mu.Unlock()
+
+	return stdout.String(), nil
+}
+
+// markFailed flags the client as disconnected after transport errors so the
+// pool builds a fresh one
+func (c *WinRMClient) markFailed(err error) {
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		c.mu.Lock()
+		c.connected = false
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,76 @@
+package winrm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"monitoring/config"
+	"monitoring/internal/models"
+	"monitoring/internal/utils"
+)
+
+func TestNewClientScheme(t *testing.T) {
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		port     string
+		endpoint string
+	}{
+		{"", "https://10.0.0.5:5986/wsman"},
+		{"22", "https://10.0.0.5:5986/wsman"},
+		{"5986", "https://10.0.0.5:5986/wsman"},
+		{"8443", "https://10.0.0.5:8443/wsman"},
+		{"5985", "http://10.0.0.5:5985/wsman"},
+	}
+
+	for _, tt := range tests {
+		client := NewClient(&models.Server{IPAddress: "10.0.0.5", Port: tt.port}, "secret")
+		if client.endpoint != tt.endpoint {
+			t.Errorf("port %q: endpoint = %q, want %q", tt.port, client.endpoint, tt.endpoint)
+		}
+	}
+}
+
+func TestNewClientVerifiesCertificates(t *testing.T) {
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, skip := range []bool{false, true} {
+		client := NewClient(&models.Server{IPAddress: "10.0.0.5", TLSSkipVerify: skip}, "secret")
+		transport := client.http.Transport.(*http.Transport)
+		if transport.TLSClientConfig.InsecureSkipVerify != skip {
+			t.Errorf("TLSSkipVerify %v: InsecureSkipVerify = %v", skip, transport.TLSClientConfig.InsecureSkipVerify)
+		}
+	}
+}
+
+func TestConnectRefusesPlainHTTP(t *testing.T) {
+	if err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	utils.InitLogger(utils.LogError + 1)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	client := NewClient(&models.Server{IPAddress: "127.0.0.1", Port: "5985"}, "secret")
+	client.endpoint = strings.TrimSuffix(server.URL, "/") + "/wsman"
+
+	if err := client.Connect(); !errors.Is(err, ErrPlainHTTP) {
+		t.Fatalf("Connect() error = %v, want ErrPlainHTTP", err)
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("server received %d requests, want none", n)
+	}
+}
@@ -0,0 +1,91 @@
+package winrm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"monitoring/internal/models"
+	"monitoring/internal/utils"
+)
+
+// metricsScript gathers everything in one round trip. Memory is reported in
+// MB and disk in GB to match the Linux collector.
+const metricsScript = `
+$os = Get-CimInstance Win32_OperatingSystem
+$cpu = (Get-CimInstance Win32_Processor | Measure-Object -Property LoadPercentage -Average).Average
+$disk = Get-CimInstance Win32_LogicalDisk -Filter "DeviceID='$env:SystemDrive'"
+[pscustomobject]@{
+    cpu        = [double]$cpu
+    mem_total  = [uint64]($os.TotalVisibleMemorySize / 1024)
+    mem_free   = [uint64]($os.FreePhysicalMemory / 1024)
+    disk_total = [uint64]($disk.Size / 1GB)
+    disk_free  = [uint64]($disk.FreeSpace / 1GB)
+    uptime     = [uint64]((Get-Date) - $os.LastBootUpTime).TotalSeconds
+} | ConvertTo-Json -Compress
+`
+
+type windowsMetrics struct {
+	CPU       float64 `json:"cpu"`
+	MemTotal  uint64  `json:"mem_total"`
+	MemFree   uint64  `json:"mem_free"`
+	DiskTotal uint64  `json:"disk_total"`
+	DiskFree  uint64  `json:"disk_free"`
+	Uptime    uint64  `json:"uptime"`
+}
+
+// MetricCollector collects system metrics via WinRM and CIM queries
+type MetricCollector struct {
+	client *WinRMClient
+	logger *utils.ContextLogger
+}
+
+// NewMetricCollector creates a new metric collector
+func NewMetricCollector(client *WinRMClient) *MetricCollector {
+	return &MetricCollector{
+		client: client,
+		logger: utils.AppLogger.WithContext(client.Server.ID, client.Server.Name),
+	}
+}
+
+// CollectAll collects all metrics from the server
+func (m *MetricCollector) CollectAll() (*models.MetricSnapshot, error) {
+	output, err := m.client.ExecutePowerShell(metricsScript)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw windowsMetrics
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+
+	snapshot := &models.MetricSnapshot{
+		ServerID:   m.client.Server.ID,
+		ServerName: m.client.Server.Name,
+		Timestamp:  time.Now().Unix(),
+		CPUUsage:   raw.CPU,
+		MemTotal:   raw.MemTotal,
+		MemFree:    raw.MemFree,
+		DiskTotal:  raw.DiskTotal,
+		DiskFree:   raw.DiskFree,
+		Uptime:     raw.Uptime,
+	}
+
+	if raw.MemTotal >= raw.MemFree {
+		snapshot.MemUsed = raw.MemTotal - raw.MemFree
+	}
+	if raw.MemTotal > 0 {
+		snapshot.MemPercent = float64(snapshot.MemUsed) / float64(raw.MemTotal) * 100
+	}
+
+	if raw.DiskTotal >= raw.DiskFree {
+		snapshot.DiskUsed = raw.DiskTotal - raw.DiskFree
+	}
+	if raw.DiskTotal > 0 {
+		snapshot.DiskPercent = float64(snapshot.DiskUsed) / float64(raw.DiskTotal) * 100
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,231 @@
+package winrm
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf16"
+)
+
+// Minimal WS-Management client for the Windows Remote Shell, covering only
+// what is needed to run a command and read its output.
+
+const (
+	resourceShellCmd = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/cmd"
+
+	actionCreate  = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Create"
+	actionDelete  = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Delete"
+	actionCommand = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Command"
+	actionReceive = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Receive"
+	actionSignal  = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Signal"
+
+	signalTerminate  = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/signal/terminate"
+	commandStateDone = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/CommandState/Done"
+
+	maxEnvelopeSize  = 153600
+	operationTimeout = "PT20S"
+)
+
+var errOperationTimeout = errors.New("wsman operation timeout")
+
+type envelope struct {
+	action   string
+	shellID  string
+	options  string
+	body     string
+	endpoint string
+}
+
+func (e envelope) marshal() []byte {
+	var b strings.Builder
+	b.WriteString(`<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd" xmlns:rsp="http://schemas.microsoft.com/wbem/wsman/1/windows/shell"><env:Header>`)
+	fmt.Fprintf(&b, `<a:To>%s</a:To>`, e.endpoint)
+	b.WriteString(`<a:ReplyTo><a:Address env:mustUnderstand="true">http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</a:Address></a:ReplyTo>`)
+	fmt.Fprintf(&b, `<w:MaxEnvelopeSize env:mustUnderstand="true">%d</w:MaxEnvelopeSize>`, maxEnvelopeSize)
+	fmt.Fprintf(&b, `<a:MessageID>uuid:%s</a:MessageID>`, newUUID())
+	b.WriteString(`<w:Locale env:mustUnderstand="false" xml:lang="en-US"/>`)
+	fmt.Fprintf(&b, `<w:OperationTimeout>%s</w:OperationTimeout>`, operationTimeout)
+	fmt.Fprintf(&b, `<w:ResourceURI env:mustUnderstand="true">%s</w:ResourceURI>`, resourceShellCmd)
+	fmt.Fprintf(&b, `<a:Action env:mustUnderstand="true">%s</a:Action>`, e.action)
+	if e.shellID != "" {
+		fmt.Fprintf(&b, `<w:SelectorSet><w:Selector Name="ShellId">%s</w:Selector></w:SelectorSet>`, e.shellID)
+	}
+	b.WriteString(e.options)
+	b.WriteString(`</env:Header><env:Body>`)
+	b.WriteString(e.body)
+	b.WriteString(`</env:Body></env:Envelope>`)
+	return []byte(b.String())
+}
+
+type soapFault struct {
+	Code   string `xml:"Body>Fault>Code>Subcode>Value"`
+	Reason string `xml:"Body>Fault>Reason>Text"`
+}
+
+// post sends an envelope and returns the raw response body
+func (c *WinRMClient) post(ctx context.Context, env envelope) ([]byte, error) {
+	env.endpoint = c.endpoint
+	if strings.HasPrefix(c.endpoint, "http://") {
+		return nil, ErrPlainHTTP
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(env.marshal()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/soap+xml;charset=UTF-8")
+	req.SetBasicAuth(c.Server.Username, c.password)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("winrm request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read winrm response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, fmt.Errorf("winrm authentication failed")
+	case resp.StatusCode != http.StatusOK:
+		var fault soapFault
+		if xml.Unmarshal(data, &fault) == nil && fault.Reason != "" {
+			if strings.HasSuffix(fault.Code, "TimedOut") {
+				return nil, errOperationTimeout
+			}
+			return nil, fmt.Errorf("winrm fault: %s", strings.TrimSpace(fault.Reason))
+		}
+		return nil, fmt.Errorf("winrm returned HTTP %d", resp.StatusCode)
+	}
+
+	return data, nil
+}
+
+func (c *WinRMClient) createShell(ctx context.Context) (string, error) {
+	data, err := c.post(ctx, envelope{
+		action:  actionCreate,
+		options: `<w:OptionSet><w:Option Name="WINRS_NOPROFILE">TRUE</w:Option><w:Option Name="WINRS_CODEPAGE">65001</w:Option></w:OptionSet>`,
+		body:    `<rsp:Shell><rsp:InputStreams>stdin</rsp:InputStreams><rsp:OutputStreams>stdout stderr</rsp:OutputStreams></rsp:Shell>`,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		ShellID string `xml:"Body>Shell>ShellId"`
+	}
+	if err := xml.Unmarshal(data, &resp); err != nil || resp.ShellID == "" {
+		return "", fmt.Errorf("invalid create shell response")
+	}
+	return resp.ShellID, nil
+}
+
+func (c *WinRMClient) deleteShell(shellID string) {
+	c.post(context.Background(), envelope{action: actionDelete, shellID: shellID})
+}
+
+func (c *WinRMClient) startCommand(ctx context.Context, shellID, command string) (string, error) {
+	var body bytes.Buffer
+	body.WriteString(`<rsp:CommandLine><rsp:Command>`)
+	xml.EscapeText(&body, []byte(command))
+	body.WriteString(`</rsp:Command></rsp:CommandLine>`)
+
+	data, err := c.post(ctx, envelope{
+		action:  actionCommand,
+		shellID: shellID,
+		options: `<w:OptionSet><w:Option Name="WINRS_CONSOLEMODE_STDIN">TRUE</w:Option><w:Option Name="WINRS_SKIP_CMD_SHELL">FALSE</w:Option></w:OptionSet>`,
+		body:    body.String(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		CommandID string `xml:"Body>CommandResponse>CommandId"`
+	}
+	if err := xml.Unmarshal(data, &resp); err != nil || resp.CommandID == "" {
+		return "", fmt.Errorf("invalid command response")
+	}
+	return resp.CommandID, nil
+}
+
+type receiveResponse struct {
+	Streams []struct {
+		Name string `xml:"Name,attr"`
+		Data string `xml:",chardata"`
+	} `xml:"Body>ReceiveResponse>Stream"`
+	State struct {
+		State    string `xml:"State,attr"`
+		ExitCode int    `xml:"ExitCode"`
+	} `xml:"Body>ReceiveResponse>CommandState"`
+}
+
+// receive polls output until the command finishes and returns its exit code
+func (c *WinRMClient) receive(ctx context.Context, shellID, commandID string, stdout, stderr io.Writer) (int, error) {
+	body := fmt.Sprintf(`<rsp:Receive><rsp:DesiredStream CommandId="%s">stdout stderr</rsp:DesiredStream></rsp:Receive>`, commandID)
+
+	for {
+		data, err := c.post(ctx, envelope{action: actionReceive, shellID: shellID, body: body})
+		if errors.Is(err, errOperationTimeout) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		var resp receiveResponse
+		if err := xml.Unmarshal(data, &resp); err != nil {
+			return 0, fmt.Errorf("invalid receive response: %w", err)
+		}
+
+		for _, stream := range resp.Streams {
+			decoded, err := base64.StdEncoding.DecodeString(stream.Data)
+			if err != nil {
+				continue
+			}
+			if stream.Name == "stderr" {
+				stderr.Write(decoded)
+			} else {
+				stdout.Write(decoded)
+			}
+		}
+
+		if resp.State.State == commandStateDone {
+			return resp.State.ExitCode, nil
+		}
+	}
+}
+
+func (c *WinRMClient) terminate(shellID, commandID string) {
+	body := fmt.Sprintf(`<rsp:Signal CommandId="%s"><rsp:Code>%s</rsp:Code></rsp:Signal>`, commandID, signalTerminate)
+	c.post(context.Background(), envelope{action: actionSignal, shellID: shellID, body: body})
+}
+
+// encodePowerShell returns a command line running script through -EncodedCommand
+func encodePowerShell(script string) string {
+	units := utf16.Encode([]rune(script))
+	raw := make([]byte, len(units)*2)
+	for i, u := range units {
+		raw[i*2] = byte(u)
+		raw[i*2+1] = byte(u >> 8)
+	}
+	return "powershell.exe -NoProfile -NonInteractive -EncodedCommand " + base64.StdEncoding.EncodeToString(raw)
+}
+
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}